@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -9,10 +10,17 @@ import (
 	"time"
 
 	"arachne/internal/api"
+	"arachne/internal/assets"
 	"arachne/internal/config"
+	"arachne/internal/dedup"
+	"arachne/internal/har"
 	"arachne/internal/processor"
+	"arachne/internal/replay"
 	"arachne/internal/scraper"
+	"arachne/internal/storage"
+	"arachne/internal/strategy"
 	"arachne/internal/types"
+	"arachne/internal/validation"
 )
 
 func main() {
@@ -70,9 +78,41 @@ func setupConfig() *config.Config {
 		useHeadless    = flag.Bool("headless", false, "Use headless browser for JavaScript-rendered sites")
 		maxPages       = flag.Int("max-pages", 10, "Maximum pages to scrape for pagination")
 		_              = flag.String("site", "", "Single site URL to scrape with pagination")
-		storageBackend = flag.String("storage", "json", "Storage backend (json, memory)")
+		storageBackend = flag.String("storage", "", "Storage backend (json, memory, sqlite, postgres; default json)")
+		sqlitePath     = flag.String("sqlite-path", "", "Database file of the sqlite storage backend")
+		postgresDSN    = flag.String("postgres-dsn", "", "Connection string of the postgres storage backend")
 		enablePlugins  = flag.Bool("plugins", true, "Enable data processing plugins")
+		pluginPolicy   = flag.String("plugin-error-policy", "", "What a failing plugin does to its record (abort, skip, continue)")
+		pluginTimeout  = flag.Duration("plugin-timeout", 10*time.Second, "Time limit of one plugin run (0 = none)")
+		pluginConfig   = flag.String("plugin-config", "", "JSON file listing external process plugins")
+		wasmPlugins    = flag.String("wasm-plugins", "", "Directory of WebAssembly plugins to load")
 		_              = flag.Int("api-port", 0, "Start API server on port (0 = disabled)")
+		tlsConfig      = flag.String("tls-config", "", "JSON file with per-domain TLS profiles")
+		authConfig     = flag.String("auth-config", "", "JSON file with per-domain auth profiles")
+		harFile        = flag.String("har", "", "Record all requests to a HAR file")
+		harBodies      = flag.Bool("har-bodies", false, "Include response bodies in the HAR file")
+		record         = flag.Bool("record", false, "Record HTTP responses as replay fixtures")
+		replayFixtures = flag.Bool("replay", false, "Serve HTTP responses from recorded fixtures (offline)")
+		fixturesDir    = flag.String("fixtures", "", "Directory for record/replay fixtures")
+		strategyName   = flag.String("strategy", "", "Force a scraping strategy (http, headless, feed)")
+		feedFollow     = flag.Bool("feed-follow", false, "Scrape the linked page of every feed entry")
+		schemaFile     = flag.String("schema", "", "JSON extraction schema producing structured records")
+		validateFile   = flag.String("validate", "", "JSON Schema the extracted records are validated against")
+		readability    = flag.Bool("readability", false, "Extract the main article text of HTML pages")
+		markdown       = flag.Bool("markdown", false, "Also render extracted articles as Markdown")
+		extractLinks   = flag.Bool("links", false, "Return the outgoing links of every HTML page")
+		extractTables  = flag.Bool("tables", false, "Extract HTML tables as rows and export them as CSV")
+		tableSelector  = flag.String("table-selector", "", "CSS selector of the tables to extract (implies --tables)")
+		tableIndexes   = flag.String("table-index", "", "Comma-separated indexes of the tables to extract (implies --tables)")
+		dedupMode      = flag.String("dedup", "", "Handle near-duplicate pages within a run (mark, drop)")
+		dedupThreshold = flag.Int("dedup-threshold", config.DefaultDedupThreshold, "Max SimHash Hamming distance for near-duplicates")
+		detectChanges  = flag.Bool("detect-changes", false, "Compare pages with their previous scrape in the storage backend")
+		downloadAssets = flag.Bool("assets", false, "Download page images, stylesheets, scripts and documents")
+		assetKinds     = flag.String("asset-kinds", "", "Comma-separated asset kinds to download (image, stylesheet, script, document)")
+		assetExts      = flag.String("asset-extensions", "", "Comma-separated link extensions downloaded as documents (default .pdf)")
+		assetDir       = flag.String("asset-dir", "", "Directory of the content-addressed asset store")
+		bodyMode       = flag.String("body", "", "Include response bodies in results (inline, store)")
+		bodyDir        = flag.String("body-dir", "", "Directory of stored response bodies")
 	)
 	flag.Parse()
 
@@ -92,26 +132,180 @@ func setupConfig() *config.Config {
 	cfg.UserAgent = *userAgent
 	cfg.UseHeadless = *useHeadless
 	cfg.MaxPages = *maxPages
-	cfg.StorageBackend = *storageBackend
+	if *storageBackend != "" {
+		cfg.StorageBackend = *storageBackend
+	}
+	if *sqlitePath != "" {
+		cfg.SQLitePath = *sqlitePath
+	}
+	if *postgresDSN != "" {
+		cfg.PostgresDSN = *postgresDSN
+	}
 	cfg.EnablePlugins = *enablePlugins
+	if *pluginPolicy != "" {
+		cfg.PluginErrorPolicy = *pluginPolicy
+	}
+	if *pluginTimeout != 10*time.Second {
+		cfg.PluginTimeout = *pluginTimeout
+	}
+	if *pluginConfig != "" {
+		cfg.PluginConfigFile = *pluginConfig
+	}
+	if *wasmPlugins != "" {
+		cfg.WASMPluginDir = *wasmPlugins
+	}
+	if *tlsConfig != "" {
+		cfg.TLSConfigFile = *tlsConfig
+	}
+	if *authConfig != "" {
+		cfg.AuthConfigFile = *authConfig
+	}
+
+	if *harFile != "" {
+		cfg.HARFile = *harFile
+	}
+	if *harBodies {
+		cfg.HARIncludeBodies = true
+	}
+
+	if *record && *replayFixtures {
+		log.Fatalf("Configuration error: --record and --replay cannot be combined")
+	}
+	if *record {
+		cfg.ReplayMode = replay.ModeRecord
+	}
+	if *replayFixtures {
+		cfg.ReplayMode = replay.ModeReplay
+	}
+	if *fixturesDir != "" {
+		cfg.FixturesDir = *fixturesDir
+	}
+
+	if *strategyName != "" {
+		cfg.Strategy = *strategyName
+	}
+	if *feedFollow {
+		cfg.FeedFollowLinks = true
+	}
+	if *schemaFile != "" {
+		cfg.SchemaFile = *schemaFile
+	}
+	if *validateFile != "" {
+		cfg.ValidationSchemaFile = *validateFile
+	}
+	if *readability {
+		cfg.Readability = true
+	}
+	if *markdown {
+		cfg.Readability = true
+		cfg.ReadabilityMarkdown = true
+	}
+	if *extractLinks {
+		cfg.ExtractLinks = true
+	}
+	if *extractTables {
+		cfg.ExtractTables = true
+	}
+	if *tableSelector != "" {
+		cfg.ExtractTables = true
+		cfg.TableSelector = *tableSelector
+	}
+	if *tableIndexes != "" {
+		indexes, err := config.ParseTableIndexes(*tableIndexes)
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		cfg.ExtractTables = true
+		cfg.TableIndexes = indexes
+	}
+	if *dedupMode != "" {
+		cfg.Dedup = *dedupMode
+	}
+	if *dedupThreshold != config.DefaultDedupThreshold {
+		cfg.DedupThreshold = *dedupThreshold
+	}
+	if *detectChanges {
+		cfg.DetectChanges = true
+	}
+	if *downloadAssets {
+		cfg.DownloadAssets = true
+	}
+	if *assetKinds != "" {
+		cfg.AssetKinds = config.ParseList(*assetKinds)
+	}
+	if *assetExts != "" {
+		cfg.AssetExtensions = config.ParseList(*assetExts)
+	}
+	if *assetDir != "" {
+		cfg.AssetDir = *assetDir
+	}
+	if *bodyMode != "" {
+		cfg.BodyMode = *bodyMode
+	}
+	if *bodyDir != "" {
+		cfg.BodyDir = *bodyDir
+	}
+
+	// Load per-domain TLS profiles
+	if err := cfg.LoadTLSProfiles(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// Load per-domain auth profiles
+	if err := cfg.LoadAuthProfiles(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// Load the extraction schema
+	if err := cfg.LoadSchema(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// Load the JSON Schema validating the records
+	if err := cfg.LoadValidationSchema(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// Load the external plugins
+	if err := cfg.LoadPluginConfig(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("Configuration error: %v", err)
 	}
+	if cfg.ValidationSchema != nil {
+		if _, err := validation.Compile(cfg.ValidationSchema); err != nil {
+			log.Fatalf("Configuration error: invalid validation schema: %v", err)
+		}
+	}
+
+	// Attach a HAR recorder for the whole run
+	if cfg.HARFile != "" {
+		cfg.HARRecorder = har.NewRecorder(cfg.HARIncludeBodies)
+	}
 
 	return cfg
 }
 
 // runScrapingLogic executes the main scraping operation
-func runScrapingLogic(s *scraper.Scraper, _ *config.Config) []types.ScrapedData {
+func runScrapingLogic(s *scraper.Scraper, cfg *config.Config) []types.ScrapedData {
 	start := time.Now()
 
+	// Strategy selection and feed following are applied by the strategy crawler
+	scrapeURLs, scrapeSite := s.ScrapeURLs, s.ScrapeSite
+	if cfg.Strategy != "" || len(cfg.URLStrategies) > 0 || cfg.FeedFollowLinks {
+		crawler := strategy.NewCrawler(cfg)
+		scrapeURLs = func(urls []string) []types.ScrapedData { return crawler.ScrapeURLsWithConfig(urls, cfg) }
+		scrapeSite = func(siteURL string) []types.ScrapedData { return crawler.ScrapeSiteWithConfig(siteURL, cfg) }
+	}
+
 	// Check if we're scraping a single site with pagination
 	siteURL := flag.Lookup("site").Value.String()
 	if siteURL != "" {
 		fmt.Printf("🌐 Scraping site with pagination: %s\n", siteURL)
-		results := s.ScrapeSite(siteURL)
+		results := scrapeSite(siteURL)
 		fmt.Printf("\n⏱️  Total time: %v\n", time.Since(start))
 		return results
 	}
@@ -128,7 +322,7 @@ func runScrapingLogic(s *scraper.Scraper, _ *config.Config) []types.ScrapedData
 		"https://httpbin.org/status/429",               // Rate limit (retryable)
 	}
 	fmt.Printf("Scraping %d URLs with rate limiting...\n", len(urls))
-	results := s.ScrapeURLs(urls)
+	results := scrapeURLs(urls)
 
 	fmt.Printf("\n⏱️  Total time: %v\n", time.Since(start))
 	return results
@@ -136,6 +330,24 @@ func runScrapingLogic(s *scraper.Scraper, _ *config.Config) []types.ScrapedData
 
 // processAndSaveResults handles result processing, display, and file export
 func processAndSaveResults(s *scraper.Scraper, cfg *config.Config, results []types.ScrapedData) {
+	// Mark or drop near-duplicate pages
+	for i := range results {
+		dedup.FingerprintBody(&results[i])
+	}
+	results = dedup.Filter(results, cfg.Dedup, cfg.DedupThreshold)
+
+	// Download page assets into the content-addressed store
+	if cfg.DownloadAssets {
+		fmt.Printf("\n📦 Downloading assets to %s...\n", cfg.AssetDir)
+		downloader := assets.NewDownloader(cfg, strategy.NewHTTPClient(cfg), assets.GuardFrom(s, cfg))
+		downloader.Process(context.Background(), results)
+	}
+
+	// Inline response bodies or store them for reference
+	if err := processor.AttachBodies(results, cfg.BodyMode, cfg.BodyDir); err != nil {
+		fmt.Printf("❌ Failed to attach bodies: %v\n", err)
+	}
+
 	// Process and display results
 	proc := &processor.ResultProcessor{}
 	proc.ProcessResults(results)
@@ -152,10 +364,31 @@ func processAndSaveResults(s *scraper.Scraper, cfg *config.Config, results []typ
 		fmt.Printf("❌ Failed to export results: %v\n", err)
 	}
 
+	// Keep the results queryable in the database
+	if cfg.StorageBackend == "sqlite" || cfg.StorageBackend == "postgres" {
+		saveToDatabase(cfg, results)
+	}
+
+	// Export extracted tables as CSV files next to the JSON
+	if cfg.ExtractTables {
+		if err := proc.ExportTablesToCSV(results, cfg.OutputFile); err != nil {
+			fmt.Printf("❌ Failed to export tables: %v\n", err)
+		}
+	}
+
 	// Export metrics if enabled
 	if cfg.EnableMetrics {
 		exportMetrics(s)
 	}
+
+	// Export HAR log if recording
+	if cfg.HARRecorder != nil {
+		if err := cfg.HARRecorder.WriteFile(cfg.HARFile); err != nil {
+			fmt.Printf("❌ Failed to write HAR file: %v\n", err)
+		} else {
+			fmt.Printf("✅ HAR saved to %s\n", cfg.HARFile)
+		}
+	}
 }
 
 // printCircuitBreakerStats displays circuit breaker statistics
@@ -178,6 +411,22 @@ func printCircuitBreakerStats(s *scraper.Scraper) {
 	}
 }
 
+// saveToDatabase appends results to the database storage backend
+func saveToDatabase(cfg *config.Config, results []types.ScrapedData) {
+	backend, err := storage.NewBackend(cfg)
+	if err != nil {
+		fmt.Printf("❌ Failed to open %s storage: %v\n", cfg.StorageBackend, err)
+		return
+	}
+	defer backend.Close()
+
+	if err := backend.Save(context.Background(), results); err != nil {
+		fmt.Printf("❌ Failed to save results to %s storage: %v\n", cfg.StorageBackend, err)
+		return
+	}
+	fmt.Printf("✅ Results saved to %s storage\n", cfg.StorageBackend)
+}
+
 // exportMetrics saves metrics to a JSON file
 func exportMetrics(s *scraper.Scraper) {
 	metricsFile := "scraping_metrics.json"
@@ -39,7 +39,17 @@ func TestExtractHTMLTitle(t *testing.T) {
 		{
 			name:     "Case insensitive title",
 			html:     `<html><head><TITLE>Test Title</TITLE></head><body>Content</body></html>`,
-			expected: "Malformed HTML title",
+			expected: "Test Title",
+		},
+		{
+			name:     "Title with attributes",
+			html:     `<html><head><title lang="en">Test Title</title></head><body>Content</body></html>`,
+			expected: "Test Title",
+		},
+		{
+			name:     "Title with entities",
+			html:     `<html><head><title>Fish &amp; Chips</title></head></html>`,
+			expected: "Fish & Chips",
 		},
 	}
 
@@ -89,6 +99,16 @@ func TestExtractJSONTitle(t *testing.T) {
 			json:     `{}`,
 			expected: "JSON response (no title field)",
 		},
+		{
+			name:     "Array uses the first element",
+			json:     `[{"name": "First"}, {"name": "Second"}]`,
+			expected: "First",
+		},
+		{
+			name:     "Empty array",
+			json:     `[]`,
+			expected: "JSON response (no title field)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,11 +146,17 @@ func TestExtractTitle(t *testing.T) {
 			contentType: "text/html",
 			expected:    "HTML Title",
 		},
+		{
+			name:        "XML content",
+			content:     `<?xml version="1.0"?><response><title>XML Title</title></response>`,
+			contentType: "application/xml",
+			expected:    "XML Title",
+		},
 		{
 			name:        "Array JSON",
 			content:     `[{"title": "Array Title"}]`,
 			contentType: "application/json",
-			expected:    "Invalid JSON",
+			expected:    "Array Title",
 		},
 	}
 
@@ -260,6 +286,25 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Invalid body mode",
+			config: func() *config.Config {
+				cfg := config.DefaultConfig()
+				cfg.BodyMode = "gzip"
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "WASM plugins without a timeout",
+			config: func() *config.Config {
+				cfg := config.DefaultConfig()
+				cfg.WASMPluginDir = "plugins"
+				cfg.PluginTimeout = 0
+				return cfg
+			}(),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"arachne/internal/plugins"
+)
+
+// maxWASMUpload is the largest WASM module accepted by the admin API
+const maxWASMUpload = 16 << 20
+
+// authorizeAdmin checks the admin bearer token; the admin API is disabled
+// when no token is configured
+func (h *APIHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.config.AdminToken == "" {
+		http.Error(w, "Admin API disabled", http.StatusForbidden)
+		return false
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// HandleAdminPlugins lists the plugin pipeline (GET), uploads a WASM plugin
+// (PUT ?name=<name> with the module as body) or removes one (DELETE ?name=<name>).
+// Changes apply to the jobs started afterwards. Uploaded modules are stored in
+// WASMPluginDir when set, so they are loaded again on restart.
+func (h *APIHandler) HandleAdminPlugins(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+	pm := h.plugins
+
+	switch r.Method {
+	case http.MethodGet:
+		names, err := pm.PluginNames()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"plugins": names})
+
+	case http.MethodPut:
+		name := r.URL.Query().Get("name")
+		if err := plugins.ValidatePluginName(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		binary, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWASMUpload))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read module: %v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		plugin, err := pm.LoadWASMPlugin(name, binary)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.storeWASMPlugin(name, binary); err != nil {
+			fmt.Printf("Failed to store WASM plugin: %v\n", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"name": plugin.Name(), "sha256": plugin.Hash()})
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if err := plugins.ValidatePluginName(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !pm.UnloadWASMPlugin(name) {
+			http.Error(w, "WASM plugin not found", http.StatusNotFound)
+			return
+		}
+		if h.config.WASMPluginDir != "" {
+			if err := os.Remove(filepath.Join(h.config.WASMPluginDir, name+".wasm")); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("Failed to remove WASM plugin: %v\n", err)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// storeWASMPlugin writes an uploaded module to WASMPluginDir, if configured
+func (h *APIHandler) storeWASMPlugin(name string, binary []byte) error {
+	if h.config.WASMPluginDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(h.config.WASMPluginDir, 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(h.config.WASMPluginDir, "."+name+".wasm.tmp")
+	if err := os.WriteFile(tmp, binary, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(h.config.WASMPluginDir, name+".wasm"))
+}
@@ -3,15 +3,26 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 
+	"arachne/internal/assets"
 	"arachne/internal/config"
+	"arachne/internal/dedup"
+	"arachne/internal/har"
+	"arachne/internal/metrics"
+	"arachne/internal/plugins"
+	"arachne/internal/processor"
 	"arachne/internal/storage"
+	"arachne/internal/strategy"
 	"arachne/internal/types"
+	"arachne/internal/validation"
+	"arachne/internal/webhook"
+	"arachne/pkg/parser"
 )
 
 // ScraperInterface defines the interface for scrapers
@@ -21,6 +32,17 @@ type ScraperInterface interface {
 	GetMetrics() interface{}
 }
 
+// ConfigurableScraper is implemented by scrapers that can run a job with
+// per-job configuration overrides (HAR recording, strategies and similar job
+// options). Jobs setting such options run on the strategy crawler when the
+// scraper does not implement it. Plugin options, such as readability and
+// validation, are applied by the API itself when it runs the job's plugin
+// pipeline over the results.
+type ConfigurableScraper interface {
+	ScrapeURLsWithConfig(urls []string, cfg *config.Config) []types.ScrapedData
+	ScrapeSiteWithConfig(siteURL string, cfg *config.Config) []types.ScrapedData
+}
+
 // Storage interface for job persistence
 type Storage interface {
 	SaveJob(ctx context.Context, job *storage.ScrapingJob) error
@@ -34,24 +56,54 @@ type Storage interface {
 
 // APIHandler handles HTTP API requests
 type APIHandler struct {
-	scraper ScraperInterface
-	config  *config.Config
-	storage Storage
+	scraper  ScraperInterface
+	config   *config.Config
+	storage  Storage
+	webhooks *webhook.Dispatcher
+	plugins  *plugins.PluginManager // Pipeline the results of every job go through
+	crawler  ConfigurableScraper    // Runs the jobs with options the scraper cannot apply
+	metrics  *metrics.Metrics       // Plugin and validation statistics of the jobs
+	assets   assets.Guard           // Rate limits and breakers of asset downloads, across jobs
 }
 
 // NewAPIHandler creates a new API handler
 func NewAPIHandler(scraper ScraperInterface, cfg *config.Config, storage Storage) *APIHandler {
-	return &APIHandler{
-		scraper: scraper,
-		config:  cfg,
-		storage: storage,
+	handler := &APIHandler{
+		scraper:  scraper,
+		config:   cfg,
+		storage:  storage,
+		webhooks: webhook.NewDispatcher(cfg),
+		plugins:  plugins.NewPluginManagerFromConfig(cfg),
+		crawler:  strategy.NewCrawler(cfg),
+		metrics:  metrics.NewMetrics(),
+		assets:   assets.GuardFrom(scraper, cfg),
 	}
+	handler.plugins.SetMetrics(handler.metrics)
+	return handler
 }
 
 // ScrapeRequest represents a scraping request
 type ScrapeRequest struct {
-	URLs    []string `json:"urls"`
-	SiteURL string   `json:"site_url,omitempty"`
+	URLs             []string          `json:"urls"`
+	SiteURL          string            `json:"site_url,omitempty"`
+	RecordHAR        bool              `json:"record_har,omitempty"`
+	HARIncludeBodies bool              `json:"har_include_bodies,omitempty"`
+	Strategy         string            `json:"strategy,omitempty"`          // "http", "headless" or "feed"
+	URLStrategies    map[string]string `json:"url_strategies,omitempty"`    // URL prefix -> strategy
+	FeedFollowLinks  bool              `json:"feed_follow_links,omitempty"` // Scrape the linked page of every feed entry
+	Schema           *parser.Schema    `json:"schema,omitempty"`            // Extraction schema for structured records
+	ValidationSchema json.RawMessage   `json:"validation_schema,omitempty"` // JSON Schema the records are validated against
+	Readability      bool              `json:"readability,omitempty"`       // Extract the main article content
+	Markdown         bool              `json:"markdown,omitempty"`          // Render the article as Markdown
+	ExtractLinks     bool              `json:"extract_links,omitempty"`     // Return the outgoing links of HTML pages
+	Tables           *parser.TableSpec `json:"tables,omitempty"`            // Extract HTML tables, {} selects all of them
+	Dedup            string            `json:"dedup,omitempty"`             // Near-duplicate handling: "mark" or "drop"
+	DedupThreshold   *int              `json:"dedup_threshold,omitempty"`   // Max SimHash Hamming distance of near-duplicates
+	DownloadAssets   bool              `json:"download_assets,omitempty"`   // Download page assets into the asset store
+	AssetKinds       []string          `json:"asset_kinds,omitempty"`       // Asset kinds to download, all when empty
+	AssetExtensions  []string          `json:"asset_extensions,omitempty"`  // Link extensions downloaded as documents
+	Body             string            `json:"body,omitempty"`              // Include response bodies: "inline" or "store"
+	Webhooks         []webhook.Webhook `json:"webhooks,omitempty"`          // URLs notified of the job's events
 }
 
 // ScrapeResponse represents a scraping response
@@ -64,8 +116,9 @@ type ScrapeResponse struct {
 
 // JobStatusResponse represents a job status response
 type JobStatusResponse struct {
-	Job     *storage.ScrapingJob `json:"job"`
-	Metrics interface{}          `json:"metrics,omitempty"`
+	Job        *storage.ScrapingJob `json:"job"`
+	Metrics    interface{}          `json:"metrics,omitempty"`
+	Deliveries []webhook.Delivery   `json:"deliveries,omitempty"` // Webhook delivery log
 }
 
 // HandleScrape handles scraping requests asynchronously
@@ -87,12 +140,93 @@ func (h *APIHandler) HandleScrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := config.ValidateStrategy(req.Strategy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, strategy := range req.URLStrategies {
+		if err := config.ValidateStrategy(strategy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Schema != nil {
+		if err := req.Schema.Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid schema: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.ValidationSchema != nil {
+		if _, err := validation.Compile(req.ValidationSchema); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid validation schema: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Tables != nil {
+		if err := req.Tables.Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid table selection: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := config.ValidateDedupMode(req.Dedup); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.DedupThreshold != nil && (*req.DedupThreshold < 0 || *req.DedupThreshold > 64) {
+		http.Error(w, "dedup_threshold must be between 0 and 64", http.StatusBadRequest)
+		return
+	}
+
+	if err := parser.ValidateAssetKinds(req.AssetKinds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := config.ValidateBodyMode(req.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Webhooks) > 0 && !h.webhooks.Enabled() {
+		http.Error(w, "Webhooks are disabled, no webhook secret is configured", http.StatusBadRequest)
+		return
+	}
+	if err := webhook.Validate(req.Webhooks); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Create job
 	jobID := uuid.New().String()
 	job := &storage.ScrapingJob{
-		ID:        jobID,
-		Status:    "pending",
-		Request:   storage.ScrapeRequest{URLs: req.URLs, SiteURL: req.SiteURL},
+		ID:     jobID,
+		Status: "pending",
+		Request: storage.ScrapeRequest{
+			URLs:             req.URLs,
+			SiteURL:          req.SiteURL,
+			RecordHAR:        req.RecordHAR,
+			HARIncludeBodies: req.HARIncludeBodies,
+			Strategy:         req.Strategy,
+			URLStrategies:    req.URLStrategies,
+			FeedFollowLinks:  req.FeedFollowLinks,
+			Schema:           req.Schema,
+			ValidationSchema: req.ValidationSchema,
+			Readability:      req.Readability,
+			Markdown:         req.Markdown,
+			ExtractLinks:     req.ExtractLinks,
+			Tables:           req.Tables,
+			Dedup:            req.Dedup,
+			DedupThreshold:   req.DedupThreshold,
+			DownloadAssets:   req.DownloadAssets,
+			AssetKinds:       req.AssetKinds,
+			AssetExtensions:  req.AssetExtensions,
+			Body:             req.Body,
+			Webhooks:         req.Webhooks,
+		},
 		CreatedAt: time.Now(),
 		Progress:  0,
 	}
@@ -146,7 +280,8 @@ func (h *APIHandler) HandleJobStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := JobStatusResponse{
-		Job: job,
+		Job:        job,
+		Deliveries: h.jobDeliveries(ctx, jobID),
 	}
 
 	if h.config.EnableMetrics {
@@ -164,6 +299,22 @@ func (h *APIHandler) HandleJobStatus(w http.ResponseWriter, r *http.Request) {
 func (h *APIHandler) executeScrapingJob(job *storage.ScrapingJob) {
 	ctx := context.Background()
 
+	// Webhook events are delivered in order, after the job if need be
+	notifier := h.notifierFor(job)
+	defer notifier.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			job.Status = "failed"
+			job.Error = fmt.Sprintf("job panicked: %v", r)
+			completedAt := time.Now()
+			job.CompletedAt = &completedAt
+			if err := h.storage.UpdateJob(ctx, job); err != nil {
+				fmt.Printf("Failed to update failed job: %v\n", err)
+			}
+			notifier.Notify(webhook.JobFailed, jobEvent(job))
+		}
+	}()
+
 	// Update job status to running
 	job.Status = "running"
 	now := time.Now()
@@ -172,18 +323,53 @@ func (h *APIHandler) executeScrapingJob(job *storage.ScrapingJob) {
 		// Log error but continue execution
 		fmt.Printf("Failed to update job status to running: %v\n", err)
 	}
+	notifier.Notify(webhook.JobStarted, jobEvent(job))
+
+	jobCfg := h.jobConfig(job)
+	scraped := h.runScraper(job, jobCfg)
+	failure := jobFailure(scraped)
+	results := h.processResults(ctx, jobCfg, scraped)
+	results = dedup.Filter(results, jobCfg.Dedup, jobCfg.DedupThreshold)
+
+	// Download page assets under the limits shared by all jobs
+	if jobCfg.DownloadAssets {
+		downloader := assets.NewDownloader(jobCfg, strategy.NewHTTPClient(jobCfg), h.assets)
+		downloader.Process(ctx, results)
+	}
+
+	// Inline response bodies or store them for reference
+	if err := processor.AttachBodies(results, jobCfg.BodyMode, jobCfg.BodyDir); err != nil {
+		fmt.Printf("Failed to attach bodies: %v\n", err)
+	}
 
-	var results []types.ScrapedData
+	// Persist the HAR log as a downloadable job artifact
+	if job.Request.RecordHAR {
+		if err := h.saveArtifact(job, harArtifact, jobCfg.HARRecorder.WriteFile); err != nil {
+			fmt.Printf("Failed to save HAR artifact: %v\n", err)
+		}
+	}
 
-	// Execute scraping based on request type
-	if job.Request.SiteURL != "" {
-		results = h.scraper.ScrapeSite(job.Request.SiteURL)
-	} else {
-		results = h.scraper.ScrapeURLs(job.Request.URLs)
+	// Extracted tables are downloadable as CSV artifacts
+	if jobCfg.ExtractTables {
+		h.saveTableArtifacts(job, results)
+	}
+
+	// New and changed pages stay queryable after the job expires
+	h.saveChangeEvents(ctx, job, results)
+
+	// Records are sent once final, after the plugins and dedup ran on them
+	for _, result := range results {
+		notifier.Notify(webhook.RecordScraped, result)
 	}
 
 	// Update job with results
 	job.Status = "completed"
+	event := webhook.JobCompleted
+	if failure != "" {
+		job.Status = "failed"
+		job.Error = failure
+		event = webhook.JobFailed
+	}
 	job.Results = results
 	completedAt := time.Now()
 	job.CompletedAt = &completedAt
@@ -192,6 +378,130 @@ func (h *APIHandler) executeScrapingJob(job *storage.ScrapingJob) {
 	if err := h.storage.UpdateJob(ctx, job); err != nil {
 		fmt.Printf("Failed to update job with results: %v\n", err)
 	}
+	notifier.Notify(event, jobEvent(job))
+}
+
+// jobConfig returns a copy of the handler configuration with the job's options applied
+func (h *APIHandler) jobConfig(job *storage.ScrapingJob) *config.Config {
+	cfg := *h.config
+	if job.Request.RecordHAR {
+		cfg.HARRecorder = har.NewRecorder(job.Request.HARIncludeBodies)
+	}
+	if job.Request.Strategy != "" {
+		cfg.Strategy = job.Request.Strategy
+	}
+	if len(job.Request.URLStrategies) > 0 {
+		cfg.URLStrategies = make(map[string]string, len(h.config.URLStrategies)+len(job.Request.URLStrategies))
+		for prefix, strategy := range h.config.URLStrategies {
+			cfg.URLStrategies[prefix] = strategy
+		}
+		for prefix, strategy := range job.Request.URLStrategies {
+			cfg.URLStrategies[prefix] = strategy
+		}
+	}
+	if job.Request.FeedFollowLinks {
+		cfg.FeedFollowLinks = true
+	}
+	if job.Request.Schema != nil {
+		cfg.Schema = job.Request.Schema
+	}
+	if job.Request.ValidationSchema != nil {
+		cfg.ValidationSchema = job.Request.ValidationSchema
+	}
+	if job.Request.Readability || job.Request.Markdown {
+		cfg.Readability = true
+		cfg.ReadabilityMarkdown = cfg.ReadabilityMarkdown || job.Request.Markdown
+	}
+	if job.Request.ExtractLinks {
+		cfg.ExtractLinks = true
+	}
+	if job.Request.Tables != nil {
+		cfg.ExtractTables = true
+		cfg.TableSelector = job.Request.Tables.Selector
+		cfg.TableIndexes = job.Request.Tables.Indexes
+	}
+	if job.Request.Dedup != "" {
+		cfg.Dedup = job.Request.Dedup
+	}
+	if job.Request.DedupThreshold != nil {
+		cfg.DedupThreshold = *job.Request.DedupThreshold
+	}
+	if job.Request.DownloadAssets {
+		cfg.DownloadAssets = true
+	}
+	if len(job.Request.AssetKinds) > 0 {
+		cfg.AssetKinds = job.Request.AssetKinds
+	}
+	if len(job.Request.AssetExtensions) > 0 {
+		cfg.AssetExtensions = job.Request.AssetExtensions
+	}
+	if job.Request.Body != "" {
+		cfg.BodyMode = job.Request.Body
+	}
+	return &cfg
+}
+
+// hasScraperOptions reports whether the request sets options that change
+// how pages are fetched and extracted, which only a ConfigurableScraper applies
+func hasScraperOptions(req *storage.ScrapeRequest) bool {
+	return req.RecordHAR || req.HARIncludeBodies || req.Strategy != "" || len(req.URLStrategies) > 0 ||
+		req.FeedFollowLinks || req.Schema != nil || req.ExtractLinks || req.Tables != nil ||
+		req.DownloadAssets || len(req.AssetKinds) > 0 || len(req.AssetExtensions) > 0 || req.Body != ""
+}
+
+// jobFailure returns why a job failed, or "" when it did not: a job fails
+// when the scraper returns no results, or only failed ones
+func jobFailure(results []types.ScrapedData) string {
+	for _, result := range results {
+		if result.Error == "" {
+			return ""
+		}
+	}
+	if len(results) == 0 {
+		return "no pages were scraped"
+	}
+	return fmt.Sprintf("all %d pages failed", len(results))
+}
+
+// runScraper executes the job, passing the per-job configuration when the
+// scraper supports it. Jobs with options the scraper cannot apply run on the
+// strategy crawler instead.
+func (h *APIHandler) runScraper(job *storage.ScrapingJob, cfg *config.Config) []types.ScrapedData {
+	scraper, ok := h.scraper.(ConfigurableScraper)
+	if !ok && hasScraperOptions(&job.Request) {
+		scraper, ok = h.crawler, true
+	}
+	if ok {
+		if job.Request.SiteURL != "" {
+			return scraper.ScrapeSiteWithConfig(job.Request.SiteURL, cfg)
+		}
+		return scraper.ScrapeURLsWithConfig(job.Request.URLs, cfg)
+	}
+
+	// Execute scraping based on request type
+	if job.Request.SiteURL != "" {
+		return h.scraper.ScrapeSite(job.Request.SiteURL)
+	}
+	return h.scraper.ScrapeURLs(job.Request.URLs)
+}
+
+// processResults fingerprints the results and runs them through the plugin
+// pipeline with the job's options, dropping the records a plugin with the
+// skip policy rejected
+func (h *APIHandler) processResults(ctx context.Context, cfg *config.Config, results []types.ScrapedData) []types.ScrapedData {
+	pipeline := h.plugins.ForJob(cfg)
+	processed := results[:0]
+	for i := range results {
+		dedup.FingerprintBody(&results[i])
+		if err := pipeline.ProcessData(ctx, &results[i]); err != nil {
+			if errors.Is(err, plugins.ErrSkipRecord) {
+				continue
+			}
+			fmt.Printf("Failed to process %s: %v\n", results[i].URL, err)
+		}
+		processed = append(processed, results[i])
+	}
+	return processed
 }
 
 // HandleHealth handles health check requests
@@ -215,9 +525,25 @@ func (h *APIHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metrics := h.scraper.GetMetrics()
+	// The plugins run in the API, so their statistics are added to the scraper's
+	pipeline := h.metrics.GetMetrics()
+	response := map[string]interface{}{
+		"plugins":    pipeline["plugins"],
+		"validation": pipeline["validation"],
+	}
+	scraperMetrics := h.scraper.GetMetrics()
+	if fields, ok := scraperMetrics.(map[string]interface{}); ok {
+		for name, value := range fields {
+			if _, exists := response[name]; !exists {
+				response[name] = value
+			}
+		}
+	} else if scraperMetrics != nil {
+		response["scraper"] = scraperMetrics
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(metrics); err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to encode metrics", http.StatusInternalServerError)
 		return
 	}
@@ -229,7 +555,21 @@ func StartAPIServer(scraper ScraperInterface, cfg *config.Config, port int) erro
 	var storageBackend Storage
 	var err error
 
-	if cfg.RedisAddr != "" {
+	if cfg.StorageBackend == "sqlite" {
+		// Keep jobs with the results in the database file
+		storageBackend, err = storage.NewSQLiteStorage(cfg.SQLitePath)
+		if err != nil {
+			return fmt.Errorf("failed to initialize SQLite storage: %w", err)
+		}
+		fmt.Printf("Using SQLite storage at %s\n", cfg.SQLitePath)
+	} else if cfg.StorageBackend == "postgres" {
+		// Keep jobs with the results in the shared database
+		storageBackend, err = storage.NewPostgresStorage(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
+		}
+		fmt.Println("Using PostgreSQL storage")
+	} else if cfg.RedisAddr != "" {
 		// Use Redis for persistent storage
 		storageBackend, err = storage.NewRedisStorage(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
 		if err != nil {
@@ -243,12 +583,16 @@ func StartAPIServer(scraper ScraperInterface, cfg *config.Config, port int) erro
 	}
 
 	handler := NewAPIHandler(scraper, cfg, storageBackend)
+	defer handler.plugins.Close()
 
 	// Set up routes
 	http.HandleFunc("/scrape", handler.HandleScrape)
 	http.HandleFunc("/scrape/status", handler.HandleJobStatus)
+	http.HandleFunc("/scrape/artifacts", handler.HandleJobArtifact)
 	http.HandleFunc("/health", handler.HandleHealth)
 	http.HandleFunc("/metrics", handler.HandleMetrics)
+	http.HandleFunc("/changes", handler.HandleChanges)
+	http.HandleFunc("/admin/plugins", handler.HandleAdminPlugins)
 
 	// Start server
 	addr := fmt.Sprintf(":%d", port)
@@ -256,8 +600,11 @@ func StartAPIServer(scraper ScraperInterface, cfg *config.Config, port int) erro
 	fmt.Printf("📡 Endpoints:\n")
 	fmt.Printf("   POST /scrape - Create scraping job\n")
 	fmt.Printf("   GET  /scrape/status?id=<job_id> - Get job status\n")
+	fmt.Printf("   GET  /scrape/artifacts?id=<job_id>&name=<artifact> - Download job artifact\n")
+	fmt.Printf("   GET  /changes?url=<url>&status=<new|changed>&since=<time> - List page changes\n")
 	fmt.Printf("   GET  /health - Health check\n")
 	fmt.Printf("   GET  /metrics - Get metrics\n")
+	fmt.Printf("   GET|PUT|DELETE /admin/plugins[?name=<plugin>] - Manage WASM plugins (admin token)\n")
 
 	return http.ListenAndServe(addr, nil)
 }
@@ -4,15 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"arachne/internal/config"
+	"arachne/internal/har"
+	"arachne/internal/metrics"
 	"arachne/internal/storage"
 	"arachne/internal/types"
+	"arachne/internal/webhook"
 )
 
 // MockScraper is a mock implementation for testing
@@ -53,6 +59,47 @@ func (m *MockScraper) GetMetrics() interface{} {
 	}
 }
 
+// ConfigurableMockScraper is a mock scraper that honours per-job configuration
+type ConfigurableMockScraper struct {
+	MockScraper
+}
+
+func (m *ConfigurableMockScraper) ScrapeURLsWithConfig(urls []string, cfg *config.Config) []types.ScrapedData {
+	if cfg.HARRecorder != nil {
+		for _, url := range urls {
+			cfg.HARRecorder.Add(&har.Entry{
+				StartedDateTime: time.Now(),
+				Request:         &har.Request{Method: "GET", URL: url},
+				Response:        &har.Response{Status: 200},
+			})
+		}
+	}
+	return m.ScrapeURLs(urls)
+}
+
+func (m *ConfigurableMockScraper) ScrapeSiteWithConfig(siteURL string, cfg *config.Config) []types.ScrapedData {
+	return m.ScrapeURLsWithConfig([]string{siteURL}, cfg)
+}
+
+// waitForJob polls storage until the job completes or the timeout expires
+func waitForJob(t *testing.T, storageBackend Storage, jobID string) *storage.ScrapingJob {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := storageBackend.GetJob(context.Background(), jobID)
+		if err != nil {
+			t.Fatalf("job not found in storage: %v", err)
+		}
+		if job.Status == "completed" {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not complete in time (last status: %s)", job.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
 func TestHandleHealth(t *testing.T) {
 	// Create a mock request to pass to our handler
 	req, err := http.NewRequest("GET", "/health", nil)
@@ -137,6 +184,35 @@ func TestHandleScrape(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedFields: []string{},
 		},
+
+		{
+			name:           "Invalid extraction schema",
+			method:         "POST",
+			body:           `{"urls": ["https://example.com"], "schema": {"fields": [{"name": "price", "xpath": "//span[@class"}]}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{},
+		},
+		{
+			name:           "Valid validation schema",
+			method:         "POST",
+			body:           `{"urls": ["https://example.com"], "validation_schema": {"type": "object", "required": ["price"]}}`,
+			expectedStatus: http.StatusAccepted,
+			expectedFields: []string{"job_id", "status"},
+		},
+		{
+			name:           "Invalid validation schema",
+			method:         "POST",
+			body:           `{"urls": ["https://example.com"], "validation_schema": {"type": "price"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{},
+		},
+		{
+			name:           "Invalid table selection",
+			method:         "POST",
+			body:           `{"urls": ["https://example.com"], "tables": {"indexes": [-1]}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -325,6 +401,31 @@ func TestHandleMetrics(t *testing.T) {
 		}
 	})
 
+	// The plugins of every job report their runs
+	t.Run("Plugin statistics", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.HandleScrape(rr, httptest.NewRequest("POST", "/scrape", strings.NewReader(`{"urls": ["https://example.com"]}`)))
+		var response ScrapeResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		waitForJob(t, storageBackend, response.JobID)
+
+		rr = httptest.NewRecorder()
+		handler.HandleMetrics(rr, httptest.NewRequest("GET", "/metrics", nil))
+		var body struct {
+			Retries *int                             `json:"retries"` // From the scraper
+			Plugins map[string]metrics.PluginMetrics `json:"plugins"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid metrics JSON: %v", err)
+		}
+		if body.Retries == nil {
+			t.Errorf("metrics %s lack the scraper's counters", rr.Body.String())
+		}
+		if body.Plugins["TitleCleaner"].Runs != 1 {
+			t.Errorf("plugins = %+v, want one TitleCleaner run", body.Plugins)
+		}
+	})
+
 	// Test with metrics disabled
 	t.Run("Metrics disabled", func(t *testing.T) {
 		cfg.EnableMetrics = false
@@ -515,3 +616,402 @@ func TestScrapeJobLifecycle(t *testing.T) {
 		}
 	}
 }
+
+func TestJobHARArtifact(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ArtifactDir = t.TempDir()
+	storageBackend := storage.NewInMemoryStorage()
+	handler := NewAPIHandler(&ConfigurableMockScraper{}, cfg, storageBackend)
+
+	requestBody := `{"urls": ["https://example.com", "https://test.com"], "record_har": true}`
+	req := httptest.NewRequest("POST", "/scrape", bytes.NewBufferString(requestBody))
+	rr := httptest.NewRecorder()
+	handler.HandleScrape(rr, req)
+
+	var response ScrapeResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	job := waitForJob(t, storageBackend, response.JobID)
+	if len(job.Artifacts) != 1 || job.Artifacts[0] != harArtifact {
+		t.Fatalf("expected HAR artifact on job, got %v", job.Artifacts)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{name: "Download HAR", query: "?id=" + job.ID + "&name=" + harArtifact, expectedStatus: http.StatusOK},
+		{name: "Missing name", query: "?id=" + job.ID, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown job", query: "?id=missing&name=" + harArtifact, expectedStatus: http.StatusNotFound},
+		{name: "Path traversal", query: "?id=" + job.ID + "&name=../../etc/passwd", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.HandleJobArtifact(rr, httptest.NewRequest("GET", "/scrape/artifacts"+tt.query, nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var doc har.HAR
+			if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("artifact is not valid HAR JSON: %v", err)
+			}
+			if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 {
+				t.Errorf("expected HAR 1.2 with 2 entries, got version %s with %d entries",
+					doc.Log.Version, len(doc.Log.Entries))
+			}
+		})
+	}
+}
+
+func TestJobStrategyOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/news":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`<rss version="2.0"><channel><title>News</title>` +
+				`<item><title>One</title><link>/one</link></item></channel></rss>`))
+		default:
+			w.Write([]byte(`<html><head><title>Story</title></head></html>`))
+		}
+	}))
+	defer server.Close()
+
+	// The mock scraper cannot apply the options, so the job runs on the crawler
+	storageBackend := storage.NewInMemoryStorage()
+	handler := NewAPIHandler(&MockScraper{}, config.DefaultConfig(), storageBackend)
+
+	body := fmt.Sprintf(`{"urls": [%q], "url_strategies": {%q: "feed"}, "feed_follow_links": true}`, server.URL+"/news", server.URL+"/news")
+	rr := httptest.NewRecorder()
+	handler.HandleScrape(rr, httptest.NewRequest("POST", "/scrape", strings.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("HandleScrape() status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var response ScrapeResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+
+	job := waitForJob(t, storageBackend, response.JobID)
+	if len(job.Results) != 2 {
+		t.Fatalf("expected the feed and its entry, got %+v", job.Results)
+	}
+	if job.Results[0].Strategy != "feed" || job.Results[0].Title != "News" {
+		t.Errorf("feed result = %s %q, want the feed strategy", job.Results[0].Strategy, job.Results[0].Title)
+	}
+	if job.Results[1].URL != server.URL+"/one" || job.Results[1].Title != "Story" {
+		t.Errorf("followed result = %s %q, want the entry page", job.Results[1].URL, job.Results[1].Title)
+	}
+}
+
+// RecordMockScraper is a mock scraper whose pages have extracted records
+type RecordMockScraper struct {
+	MockScraper
+}
+
+func (m *RecordMockScraper) ScrapeURLs(urls []string) []types.ScrapedData {
+	results := m.MockScraper.ScrapeURLs(urls)
+	for i := range results {
+		results[i].Records = []map[string]interface{}{{"price": 10}, {"plan": "Pro"}}
+	}
+	return results
+}
+
+func TestJobValidation(t *testing.T) {
+	storageBackend := storage.NewInMemoryStorage()
+	handler := NewAPIHandler(&RecordMockScraper{}, config.DefaultConfig(), storageBackend)
+
+	rr := submitJob(handler, `{"urls": ["https://example.com/pricing"], "validation_schema": {"$id": "pricing", "type": "object", "required": ["price"]}}`)
+	var response ScrapeResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	job := waitForJob(t, storageBackend, response.JobID)
+
+	if len(job.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(job.Results))
+	}
+	validation := job.Results[0].Validation
+	if validation == nil || validation.Valid || validation.Records != 2 || validation.Failed != 1 {
+		t.Errorf("Validation = %+v, want the record without a price flagged", validation)
+	}
+
+	// The pass rate of the schema is reported with the metrics
+	rr = httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest("GET", "/metrics", nil))
+	var body struct {
+		Validation map[string]metrics.ValidationMetrics `json:"validation"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid metrics JSON: %v", err)
+	}
+	stats := body.Validation["pricing"]
+	if stats.Records != 2 || stats.Passed != 1 || stats.Failed != 1 || stats.PassRate != 50 {
+		t.Errorf("validation metrics = %+v, want 1 of 2 records passing schema pricing", body.Validation)
+	}
+}
+
+// noopModule is a WASM plugin exporting its memory and a process function
+// that returns 0
+var noopModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // Header
+	0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f, // Types: () -> i32
+	0x03, 0x02, 0x01, 0x00, // Functions
+	0x05, 0x03, 0x01, 0x00, 0x01, // Memory of one page
+	0x07, 0x14, 0x02, // Exports
+	0x07, 'p', 'r', 'o', 'c', 'e', 's', 's', 0x00, 0x00,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x0a, 0x06, 0x01, 0x04, 0x00, 0x41, 0x00, 0x0b, // Code: i32.const 0
+}
+
+func TestHandleAdminPlugins(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AdminToken = "secret"
+	cfg.WASMPluginDir = t.TempDir()
+	handler := NewAPIHandler(&MockScraper{}, cfg, storage.NewInMemoryStorage())
+	defer handler.plugins.Close()
+
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		token          string
+		body           string
+		expectedStatus int
+	}{
+		{name: "List plugins", method: "GET", token: "secret", expectedStatus: http.StatusOK},
+		{name: "Missing token", method: "GET", expectedStatus: http.StatusUnauthorized},
+		{name: "Wrong token", method: "GET", token: "guess", expectedStatus: http.StatusUnauthorized},
+		{name: "Invalid module", method: "PUT", query: "?name=broken", token: "secret", body: "not wasm", expectedStatus: http.StatusBadRequest},
+		{name: "Invalid name", method: "PUT", query: "?name=../x", token: "secret", body: "\x00asm", expectedStatus: http.StatusBadRequest},
+		{name: "Remove built-in", method: "DELETE", query: "?name=TitleCleaner", token: "secret", expectedStatus: http.StatusNotFound},
+		{name: "Upload module", method: "PUT", query: "?name=noop", token: "secret", body: string(noopModule), expectedStatus: http.StatusCreated},
+		{name: "List uploaded", method: "GET", token: "secret", expectedStatus: http.StatusOK},
+		{name: "Remove uploaded", method: "DELETE", query: "?name=noop", token: "secret", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/plugins"+tt.query, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rr := httptest.NewRecorder()
+			handler.HandleAdminPlugins(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.name == "List plugins" && !strings.Contains(rr.Body.String(), "TitleCleaner") {
+				t.Errorf("plugin list = %s, want the built-in plugins", rr.Body.String())
+			}
+			if tt.name == "List uploaded" && !strings.Contains(rr.Body.String(), "noop") {
+				t.Errorf("plugin list = %s, want the uploaded plugin", rr.Body.String())
+			}
+		})
+	}
+
+	// Without a token the admin API is disabled
+	cfg.AdminToken = ""
+	rr := httptest.NewRecorder()
+	handler.HandleAdminPlugins(rr, httptest.NewRequest("GET", "/admin/plugins", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("disabled admin API returned %v, want %v", rr.Code, http.StatusForbidden)
+	}
+}
+
+// ChangeMockScraper is a mock scraper whose pages change on every scrape
+type ChangeMockScraper struct {
+	MockScraper
+	scrapes int
+}
+
+func (m *ChangeMockScraper) ScrapeURLs(urls []string) []types.ScrapedData {
+	m.scrapes++
+	results := m.MockScraper.ScrapeURLs(urls)
+	for i := range results {
+		results[i].ContentHash = fmt.Sprintf("hash-%d", m.scrapes)
+	}
+	return results
+}
+
+func TestHandleChanges(t *testing.T) {
+	// Changes are detected by the job pipeline against the earlier jobs
+	cfg := config.DefaultConfig()
+	cfg.DetectChanges = true
+	cfg.StorageBackend = "memory"
+	storageBackend := storage.NewInMemoryStorage()
+	handler := NewAPIHandler(&ChangeMockScraper{}, cfg, storageBackend)
+
+	// The first job sees the pages as new, the second as changed
+	for i := 0; i < 2; i++ {
+		body := `{"urls": ["https://example.com/pricing", "https://example.com/terms"]}`
+		rr := httptest.NewRecorder()
+		handler.HandleScrape(rr, httptest.NewRequest("POST", "/scrape", strings.NewReader(body)))
+		var response ScrapeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		waitForJob(t, storageBackend, response.JobID)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "All events", query: "", expectedStatus: http.StatusOK, expectedCount: 4},
+		{name: "Changed pages", query: "?status=changed", expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "One page", query: "?url=HTTPS://example.com/pricing%23plans&status=new", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "Limit", query: "?limit=1", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "Future", query: "?since=2999-01-01T00:00:00Z", expectedStatus: http.StatusOK, expectedCount: 0},
+		{name: "Invalid status", query: "?status=unchanged", expectedStatus: http.StatusBadRequest},
+		{name: "Invalid since", query: "?since=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "Invalid limit", query: "?limit=0", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.HandleChanges(rr, httptest.NewRequest("GET", "/changes"+tt.query, nil))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response ChangesResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Count != tt.expectedCount || len(response.Changes) != tt.expectedCount {
+				t.Errorf("got %d events, want %d", response.Count, tt.expectedCount)
+			}
+		})
+	}
+}
+
+// PanicMockScraper is a mock scraper whose jobs fail
+type PanicMockScraper struct {
+	MockScraper
+}
+
+func (m *PanicMockScraper) ScrapeURLs(urls []string) []types.ScrapedData {
+	panic("browser crashed")
+}
+
+// FailingMockScraper is a mock scraper whose pages all fail
+type FailingMockScraper struct {
+	MockScraper
+}
+
+func (m *FailingMockScraper) ScrapeURLs(urls []string) []types.ScrapedData {
+	results := m.MockScraper.ScrapeURLs(urls)
+	for i := range results {
+		results[i].Status = http.StatusServiceUnavailable
+		results[i].Error = "HTTP 503"
+	}
+	return results
+}
+
+func TestJobWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify("s3cret", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		events = append(events, r.Header.Get(webhook.EventHeader))
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	// waitForEvents polls the receiver until it has count events
+	waitForEvents := func(count int) []string {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			mu.Lock()
+			received := append([]string(nil), events...)
+			mu.Unlock()
+			if len(received) >= count || time.Now().After(deadline) {
+				return received
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	storageBackend := storage.NewInMemoryStorage()
+	if rr := submitJob(NewAPIHandler(&MockScraper{}, cfg, storageBackend), fmt.Sprintf(`{"urls": ["https://example.com"], "webhooks": [{"url": %q}]}`, receiver.URL)); rr.Code != http.StatusBadRequest {
+		t.Errorf("job with webhooks and no secret: got status %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	cfg.WebhookSecret = "s3cret"
+	handler := NewAPIHandler(&MockScraper{}, cfg, storageBackend)
+	if rr := submitJob(handler, `{"urls": ["https://example.com"], "webhooks": [{"url": "ftp://example.com"}]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("job with an invalid webhook: got status %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	body := fmt.Sprintf(`{"urls": ["https://example.com/a", "https://example.com/b"], "webhooks": [{"url": %q, "events": ["job.started", "record.scraped", "job.completed"]}]}`, receiver.URL)
+	rr := submitJob(handler, body)
+	var response ScrapeResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	waitForJob(t, storageBackend, response.JobID)
+
+	expected := []string{webhook.JobStarted, webhook.RecordScraped, webhook.RecordScraped, webhook.JobCompleted}
+	if received := waitForEvents(len(expected)); strings.Join(received, ",") != strings.Join(expected, ",") {
+		t.Fatalf("received events %v, want %v", received, expected)
+	}
+
+	// The delivery log is part of the job status
+	status := httptest.NewRecorder()
+	handler.HandleJobStatus(status, httptest.NewRequest("GET", "/scrape/status?id="+response.JobID, nil))
+	var statusResponse JobStatusResponse
+	if err := json.NewDecoder(status.Body).Decode(&statusResponse); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if len(statusResponse.Deliveries) != len(expected) || !statusResponse.Deliveries[3].Delivered {
+		t.Errorf("deliveries = %+v, want %d delivered", statusResponse.Deliveries, len(expected))
+	}
+
+	// A job that panics or has no page scraped notifies job.failed
+	for _, scraper := range []ScraperInterface{&PanicMockScraper{}, &FailingMockScraper{}} {
+		mu.Lock()
+		events = nil
+		mu.Unlock()
+		failing := NewAPIHandler(scraper, cfg, storageBackend)
+		rr := submitJob(failing, fmt.Sprintf(`{"urls": ["https://example.com"], "webhooks": [{"url": %q}]}`, receiver.URL))
+		if received := waitForEvents(2); strings.Join(received, ",") != webhook.JobStarted+","+webhook.JobFailed {
+			t.Errorf("%T: received events %v, want job.started then job.failed", scraper, received)
+		}
+
+		var response ScrapeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if job, err := storageBackend.GetJob(context.Background(), response.JobID); err != nil || job.Status != "failed" || job.Error == "" {
+			t.Errorf("%T: job = %+v, %v, want it failed with an error", scraper, job, err)
+		}
+	}
+}
+
+// submitJob posts a scrape request to the handler
+func submitJob(handler *APIHandler, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.HandleScrape(rr, httptest.NewRequest("POST", "/scrape", strings.NewReader(body)))
+	return rr
+}
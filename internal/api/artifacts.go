@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"arachne/internal/processor"
+	"arachne/internal/storage"
+	"arachne/internal/types"
+)
+
+// Artifact names produced by jobs
+const (
+	harArtifact = "requests.har"
+)
+
+// artifactContentTypes maps artifact extensions to response content types
+var artifactContentTypes = map[string]string{
+	".har": "application/json",
+	".csv": "text/csv; charset=utf-8",
+}
+
+// artifactPath returns where an artifact of a job is stored on disk
+func (h *APIHandler) artifactPath(jobID, name string) string {
+	return filepath.Join(h.config.ArtifactDir, jobID, name)
+}
+
+// saveArtifact writes a job artifact using write and records it on the job
+func (h *APIHandler) saveArtifact(job *storage.ScrapingJob, name string, write func(filename string) error) error {
+	filename := h.artifactPath(job.ID, name)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	if err := write(filename); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", name, err)
+	}
+
+	job.Artifacts = append(job.Artifacts, name)
+	return nil
+}
+
+// saveTableArtifacts stores every extracted table of a job as a CSV artifact
+func (h *APIHandler) saveTableArtifacts(job *storage.ScrapingJob, results []types.ScrapedData) {
+	for i, result := range results {
+		for _, table := range result.Tables {
+			name := fmt.Sprintf("table_%d_%d.csv", i, table.Index)
+			if err := h.saveArtifact(job, name, func(filename string) error {
+				return processor.WriteTableCSVFile(filename, table)
+			}); err != nil {
+				fmt.Printf("Failed to save table artifact: %v\n", err)
+			}
+		}
+	}
+}
+
+// HandleJobArtifact serves a file produced by a job, such as its HAR log
+func (h *APIHandler) HandleJobArtifact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID := r.URL.Query().Get("id")
+	name := r.URL.Query().Get("name")
+	if jobID == "" || name == "" {
+		http.Error(w, "Job ID and artifact name required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	job, err := h.storage.GetJob(ctx, jobID)
+	if err != nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	// Only serve artifacts the job recorded, never arbitrary paths
+	found := false
+	for _, artifact := range job.Artifacts {
+		if artifact == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		http.Error(w, "Artifact not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := os.ReadFile(h.artifactPath(job.ID, name))
+	if err != nil {
+		http.Error(w, "Artifact not available", http.StatusNotFound)
+		return
+	}
+
+	contentType := artifactContentTypes[filepath.Ext(name)]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.ID+"-"+name))
+	if _, err := w.Write(data); err != nil {
+		return
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"arachne/internal/changes"
+	"arachne/internal/storage"
+	"arachne/internal/types"
+)
+
+// Page sizes of the change event listing
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// ChangesResponse lists change events, newest first
+type ChangesResponse struct {
+	Changes []storage.ChangeEvent `json:"changes"`
+	Count   int                   `json:"count"`
+}
+
+// saveChangeEvents keeps the job's new and changed pages queryable through
+// /changes, when the job store supports it
+func (h *APIHandler) saveChangeEvents(ctx context.Context, job *storage.ScrapingJob, results []types.ScrapedData) {
+	store, ok := h.storage.(storage.ChangeStore)
+	if !ok {
+		return
+	}
+	if err := store.SaveChanges(ctx, changes.Events(job.ID, results)); err != nil {
+		fmt.Printf("Failed to save change events: %v\n", err)
+	}
+}
+
+// HandleChanges lists change events, filtered by the url, status (new or
+// changed), since (RFC 3339) and limit query parameters
+func (h *APIHandler) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := h.storage.(storage.ChangeStore)
+	if !ok {
+		http.Error(w, "Storage does not keep change events", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.ChangeFilter{Status: query.Get("status"), Limit: defaultChangesLimit}
+	if url := query.Get("url"); url != "" {
+		filter.URL = changes.NormalizeURL(url)
+	}
+	if filter.Status != "" && filter.Status != types.ChangeNew && filter.Status != types.ChangeChanged {
+		http.Error(w, "Invalid status, must be one of: new, changed", http.StatusBadRequest)
+		return
+	}
+	if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "Invalid since, must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		filter.Since = parsed
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed <= 0 || parsed > maxChangesLimit {
+			http.Error(w, fmt.Sprintf("Invalid limit, must be between 1 and %d", maxChangesLimit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	events, err := store.ListChanges(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []storage.ChangeEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ChangesResponse{Changes: events, Count: len(events)}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
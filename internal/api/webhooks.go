@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"arachne/internal/storage"
+	"arachne/internal/webhook"
+)
+
+// JobEvent is the data of job lifecycle webhook events
+type JobEvent struct {
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Results     int        `json:"results"`
+	Failed      int        `json:"failed"` // Results with a scraping error
+	Artifacts   []string   `json:"artifacts,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// jobEvent summarizes a job for a lifecycle event; results are fetched from
+// /scrape/status or received as record.scraped events
+func jobEvent(job *storage.ScrapingJob) JobEvent {
+	event := JobEvent{
+		Status:      job.Status,
+		Error:       job.Error,
+		Results:     len(job.Results),
+		Artifacts:   job.Artifacts,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	for _, result := range job.Results {
+		if result.Error != "" {
+			event.Failed++
+		}
+	}
+	return event
+}
+
+// notifierFor starts delivering a job's webhook events, recording every
+// delivery in the job's log when the job store keeps one
+func (h *APIHandler) notifierFor(job *storage.ScrapingJob) *webhook.Notifier {
+	return h.webhooks.ForJob(job.ID, job.Request.Webhooks, func(delivery webhook.Delivery) {
+		store, ok := h.storage.(storage.DeliveryStore)
+		if !ok {
+			return
+		}
+		if err := store.SaveDelivery(context.Background(), job.ID, delivery); err != nil {
+			fmt.Printf("Failed to save webhook delivery: %v\n", err)
+		}
+	})
+}
+
+// jobDeliveries returns a job's webhook delivery log, when the job store keeps one
+func (h *APIHandler) jobDeliveries(ctx context.Context, jobID string) []webhook.Delivery {
+	store, ok := h.storage.(storage.DeliveryStore)
+	if !ok {
+		return nil
+	}
+	deliveries, err := store.ListDeliveries(ctx, jobID)
+	if err != nil {
+		fmt.Printf("Failed to list webhook deliveries: %v\n", err)
+		return nil
+	}
+	return deliveries
+}
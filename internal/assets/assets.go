@@ -0,0 +1,216 @@
+// Package assets downloads the images, stylesheets, scripts and documents
+// referenced by scraped pages into a content-addressed store
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// Downloader fetches assets and stores them under their SHA-256
+type Downloader struct {
+	client      *http.Client
+	guard       Guard
+	dir         string
+	maxSize     int64
+	userAgent   string
+	concurrency int
+}
+
+// NewDownloader creates a downloader storing assets in cfg.AssetDir
+func NewDownloader(cfg *config.Config, client *http.Client, guard Guard) *Downloader {
+	return &Downloader{
+		client:      client,
+		guard:       guard,
+		dir:         cfg.AssetDir,
+		maxSize:     cfg.AssetMaxSize,
+		userAgent:   cfg.UserAgent,
+		concurrency: max(cfg.MaxConcurrent, 1),
+	}
+}
+
+// Process downloads every asset referenced by the results and fills in its
+// path, size, MIME type and hash, or its error. Assets shared by several
+// pages are downloaded once.
+func (d *Downloader) Process(ctx context.Context, results []types.ScrapedData) {
+	downloaded := make(map[string]*types.Asset)
+	for _, data := range results {
+		for _, asset := range data.Assets {
+			if _, ok := downloaded[asset.URL]; !ok {
+				downloaded[asset.URL] = &types.Asset{URL: asset.URL, Kind: asset.Kind}
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, d.concurrency)
+	for _, asset := range downloaded {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(asset *types.Asset) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			d.Download(ctx, asset)
+		}(asset)
+	}
+	wg.Wait()
+
+	for i := range results {
+		for j := range results[i].Assets {
+			stored := *downloaded[results[i].Assets[j].URL]
+			stored.Kind = results[i].Assets[j].Kind
+			results[i].Assets[j] = stored
+		}
+	}
+}
+
+// Download fetches one asset through the guard and stores it, recording the
+// outcome on asset
+func (d *Downloader) Download(ctx context.Context, asset *types.Asset) {
+	target, err := url.Parse(asset.URL)
+	if err != nil {
+		asset.Error = fmt.Sprintf("invalid URL: %v", err)
+		return
+	}
+
+	err = d.guard.Do(ctx, target.Hostname(), func() error {
+		return d.fetch(ctx, asset)
+	})
+	if err != nil {
+		asset.Error = err.Error()
+	}
+}
+
+// fetch performs the request and writes the body to the store
+func (d *Downloader) fetch(ctx context.Context, asset *types.Asset) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", d.userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > d.maxSize {
+		return fmt.Errorf("asset exceeds max size of %d bytes", d.maxSize)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	stored, err := d.store(resp.Body, extension(asset.URL, mediaType))
+	if err != nil {
+		return err
+	}
+
+	asset.Path = stored.path
+	asset.Size = stored.size
+	asset.SHA256 = stored.hash
+	asset.MIMEType = mediaType
+	if asset.MIMEType == "" {
+		asset.MIMEType = stored.sniffed
+	}
+	return nil
+}
+
+// storedFile describes content written to the store
+type storedFile struct {
+	path    string
+	hash    string
+	size    int64
+	sniffed string // MIME type detected from the first bytes
+}
+
+// store copies content to a temporary file while hashing it, then moves it
+// to <dir>/<hash[:2]>/<hash><ext>. Content already in the store is kept.
+func (d *Downloader) store(content io.Reader, ext string) (*storedFile, error) {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create asset directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(d.dir, ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	hasher := sha256.New()
+	sniffer := &sniffWriter{}
+	size, err := io.Copy(io.MultiWriter(tmp, hasher, sniffer), io.LimitReader(content, d.maxSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset: %w", err)
+	}
+	if size > d.maxSize {
+		return nil, fmt.Errorf("asset exceeds max size of %d bytes", d.maxSize)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	final := filepath.Join(d.dir, sum[:2], sum+ext)
+	if _, err := os.Stat(final); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(final), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create asset directory: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), final); err != nil {
+			return nil, fmt.Errorf("failed to store asset: %w", err)
+		}
+	}
+
+	return &storedFile{path: final, hash: sum, size: size, sniffed: sniffer.contentType()}, nil
+}
+
+// extension picks the stored file extension from the URL path, falling back
+// to the MIME type
+func extension(rawURL, mediaType string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		ext := strings.ToLower(path.Ext(parsed.Path))
+		if len(ext) > 1 && len(ext) <= 6 && strings.Trim(ext[1:], "abcdefghijklmnopqrstuvwxyz0123456789") == "" {
+			return ext
+		}
+	}
+	if mediaType != "" {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ""
+}
+
+// sniffWriter keeps the first bytes written for content type detection
+type sniffWriter struct {
+	head []byte
+}
+
+// Write records up to 512 bytes and discards the rest
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if remaining := 512 - len(w.head); remaining > 0 {
+		w.head = append(w.head, p[:min(remaining, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// contentType returns the detected MIME type without parameters
+func (w *sniffWriter) contentType() string {
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(w.head))
+	return mediaType
+}
@@ -0,0 +1,143 @@
+package assets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngHeader) // No Content-Type, so the type is sniffed
+	})
+	mux.HandleFunc("/copy-of-logo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngHeader)
+	})
+	mux.HandleFunc("/manual.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.7 " + strings.Repeat("x", 100)))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloaderProcess(t *testing.T) {
+	server := newTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.AssetDir = t.TempDir()
+	cfg.AssetMaxSize = 64
+
+	results := []types.ScrapedData{
+		{URL: server.URL + "/a", Assets: []types.Asset{
+			{URL: server.URL + "/logo.png", Kind: "image"},
+			{URL: server.URL + "/missing.css", Kind: "stylesheet"},
+		}},
+		{URL: server.URL + "/b", Assets: []types.Asset{
+			{URL: server.URL + "/logo.png", Kind: "image"},
+			{URL: server.URL + "/copy-of-logo", Kind: "image"},
+			{URL: server.URL + "/manual.pdf", Kind: "document"},
+		}},
+	}
+
+	downloader := NewDownloader(cfg, server.Client(), NewDomainGuard(cfg))
+	downloader.Process(context.Background(), results)
+
+	logo := results[0].Assets[0]
+	if logo.Error != "" || logo.Size != int64(len(pngHeader)) || logo.MIMEType != "image/png" || len(logo.SHA256) != 64 {
+		t.Fatalf("logo asset = %+v", logo)
+	}
+	if logo.Path != filepath.Join(cfg.AssetDir, logo.SHA256[:2], logo.SHA256+".png") {
+		t.Errorf("logo path = %s, want content-addressed path", logo.Path)
+	}
+	if _, err := os.Stat(logo.Path); err != nil {
+		t.Errorf("stored asset missing: %v", err)
+	}
+
+	// The same content under another URL is stored once
+	if duplicate := results[1].Assets[1]; duplicate.SHA256 != logo.SHA256 || duplicate.Error != "" {
+		t.Errorf("duplicate asset = %+v, want the logo's hash", duplicate)
+	}
+	if results[1].Assets[0] != logo {
+		t.Errorf("shared asset = %+v, want %+v", results[1].Assets[0], logo)
+	}
+
+	if missing := results[0].Assets[1]; missing.Error == "" || missing.Path != "" {
+		t.Errorf("missing asset = %+v, want an error", missing)
+	}
+	if pdf := results[1].Assets[2]; !strings.Contains(pdf.Error, "max size") {
+		t.Errorf("oversized asset = %+v, want a size error", pdf)
+	}
+
+	entries, _ := os.ReadDir(cfg.AssetDir)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".download-") {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestDomainGuardCircuitBreaker(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.CircuitBreakerThreshold = 2
+	guard := NewDomainGuard(cfg)
+
+	fail := func() error { return os.ErrNotExist }
+	for i := 0; i < 2; i++ {
+		guard.Do(context.Background(), "down.example", fail)
+	}
+
+	called := false
+	err := guard.Do(context.Background(), "down.example", func() error { called = true; return nil })
+	if err == nil || called {
+		t.Errorf("Do() after repeated failures = %v, called = %v, want the breaker open", err, called)
+	}
+	if err := guard.Do(context.Background(), "up.example", func() error { return nil }); err != nil {
+		t.Errorf("Do() on another host = %v, want breakers per host", err)
+	}
+}
+
+func TestDomainGuardRateLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DomainRateLimit = map[string]int{"slow.example": 20}
+	guard := NewDomainGuard(cfg)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		guard.Do(context.Background(), "slow.example", func() error { return nil })
+		guard.Do(context.Background(), "fast.example", func() error { return nil })
+	}
+	// Three requests at 20/s need two 50ms intervals; fast.example is unlimited
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 rate limited requests took %v, want at least 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	guard.Do(context.Background(), "slow.example", func() error { return nil })
+	if err := guard.Do(ctx, "slow.example", func() error { return nil }); err == nil {
+		t.Error("Do() ignored a cancelled context while waiting for a slot")
+	}
+}
+
+func TestGuardFrom(t *testing.T) {
+	custom := NewDomainGuard(config.DefaultConfig())
+	if GuardFrom(custom, config.DefaultConfig()) != Guard(custom) {
+		t.Error("GuardFrom() did not reuse a source implementing Guard")
+	}
+	if _, ok := GuardFrom(struct{}{}, config.DefaultConfig()).(*DomainGuard); !ok {
+		t.Error("GuardFrom() did not fall back to a DomainGuard")
+	}
+}
@@ -0,0 +1,95 @@
+package assets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"arachne/internal/circuit_breaker"
+	"arachne/internal/config"
+)
+
+// Guard runs a request to host under rate limiting and circuit breaking
+type Guard interface {
+	Do(ctx context.Context, host string, fn func() error) error
+}
+
+// GuardFrom returns source itself when it implements Guard, so assets share
+// the scraper's limiters and breakers, and a new DomainGuard otherwise. The
+// guard only limits what goes through it, so callers keep one for the life
+// of the process rather than one per job.
+func GuardFrom(source interface{}, cfg *config.Config) Guard {
+	if guard, ok := source.(Guard); ok {
+		return guard
+	}
+	return NewDomainGuard(cfg)
+}
+
+// DomainGuard applies the configured per-domain rate limits and one circuit
+// breaker per host
+type DomainGuard struct {
+	mu        sync.Mutex
+	limits    map[string]int // Requests per second per host
+	next      map[string]time.Time
+	breakers  map[string]*circuit_breaker.CircuitBreaker
+	threshold int
+	timeout   time.Duration
+}
+
+// NewDomainGuard creates a guard from DomainRateLimit and the circuit breaker settings
+func NewDomainGuard(cfg *config.Config) *DomainGuard {
+	return &DomainGuard{
+		limits:    cfg.DomainRateLimit,
+		next:      make(map[string]time.Time),
+		breakers:  make(map[string]*circuit_breaker.CircuitBreaker),
+		threshold: cfg.CircuitBreakerThreshold,
+		timeout:   cfg.CircuitBreakerTimeout,
+	}
+}
+
+// Do waits for the host's next rate limit slot, then runs fn through the
+// host's circuit breaker
+func (g *DomainGuard) Do(ctx context.Context, host string, fn func() error) error {
+	if err := g.wait(ctx, host); err != nil {
+		return err
+	}
+	return g.breaker(host).Execute(fn)
+}
+
+// wait reserves the next request slot for host and sleeps until it starts
+func (g *DomainGuard) wait(ctx context.Context, host string) error {
+	g.mu.Lock()
+	limit := g.limits[host]
+	if limit <= 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	slot := g.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	g.next[host] = slot.Add(time.Second / time.Duration(limit))
+	g.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// breaker returns the circuit breaker of host, creating it on first use
+func (g *DomainGuard) breaker(host string) *circuit_breaker.CircuitBreaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cb, ok := g.breakers[host]
+	if !ok {
+		cb = circuit_breaker.NewCircuitBreaker(g.threshold, g.timeout)
+		g.breakers[host] = cb
+	}
+	return cb
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"arachne/internal/config"
+)
+
+// ResolveSecret reads the secret a reference points to. File secrets are
+// trimmed of surrounding whitespace, so a trailing newline does no harm.
+func ResolveSecret(ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, "env:"); ok {
+		value := os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("secret env var %s is not set", name)
+		}
+		return value, nil
+	}
+
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return "", fmt.Errorf("invalid secret reference, expected env:NAME or file:/path")
+}
+
+// Provider adds credentials to outgoing requests
+type Provider interface {
+	// Apply sets credentials on the request
+	Apply(req *http.Request) error
+	// Refresh discards cached credentials after the server rejected them
+	Refresh(ctx context.Context) error
+}
+
+// NewProvider creates the provider for a profile. OAuth2 token requests are
+// sent through client.
+func NewProvider(profile *config.AuthProfile, client *http.Client) (Provider, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch profile.Type {
+	case config.AuthBasic:
+		return &staticProvider{ref: profile.Password, apply: func(req *http.Request, password string) {
+			req.SetBasicAuth(profile.Username, password)
+		}}, nil
+	case config.AuthBearer:
+		return &staticProvider{ref: profile.Token, apply: func(req *http.Request, token string) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}}, nil
+	case config.AuthAPIKey:
+		return &staticProvider{ref: profile.APIKey, apply: func(req *http.Request, key string) {
+			if profile.QueryParam != "" {
+				query := req.URL.Query()
+				query.Set(profile.QueryParam, key)
+				req.URL.RawQuery = query.Encode()
+				return
+			}
+			header := profile.Header
+			if header == "" {
+				header = "X-API-Key"
+			}
+			req.Header.Set(header, key)
+		}}, nil
+	default:
+		return newOAuth2Provider(profile, client), nil
+	}
+}
+
+// staticProvider applies a secret that does not expire. The secret is read
+// lazily and re-read on Refresh, which picks up rotated secret files.
+type staticProvider struct {
+	ref    string
+	apply  func(req *http.Request, secret string)
+	mu     sync.Mutex
+	secret string
+}
+
+// Apply implements Provider
+func (p *staticProvider) Apply(req *http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.secret == "" {
+		secret, err := ResolveSecret(p.ref)
+		if err != nil {
+			return err
+		}
+		p.secret = secret
+	}
+	p.apply(req, p.secret)
+	return nil
+}
+
+// Refresh implements Provider
+func (p *staticProvider) Refresh(_ context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.secret = ""
+	return nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"arachne/internal/config"
+)
+
+func TestTransportProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		fmt.Fprintf(w, "basic=%s:%s bearer=%s header=%s query=%s",
+			user, pass, r.Header.Get("Authorization"), r.Header.Get("X-Token"), r.URL.Query().Get("key"))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_AUTH_SECRET", "s3cret")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		profile  config.AuthProfile
+		expected string
+	}{
+		{name: "Basic", profile: config.AuthProfile{Type: config.AuthBasic, Username: "bob", Password: "env:TEST_AUTH_SECRET"}, expected: "basic=bob:s3cret"},
+		{name: "Bearer from file", profile: config.AuthProfile{Type: config.AuthBearer, Token: "file:" + secretFile}, expected: "bearer=Bearer from-file"},
+		{name: "API key header", profile: config.AuthProfile{Type: config.AuthAPIKey, APIKey: "env:TEST_AUTH_SECRET", Header: "X-Token"}, expected: "header=s3cret"},
+		{name: "API key query", profile: config.AuthProfile{Type: config.AuthAPIKey, APIKey: "env:TEST_AUTH_SECRET", QueryParam: "key"}, expected: "query=s3cret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := tt.profile
+			transport := NewTransport(http.DefaultTransport, func(string) (string, *config.AuthProfile) {
+				return "test", &profile
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/data", nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.expected) {
+				t.Errorf("server saw %q, want it to contain %q", body, tt.expected)
+			}
+			if req.Header.Get("Authorization") != "" || req.URL.RawQuery != "" {
+				t.Errorf("transport must not modify the caller's request")
+			}
+		})
+	}
+}
+
+func TestOAuth2RefreshOn401(t *testing.T) {
+	tokensIssued := 0
+	revoked := "token-1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokensIssued++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", tokensIssued),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer "+revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Header.Get("Authorization"), body)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("TEST_CLIENT_SECRET", "s3cret")
+	profile := &config.AuthProfile{
+		Type:         config.AuthOAuth2,
+		TokenURL:     server.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "env:TEST_CLIENT_SECRET",
+		Scopes:       []string{"read"},
+	}
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, func(string) (string, *config.AuthProfile) {
+		return "api", profile
+	})}
+
+	// The first token is rejected, so the transport must refresh and retry,
+	// replaying the request body
+	resp, err := client.Post(server.URL+"/api", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "Bearer token-2 payload" {
+		t.Errorf("got %d %q, want retried request with refreshed token", resp.StatusCode, body)
+	}
+
+	// A second request reuses the cached token
+	resp2, err := client.Get(server.URL + "/api")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp2.Body.Close()
+	if tokensIssued != 2 {
+		t.Errorf("expected 2 tokens issued, got %d", tokensIssued)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile config.AuthProfile
+		wantErr bool
+	}{
+		{name: "Valid bearer", profile: config.AuthProfile{Type: config.AuthBearer, Token: "env:TOKEN"}},
+		{name: "Inline secret", profile: config.AuthProfile{Type: config.AuthBearer, Token: "abc123"}, wantErr: true},
+		{name: "Unknown type", profile: config.AuthProfile{Type: "digest"}, wantErr: true},
+		{name: "OAuth2 without token URL", profile: config.AuthProfile{Type: config.AuthOAuth2, ClientID: "id", ClientSecret: "env:S"}, wantErr: true},
+		{name: "API key header and query", profile: config.AuthProfile{Type: config.AuthAPIKey, APIKey: "env:K", Header: "X", QueryParam: "k"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"arachne/internal/config"
+)
+
+// tokenExpiryMargin renews tokens slightly before the server expires them
+const tokenExpiryMargin = 30 * time.Second
+
+// tokenResponse is the token endpoint response defined by RFC 6749
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// oauth2Provider implements the OAuth2 client credentials grant, caching the
+// access token until it expires
+type oauth2Provider struct {
+	profile *config.AuthProfile
+	client  *http.Client
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newOAuth2Provider creates an OAuth2 client credentials provider
+func newOAuth2Provider(profile *config.AuthProfile, client *http.Client) *oauth2Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &oauth2Provider{profile: profile, client: client}
+}
+
+// Apply implements Provider
+func (p *oauth2Provider) Apply(req *http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || (!p.expires.IsZero() && time.Now().After(p.expires)) {
+		if err := p.fetchToken(req.Context()); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return nil
+}
+
+// Refresh implements Provider
+func (p *oauth2Provider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.fetchToken(ctx)
+}
+
+// fetchToken requests a new access token. Callers must hold p.mu.
+func (p *oauth2Provider) fetchToken(ctx context.Context) error {
+	secret, err := ResolveSecret(p.profile.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.profile.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.profile.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.profile.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.profile.ClientID), url.QueryEscape(secret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("failed to parse token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported token type: %s", token.TokenType)
+	}
+
+	p.token = token.AccessToken
+	p.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		p.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	}
+	return nil
+}
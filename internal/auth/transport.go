@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"arachne/internal/config"
+)
+
+// ProfileLookup returns the profile key and auth profile for a host, or a nil
+// profile when the host needs no authentication
+type ProfileLookup func(host string) (string, *config.AuthProfile)
+
+// Transport authenticates requests using the profile of each request's host.
+// A 401 response triggers a single credential refresh and retry.
+type Transport struct {
+	Base      http.RoundTripper
+	lookup    ProfileLookup
+	mu        sync.Mutex
+	providers map[string]Provider
+}
+
+// NewTransport creates an authenticating transport around base. Token
+// requests are sent through base as well.
+func NewTransport(base http.RoundTripper, lookup ProfileLookup) *Transport {
+	return &Transport{
+		Base:      base,
+		lookup:    lookup,
+		providers: make(map[string]Provider),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider, err := t.providerFor(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return t.Base.RoundTrip(req)
+	}
+
+	// Buffer the body so the request can be sent a second time
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.send(req, body, provider)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	if err := provider.Refresh(req.Context()); err != nil {
+		// Keep the original 401 so callers see what the server said
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return t.send(req, body, provider)
+}
+
+// send clones req, applies credentials and performs it. The original request
+// is never modified, as RoundTrippers must not mutate their input, and it is
+// what the response points back to so credentials never reach the final URL
+// or redirect chain callers report.
+func (t *Transport) send(req *http.Request, body []byte, provider Provider) (*http.Response, error) {
+	authed := req.Clone(req.Context())
+	if body != nil {
+		authed.Body = io.NopCloser(bytes.NewReader(body))
+		authed.ContentLength = int64(len(body))
+	}
+
+	if err := provider.Apply(authed); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request to %s: %w", req.URL.Host, err)
+	}
+	resp, err := t.Base.RoundTrip(authed)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// providerFor returns the cached provider for the host's auth profile
+func (t *Transport) providerFor(host string) (Provider, error) {
+	key, profile := t.lookup(host)
+	if profile == nil {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if provider, exists := t.providers[key]; exists {
+		return provider, nil
+	}
+
+	provider, err := NewProvider(profile, &http.Client{Transport: t.Base})
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth profile %q: %w", key, err)
+	}
+	t.providers[key] = provider
+	return provider, nil
+}
@@ -0,0 +1,321 @@
+// Package changes compares scraped results with the previous scrape of the
+// same page, for monitoring pages over time
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"arachne/internal/storage"
+	"arachne/internal/types"
+	"arachne/pkg/parser"
+)
+
+// CanonicalURL returns the key under which scrapes of a page are compared:
+// the canonical link the page declares, or else its URL, normalised
+func CanonicalURL(data *types.ScrapedData) string {
+	if data.Metadata != nil && data.Metadata.Canonical != "" {
+		return NormalizeURL(data.Metadata.Canonical)
+	}
+	return NormalizeURL(data.URL)
+}
+
+// NormalizeURL lower-cases the scheme and host, drops default ports and the
+// fragment and sorts the query, so equivalent spellings of a URL compare equal
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+// Compare describes how current differs from previous, the latest earlier
+// scrape of the same page or nil when there is none. Pages change when their
+// content hash or any extracted value differs.
+func Compare(previous, current *types.ScrapedData) *types.Change {
+	change := &types.Change{Status: types.ChangeNew, CanonicalURL: CanonicalURL(current)}
+	text := MainText(current)
+	if current.Article == nil {
+		text = bodyText(current)
+		change.Text = text
+	}
+	if previous == nil {
+		return change
+	}
+
+	scraped := previous.Scraped
+	change.PreviousScraped = &scraped
+	change.PreviousHash = previous.ContentHash
+	change.Fields = DiffFields(Fields(previous), Fields(current))
+	// Text is only compared when both scrapes kept it; the hash covers the rest
+	if previousText := MainText(previous); previousText != "" && text != "" && previousText != text {
+		change.TextDiff = Diff(previousText, text)
+	}
+
+	hashChanged := previous.ContentHash != "" && current.ContentHash != "" && previous.ContentHash != current.ContentHash
+	if hashChanged || len(change.Fields) > 0 || change.TextDiff != "" {
+		change.Status = types.ChangeChanged
+	} else {
+		change.Status = types.ChangeUnchanged
+	}
+	return change
+}
+
+// MainText returns the main content of a result: the article text when the
+// readability plugin ran, else the text kept by an earlier comparison
+func MainText(data *types.ScrapedData) string {
+	if data.Article != nil {
+		return data.Article.Text
+	}
+	if data.Change != nil {
+		return data.Change.Text
+	}
+	return ""
+}
+
+// bodyText returns the main content of a result without an article: the
+// readable text of HTML pages, or the body of other text responses
+func bodyText(data *types.ScrapedData) string {
+	if data.Body == "" {
+		return ""
+	}
+	category := ""
+	if data.ContentInfo != nil {
+		category = data.ContentInfo.Category
+	}
+	switch {
+	case category == parser.CategoryHTML || (category == "" && parser.IsHTML(data.Body, data.ContentType)):
+		article, err := parser.ExtractArticle(data.Body, data.URL, false)
+		if err != nil {
+			return ""
+		}
+		return article.Text
+	case category == parser.CategoryText || category == parser.CategoryJSON || category == parser.CategoryXML || category == parser.CategoryFeed:
+		return data.Body
+	}
+	return ""
+}
+
+// Fields returns the extracted values compared between scrapes, flattened
+// to paths such as "records[0].price"
+func Fields(data *types.ScrapedData) map[string]interface{} {
+	extracted := map[string]interface{}{
+		"title":           data.Title,
+		"status":          data.Status,
+		"final_url":       data.FinalURL,
+		"metadata":        data.Metadata,
+		"records":         data.Records,
+		"structured_data": data.Structured,
+		"feed_items":      data.FeedItems,
+		"tables":          data.Tables,
+	}
+	// Round-trip through JSON so stored and fresh results have the same value types
+	encoded, err := json.Marshal(extracted)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil
+	}
+
+	flat := make(map[string]interface{})
+	flatten("", decoded, flat)
+	return flat
+}
+
+// flatten adds the leaf values of a decoded JSON value to flat, keyed by path
+func flatten(path string, value interface{}, flat map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path == "" {
+				flatten(key, child, flat)
+			} else {
+				flatten(path+"."+key, child, flat)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, i), child, flat)
+		}
+	case nil, string:
+		// Absent and empty values are the same thing in omitempty JSON
+		if v != nil && v != "" {
+			flat[path] = v
+		}
+	default:
+		flat[path] = v
+	}
+}
+
+// DiffFields returns the paths whose values differ, sorted by path
+func DiffFields(previous, current map[string]interface{}) []types.FieldChange {
+	var changed []types.FieldChange
+	for path, value := range current {
+		if old, ok := previous[path]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, types.FieldChange{Path: path, Previous: old, Current: value})
+		}
+	}
+	for path, old := range previous {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, types.FieldChange{Path: path, Previous: old})
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+	return changed
+}
+
+// History keeps the latest result of each canonical URL. It looks pages up
+// in a storage backend the first time it sees them, loading every stored
+// result once when the backend cannot look them up, and follows the results
+// it observes, so repeated scrapes within one process compare against each
+// other.
+type History struct {
+	backend storage.StorageBackend
+
+	mu     sync.Mutex
+	loaded bool
+	latest map[string]*types.ScrapedData
+}
+
+// NewHistory creates a history backed by the results stored in backend
+func NewHistory(backend storage.StorageBackend) *History {
+	return &History{backend: backend, latest: make(map[string]*types.ScrapedData)}
+}
+
+// Observe compares data with the latest result of its canonical URL, sets
+// data.Change, and makes data the latest result. Failed scrapes are ignored.
+func (h *History) Observe(ctx context.Context, data *types.ScrapedData) error {
+	if data.Error != "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := CanonicalURL(data)
+	previous, err := h.previous(ctx, key)
+	if err != nil {
+		return err
+	}
+	data.Change = Compare(previous, data)
+	h.latest[key] = snapshot(data)
+	return nil
+}
+
+// Close closes the storage backend of the history
+func (h *History) Close() error {
+	if h.backend == nil {
+		return nil
+	}
+	return h.backend.Close()
+}
+
+// previous returns the latest result of a canonical URL, querying backends
+// that can look it up once per URL, nil when there is none
+func (h *History) previous(ctx context.Context, key string) (*types.ScrapedData, error) {
+	if latest, ok := h.latest[key]; ok {
+		return latest, nil
+	}
+	store, ok := h.backend.(storage.LatestResultStore)
+	if !ok {
+		if err := h.load(ctx); err != nil {
+			return nil, err
+		}
+		return h.latest[key], nil
+	}
+
+	stored, err := store.LatestResult(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the previous result: %v", err)
+	}
+	if stored != nil {
+		stored = snapshot(stored)
+	}
+	// Pages never stored are remembered too, so they are queried once
+	h.latest[key] = stored
+	return stored, nil
+}
+
+// load indexes the stored results by canonical URL, keeping the latest scrape of each
+func (h *History) load(ctx context.Context) error {
+	if h.loaded || h.backend == nil {
+		return nil
+	}
+	results, err := h.backend.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load previous results: %v", err)
+	}
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		key := CanonicalURL(&results[i])
+		if latest, ok := h.latest[key]; !ok || !results[i].Scraped.Before(latest.Scraped) {
+			h.latest[key] = snapshot(&results[i])
+		}
+	}
+	h.loaded = true
+	return nil
+}
+
+// snapshot copies the parts of a result later comparisons need
+func snapshot(data *types.ScrapedData) *types.ScrapedData {
+	kept := &types.ScrapedData{
+		URL:         data.URL,
+		Title:       data.Title,
+		Status:      data.Status,
+		Scraped:     data.Scraped,
+		FinalURL:    data.FinalURL,
+		FeedItems:   data.FeedItems,
+		Metadata:    data.Metadata,
+		Records:     data.Records,
+		Article:     data.Article,
+		Tables:      data.Tables,
+		Structured:  data.Structured,
+		ContentHash: data.ContentHash,
+	}
+	if data.Change != nil && data.Change.Text != "" {
+		kept.Change = &types.Change{Text: data.Change.Text}
+	}
+	return kept
+}
+
+// Events returns the change events of a job's results: pages seen for the
+// first time and pages that changed
+func Events(jobID string, results []types.ScrapedData) []storage.ChangeEvent {
+	var events []storage.ChangeEvent
+	for _, data := range results {
+		if data.Change == nil || data.Change.Status == types.ChangeUnchanged {
+			continue
+		}
+		change := *data.Change
+		change.Text = ""
+		events = append(events, storage.ChangeEvent{
+			JobID:   jobID,
+			URL:     data.URL,
+			Scraped: data.Scraped,
+			Change:  change,
+		})
+	}
+	return events
+}
@@ -0,0 +1,164 @@
+package changes
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"arachne/internal/storage"
+	"arachne/internal/types"
+	"arachne/pkg/parser"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"HTTPS://Example.COM:443/pricing#plans", "https://example.com/pricing"},
+		{"http://example.com", "http://example.com/"},
+		{"http://example.com:8080/a?b=2&a=1", "http://example.com:8080/a?a=1&b=2"},
+		{"not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeURL(tt.raw); got != tt.expected {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	previous := "Plans\nBasic: $10\nPro: $20\nEnterprise: call us\nFAQ\nTerms\nPrivacy\nContact\n"
+	current := "Plans\nBasic: $10\nPro: $25\nEnterprise: call us\nFAQ\nTerms\nPrivacy\nContact\nCareers\n"
+
+	expected := "@@ -1,5 +1,5 @@\n Plans\n Basic: $10\n-Pro: $20\n+Pro: $25\n Enterprise: call us\n FAQ\n" +
+		"@@ -7,2 +7,3 @@\n Privacy\n Contact\n+Careers\n"
+	if got := Diff(previous, current); got != expected {
+		t.Errorf("Diff() =\n%s\nwant\n%s", got, expected)
+	}
+	if got := Diff(previous, previous); got != "" {
+		t.Errorf("Diff() of equal texts = %q, want empty", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := &types.ScrapedData{
+		URL:         "https://example.com/pricing",
+		Title:       "Pricing",
+		Status:      200,
+		Scraped:     earlier,
+		ContentHash: "aaa",
+		Records:     []map[string]interface{}{{"plan": "Pro", "price": 20}},
+		Article:     &parser.Article{Text: "Pro costs $20"},
+	}
+
+	unchanged := *previous
+	unchanged.Scraped = earlier.Add(time.Hour)
+	if change := Compare(previous, &unchanged); change.Status != types.ChangeUnchanged || len(change.Fields) != 0 {
+		t.Errorf("Compare() of an identical scrape = %+v, want unchanged", change)
+	}
+
+	changed := unchanged
+	changed.ContentHash = "bbb"
+	changed.Records = []map[string]interface{}{{"plan": "Pro", "price": 25.0, "currency": "USD"}}
+	changed.Article = &parser.Article{Text: "Pro costs $25"}
+	change := Compare(previous, &changed)
+	if change.Status != types.ChangeChanged || change.PreviousHash != "aaa" || !change.PreviousScraped.Equal(earlier) {
+		t.Fatalf("Compare() = %+v, want changed from the previous scrape", change)
+	}
+	expected := []types.FieldChange{
+		{Path: "records[0].currency", Current: "USD"},
+		{Path: "records[0].price", Previous: 20.0, Current: 25.0},
+	}
+	if len(change.Fields) != len(expected) || change.Fields[0] != expected[0] || change.Fields[1] != expected[1] {
+		t.Errorf("Fields = %+v, want %+v", change.Fields, expected)
+	}
+	if change.TextDiff != "@@ -1,1 +1,1 @@\n-Pro costs $20\n+Pro costs $25\n" {
+		t.Errorf("TextDiff = %q", change.TextDiff)
+	}
+
+	if change := Compare(nil, &changed); change.Status != types.ChangeNew || change.CanonicalURL != "https://example.com/pricing" {
+		t.Errorf("Compare() without a previous scrape = %+v, want new", change)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewJSONStorage(filepath.Join(t.TempDir(), "results.json"))
+	stored := []types.ScrapedData{
+		{URL: "https://example.com/terms", Title: "Terms v1", Scraped: time.Unix(100, 0), ContentHash: "old"},
+		{URL: "https://EXAMPLE.com/terms#top", Title: "Terms v2", Scraped: time.Unix(200, 0), ContentHash: "new"},
+		{URL: "https://example.com/terms", Error: "timeout", Scraped: time.Unix(300, 0)},
+	}
+	if err := backend.Save(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	history := NewHistory(backend)
+
+	page := &types.ScrapedData{
+		URL:         "https://example.com/terms",
+		Title:       "Terms v2",
+		Scraped:     time.Unix(400, 0),
+		ContentHash: "new",
+		ContentInfo: &parser.ContentInfo{Category: parser.CategoryHTML},
+		Body:        "<html><body><p>We may update these terms at any time.</p></body></html>",
+	}
+	if err := history.Observe(ctx, page); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	if page.Change.Status != types.ChangeUnchanged || !page.Change.PreviousScraped.Equal(time.Unix(200, 0)) {
+		t.Errorf("Change = %+v, want unchanged since the latest stored scrape", page.Change)
+	}
+
+	// The next scrape in the same process compares with the observed one, text included
+	next := *page
+	next.Change = nil
+	next.ContentHash = "newer"
+	next.Body = "<html><body><p>We will notify you before updating these terms.</p></body></html>"
+	if err := history.Observe(ctx, &next); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	if next.Change.Status != types.ChangeChanged || next.Change.TextDiff == "" {
+		t.Errorf("Change = %+v, want changed with a text diff", next.Change)
+	}
+
+	failed := &types.ScrapedData{URL: "https://example.com/terms", Error: "timeout"}
+	if err := history.Observe(ctx, failed); err != nil || failed.Change != nil {
+		t.Errorf("Observe() of a failed scrape = %v, %+v, want it ignored", err, failed.Change)
+	}
+
+	events := Events("job-1", []types.ScrapedData{*page, next})
+	if len(events) != 1 || events[0].Change.Status != types.ChangeChanged || events[0].Change.Text != "" {
+		t.Errorf("Events() = %+v, want the changed page without its text", events)
+	}
+}
+
+func TestHistoryLatestResult(t *testing.T) {
+	ctx := context.Background()
+	backend, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "arachne.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := NewHistory(backend)
+	defer history.Close()
+
+	first := &types.ScrapedData{URL: "https://example.com/pricing?b=2&a=1", Title: "Pricing", Scraped: time.Unix(100, 0), ContentHash: "v1"}
+	if err := history.Observe(ctx, first); err != nil || first.Change.Status != types.ChangeNew {
+		t.Fatalf("Observe() = %v, %+v, want a new page", err, first.Change)
+	}
+	if err := backend.Save(ctx, []types.ScrapedData{*first}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later process finds the stored result under its canonical URL
+	page := &types.ScrapedData{URL: "https://EXAMPLE.com/pricing?a=1&b=2", Title: "Pricing", Scraped: time.Unix(200, 0), ContentHash: "v2"}
+	if err := NewHistory(backend).Observe(ctx, page); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	if page.Change.Status != types.ChangeChanged || page.Change.PreviousHash != "v1" {
+		t.Errorf("Change = %+v, want changed since the stored scrape", page.Change)
+	}
+}
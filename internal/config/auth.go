@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Authentication types supported by profiles
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAPIKey = "api_key"
+	AuthOAuth2 = "oauth2_client_credentials"
+)
+
+// AuthProfile describes how to authenticate against a domain. Secret fields
+// hold references ("env:NAME" or "file:/path"), never the secret itself.
+type AuthProfile struct {
+	Type string `json:"type"`
+
+	// Basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"` // Secret reference
+
+	// Bearer
+	Token string `json:"token,omitempty"` // Secret reference
+
+	// API key, sent in Header or, if set, in the QueryParam query parameter
+	APIKey     string `json:"api_key,omitempty"` // Secret reference
+	Header     string `json:"header,omitempty"`
+	QueryParam string `json:"query_param,omitempty"`
+
+	// OAuth2 client credentials
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"` // Secret reference
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// Validate ensures the profile is complete and only references its secrets
+func (p *AuthProfile) Validate() error {
+	secrets := map[string]string{}
+	switch p.Type {
+	case AuthBasic:
+		if p.Username == "" {
+			return fmt.Errorf("basic auth requires username")
+		}
+		secrets["password"] = p.Password
+	case AuthBearer:
+		secrets["token"] = p.Token
+	case AuthAPIKey:
+		if p.Header != "" && p.QueryParam != "" {
+			return fmt.Errorf("api_key auth takes either header or query_param, not both")
+		}
+		secrets["api_key"] = p.APIKey
+	case AuthOAuth2:
+		if p.TokenURL == "" || p.ClientID == "" {
+			return fmt.Errorf("oauth2 auth requires token_url and client_id")
+		}
+		secrets["client_secret"] = p.ClientSecret
+	default:
+		return fmt.Errorf("invalid auth type: %s, must be one of: %s, %s, %s, %s",
+			p.Type, AuthBasic, AuthBearer, AuthAPIKey, AuthOAuth2)
+	}
+
+	for field, ref := range secrets {
+		if !IsSecretRef(ref) {
+			return fmt.Errorf("%s must be a secret reference (env:NAME or file:/path)", field)
+		}
+	}
+	return nil
+}
+
+// IsSecretRef reports whether value is an env: or file: secret reference
+func IsSecretRef(value string) bool {
+	name, found := strings.CutPrefix(value, "env:")
+	if !found {
+		name, found = strings.CutPrefix(value, "file:")
+	}
+	return found && name != ""
+}
+
+// LoadAuthProfiles loads per-domain auth profiles from AuthConfigFile, if set.
+// The file is a JSON object keyed by domain; unlike TLS profiles there is no
+// "*" fallback, so credentials only go to the listed domains. Secrets are referenced as env:NAME or file:/path and resolved at request time.
+func (c *Config) LoadAuthProfiles() error {
+	if c.AuthConfigFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.AuthConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read auth config: %v", err)
+	}
+
+	var profiles map[string]AuthProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse auth config: %v", err)
+	}
+
+	c.AuthProfiles = profiles
+	return nil
+}
+
+// AuthProfileFor returns the profile key and auth profile that apply to host,
+// matched like TLS profiles but never through the "*" fallback. A nil profile
+// means requests are sent unauthenticated.
+func (c *Config) AuthProfileFor(host string) (string, *AuthProfile) {
+	key, profile := profileFor(c.AuthProfiles, host)
+	if key == DefaultTLSProfile {
+		return "", nil
+	}
+	return key, profile
+}
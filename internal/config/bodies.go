@@ -0,0 +1,17 @@
+package config
+
+import "fmt"
+
+// Body modes controlling how response bodies appear in exported results
+const (
+	BodyInline = "inline" // Body included in the result
+	BodyStore  = "store"  // Body written to a file referenced by the result
+)
+
+// ValidateBodyMode ensures mode is a known body mode ("" omits bodies)
+func ValidateBodyMode(mode string) error {
+	if mode != "" && mode != BodyInline && mode != BodyStore {
+		return fmt.Errorf("invalid body mode: %s, must be one of: %s, %s", mode, BodyInline, BodyStore)
+	}
+	return nil
+}
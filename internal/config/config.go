@@ -1,34 +1,92 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
+
+	"arachne/internal/har"
+	"arachne/pkg/parser"
 )
 
 // Config holds all configuration for the scraper
 type Config struct {
-	MaxConcurrent           int            `json:"max_concurrent"`
-	RequestTimeout          time.Duration  `json:"request_timeout"`
-	TotalTimeout            time.Duration  `json:"total_timeout"`
-	UserAgent               string         `json:"user_agent"`
-	OutputFile              string         `json:"output_file"`
-	RetryAttempts           int            `json:"retry_attempts"`
-	RetryDelay              time.Duration  `json:"retry_delay"`
-	EnableMetrics           bool           `json:"enable_metrics"`
-	EnableLogging           bool           `json:"enable_logging"`
-	LogLevel                string         `json:"log_level"`
-	DomainRateLimit         map[string]int `json:"domain_rate_limit"`
-	CircuitBreakerThreshold int            `json:"circuit_breaker_threshold"`
-	CircuitBreakerTimeout   time.Duration  `json:"circuit_breaker_timeout"`
-	UseHeadless             bool           `json:"use_headless"`
-	MaxPages                int            `json:"max_pages"`
-	StorageBackend          string         `json:"storage_backend"`
-	EnablePlugins           bool           `json:"enable_plugins"`
-	RedisAddr               string         `json:"redis_addr"`
-	RedisPassword           string         `json:"redis_password"`
-	RedisDB                 int            `json:"redis_db"`
+	MaxConcurrent           int                      `json:"max_concurrent"`
+	RequestTimeout          time.Duration            `json:"request_timeout"`
+	TotalTimeout            time.Duration            `json:"total_timeout"`
+	UserAgent               string                   `json:"user_agent"`
+	OutputFile              string                   `json:"output_file"`
+	RetryAttempts           int                      `json:"retry_attempts"`
+	RetryDelay              time.Duration            `json:"retry_delay"`
+	EnableMetrics           bool                     `json:"enable_metrics"`
+	EnableLogging           bool                     `json:"enable_logging"`
+	LogLevel                string                   `json:"log_level"`
+	DomainRateLimit         map[string]int           `json:"domain_rate_limit"`
+	CircuitBreakerThreshold int                      `json:"circuit_breaker_threshold"`
+	CircuitBreakerTimeout   time.Duration            `json:"circuit_breaker_timeout"`
+	UseHeadless             bool                     `json:"use_headless"`
+	MaxPages                int                      `json:"max_pages"`
+	StorageBackend          string                   `json:"storage_backend"`
+	SQLitePath              string                   `json:"sqlite_path"`
+	PostgresDSN             string                   `json:"-"` // Connection string of the postgres backend, may hold a password
+	PostgresMaxConns        int32                    `json:"postgres_max_conns"`
+	PostgresMinConns        int32                    `json:"postgres_min_conns"`
+	PostgresMaxConnLifetime time.Duration            `json:"postgres_max_conn_lifetime"`
+	PostgresMaxConnIdleTime time.Duration            `json:"postgres_max_conn_idle_time"`
+	EnablePlugins           bool                     `json:"enable_plugins"`
+	RedisAddr               string                   `json:"redis_addr"`
+	RedisPassword           string                   `json:"redis_password"`
+	RedisDB                 int                      `json:"redis_db"`
+	TLSConfigFile           string                   `json:"tls_config_file"`
+	TLSProfiles             map[string]TLSProfile    `json:"tls_profiles"`
+	AuthConfigFile          string                   `json:"auth_config_file"`
+	AuthProfiles            map[string]AuthProfile   `json:"auth_profiles"`
+	HARFile                 string                   `json:"har_file"`
+	HARIncludeBodies        bool                     `json:"har_include_bodies"`
+	HARRecorder             *har.Recorder            `json:"-"` // Set per run or per job to record traffic
+	ArtifactDir             string                   `json:"artifact_dir"`
+	ReplayMode              string                   `json:"replay_mode"` // "", "record" or "replay"
+	FixturesDir             string                   `json:"fixtures_dir"`
+	Strategy                string                   `json:"strategy"`       // Explicit strategy for all URLs, "" = automatic
+	URLStrategies           map[string]string        `json:"url_strategies"` // URL prefix -> strategy
+	FeedFollowLinks         bool                     `json:"feed_follow_links"`
+	SchemaFile              string                   `json:"schema_file"`
+	Schema                  *parser.Schema           `json:"schema,omitempty"` // Extraction schema applied to HTML pages
+	ValidationSchemaFile    string                   `json:"validation_schema_file"`
+	ValidationSchema        json.RawMessage          `json:"validation_schema"`    // JSON Schema the extracted records are checked against (plugin)
+	Readability             bool                     `json:"readability"`          // Extract main article content (plugin)
+	ReadabilityMarkdown     bool                     `json:"readability_markdown"` // Also render the article as Markdown
+	ExtractLinks            bool                     `json:"extract_links"`        // Return the outgoing links of HTML pages
+	ExtractTables           bool                     `json:"extract_tables"`       // Convert HTML tables to rows and CSV
+	TableSelector           string                   `json:"table_selector"`       // CSS selector limiting which tables are extracted
+	TableIndexes            []int                    `json:"table_indexes"`        // Positions of the tables to extract, all when empty
+	Dedup                   string                   `json:"dedup"`                // Near-duplicate handling: "", "mark" or "drop"
+	DedupThreshold          int                      `json:"dedup_threshold"`      // Max SimHash Hamming distance of near-duplicates
+	DetectChanges           bool                     `json:"detect_changes"`       // Compare pages with their previous scrape in the storage backend (plugin)
+	DownloadAssets          bool                     `json:"download_assets"`      // Download page assets into AssetDir
+	AssetKinds              []string                 `json:"asset_kinds"`          // image, stylesheet, script, document; all when empty
+	AssetExtensions         []string                 `json:"asset_extensions"`     // Link extensions downloaded as documents
+	AssetDir                string                   `json:"asset_dir"`            // Content-addressed asset store
+	AssetMaxSize            int64                    `json:"asset_max_size"`       // Largest asset downloaded, in bytes
+	BodyMode                string                   `json:"body_mode"`            // Response bodies in results: "", "inline" or "store"
+	BodyDir                 string                   `json:"body_dir"`             // Directory of stored bodies
+	PluginErrorPolicy       string                   `json:"plugin_error_policy"`  // Default plugin error policy: abort, skip or continue
+	PluginTimeout           time.Duration            `json:"plugin_timeout"`       // Default time limit of one plugin run, 0 for none
+	PluginPolicies          map[string]string        `json:"plugin_policies"`      // Plugin name -> error policy
+	PluginTimeouts          map[string]time.Duration `json:"plugin_timeouts"`      // Plugin name -> time limit
+	PluginConfigFile        string                   `json:"plugin_config_file"`   // JSON file listing external plugins
+	ExternalPlugins         []ExternalPlugin         `json:"external_plugins"`
+	WASMPluginDir           string                   `json:"wasm_plugin_dir"`      // Directory of *.wasm plugins, also where uploads are stored
+	WASMMemoryLimitMB       int                      `json:"wasm_memory_limit_mb"` // Linear memory limit of one WASM plugin call
+	WASMMaxCalls            int64                    `json:"wasm_max_calls"`       // Guest function calls allowed per WASM plugin call; CPU time is only bounded by the plugin timeout
+	WASMCacheDir            string                   `json:"wasm_cache_dir"`       // Persistent compilation cache, in memory when empty
+	AdminToken              string                   `json:"-"`                    // Bearer token of the admin API, disabled when empty
+	WebhookSecret           string                   `json:"-"`                    // HMAC key signing webhook deliveries, webhooks disabled when empty
+	WebhookRetries          int                      `json:"webhook_retries"`      // Retries of a failed webhook delivery
+	WebhookBackoff          time.Duration            `json:"webhook_backoff"`      // Delay before the first retry, doubled for each next one
+	WebhookTimeout          time.Duration            `json:"webhook_timeout"`      // Time limit of one webhook delivery attempt
 }
 
 // DefaultConfig returns default configuration
@@ -50,10 +108,52 @@ func DefaultConfig() *Config {
 		UseHeadless:             false,
 		MaxPages:                10,
 		StorageBackend:          "json",
+		SQLitePath:              "arachne.db",
+		PostgresMaxConns:        10,
+		PostgresMinConns:        0,
+		PostgresMaxConnLifetime: time.Hour,
+		PostgresMaxConnIdleTime: 30 * time.Minute,
 		EnablePlugins:           true,
 		RedisAddr:               "",
 		RedisPassword:           "",
 		RedisDB:                 0,
+		TLSConfigFile:           "",
+		TLSProfiles:             make(map[string]TLSProfile),
+		AuthConfigFile:          "",
+		AuthProfiles:            make(map[string]AuthProfile),
+		HARFile:                 "",
+		HARIncludeBodies:        false,
+		ArtifactDir:             "artifacts",
+		ReplayMode:              "",
+		FixturesDir:             "tests/fixtures/http",
+		Strategy:                "",
+		URLStrategies:           make(map[string]string),
+		FeedFollowLinks:         false,
+		SchemaFile:              "",
+		ValidationSchemaFile:    "",
+		Readability:             false,
+		ReadabilityMarkdown:     false,
+		ExtractLinks:            false,
+		ExtractTables:           false,
+		TableSelector:           "",
+		Dedup:                   "",
+		DedupThreshold:          DefaultDedupThreshold,
+		DetectChanges:           false,
+		DownloadAssets:          false,
+		AssetExtensions:         []string{".pdf"},
+		AssetDir:                "downloads",
+		AssetMaxSize:            20 << 20,
+		BodyMode:                "",
+		BodyDir:                 "bodies",
+		PluginErrorPolicy:       PluginAbort,
+		PluginTimeout:           10 * time.Second,
+		PluginPolicies:          make(map[string]string),
+		PluginTimeouts:          make(map[string]time.Duration),
+		WASMMemoryLimitMB:       16,
+		WASMMaxCalls:            10_000_000,
+		WebhookRetries:          5,
+		WebhookBackoff:          1 * time.Second,
+		WebhookTimeout:          10 * time.Second,
 	}
 }
 
@@ -134,6 +234,42 @@ func LoadConfig() *Config {
 		}
 	}
 
+	if val := os.Getenv("SCRAPER_STORAGE_BACKEND"); val != "" {
+		config.StorageBackend = val
+	}
+
+	if val := os.Getenv("SCRAPER_SQLITE_PATH"); val != "" {
+		config.SQLitePath = val
+	}
+
+	if val := os.Getenv("SCRAPER_POSTGRES_DSN"); val != "" {
+		config.PostgresDSN = val
+	}
+
+	if val := os.Getenv("SCRAPER_POSTGRES_MAX_CONNS"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 32); err == nil {
+			config.PostgresMaxConns = int32(parsed)
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_POSTGRES_MIN_CONNS"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 32); err == nil {
+			config.PostgresMinConns = int32(parsed)
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_POSTGRES_MAX_CONN_LIFETIME"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil {
+			config.PostgresMaxConnLifetime = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_POSTGRES_MAX_CONN_IDLE_TIME"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil {
+			config.PostgresMaxConnIdleTime = parsed
+		}
+	}
+
 	if val := os.Getenv("SCRAPER_REDIS_ADDR"); val != "" {
 		config.RedisAddr = val
 	}
@@ -148,6 +284,188 @@ func LoadConfig() *Config {
 		}
 	}
 
+	if val := os.Getenv("SCRAPER_TLS_CONFIG"); val != "" {
+		config.TLSConfigFile = val
+	}
+
+	if val := os.Getenv("SCRAPER_AUTH_CONFIG"); val != "" {
+		config.AuthConfigFile = val
+	}
+
+	if val := os.Getenv("SCRAPER_HAR_INCLUDE_BODIES"); val != "" {
+		config.HARIncludeBodies = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_ARTIFACT_DIR"); val != "" {
+		config.ArtifactDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_REPLAY_MODE"); val != "" {
+		config.ReplayMode = val
+	}
+
+	if val := os.Getenv("SCRAPER_FIXTURES_DIR"); val != "" {
+		config.FixturesDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_STRATEGY"); val != "" {
+		config.Strategy = val
+	}
+
+	if val := os.Getenv("SCRAPER_URL_STRATEGIES"); val != "" {
+		config.URLStrategies = parsePairs(val)
+	}
+
+	if val := os.Getenv("SCRAPER_FEED_FOLLOW_LINKS"); val != "" {
+		config.FeedFollowLinks = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_SCHEMA_FILE"); val != "" {
+		config.SchemaFile = val
+	}
+
+	if val := os.Getenv("SCRAPER_VALIDATION_SCHEMA_FILE"); val != "" {
+		config.ValidationSchemaFile = val
+	}
+
+	if val := os.Getenv("SCRAPER_READABILITY"); val != "" {
+		config.Readability = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_READABILITY_MARKDOWN"); val != "" {
+		config.ReadabilityMarkdown = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_EXTRACT_LINKS"); val != "" {
+		config.ExtractLinks = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_EXTRACT_TABLES"); val != "" {
+		config.ExtractTables = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_TABLE_SELECTOR"); val != "" {
+		config.TableSelector = val
+	}
+
+	if val := os.Getenv("SCRAPER_TABLE_INDEXES"); val != "" {
+		if parsed, err := ParseTableIndexes(val); err == nil {
+			config.TableIndexes = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_DEDUP"); val != "" {
+		config.Dedup = val
+	}
+
+	if val := os.Getenv("SCRAPER_DEDUP_THRESHOLD"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil {
+			config.DedupThreshold = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_DETECT_CHANGES"); val != "" {
+		config.DetectChanges = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_DOWNLOAD_ASSETS"); val != "" {
+		config.DownloadAssets = val == "true"
+	}
+
+	if val := os.Getenv("SCRAPER_ASSET_KINDS"); val != "" {
+		config.AssetKinds = ParseList(val)
+	}
+
+	if val := os.Getenv("SCRAPER_ASSET_EXTENSIONS"); val != "" {
+		config.AssetExtensions = ParseList(val)
+	}
+
+	if val := os.Getenv("SCRAPER_ASSET_DIR"); val != "" {
+		config.AssetDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_ASSET_MAX_SIZE"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.AssetMaxSize = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_BODY_MODE"); val != "" {
+		config.BodyMode = val
+	}
+
+	if val := os.Getenv("SCRAPER_BODY_DIR"); val != "" {
+		config.BodyDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_PLUGIN_ERROR_POLICY"); val != "" {
+		config.PluginErrorPolicy = val
+	}
+
+	if val := os.Getenv("SCRAPER_PLUGIN_TIMEOUT"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil {
+			config.PluginTimeout = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_PLUGIN_POLICIES"); val != "" {
+		config.PluginPolicies = parsePairs(val)
+	}
+
+	if val := os.Getenv("SCRAPER_PLUGIN_TIMEOUTS"); val != "" {
+		config.PluginTimeouts = parsePluginTimeouts(val)
+	}
+
+	if val := os.Getenv("SCRAPER_PLUGIN_CONFIG"); val != "" {
+		config.PluginConfigFile = val
+	}
+
+	if val := os.Getenv("SCRAPER_WASM_PLUGIN_DIR"); val != "" {
+		config.WASMPluginDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_WASM_MEMORY_LIMIT_MB"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil {
+			config.WASMMemoryLimitMB = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_WASM_MAX_CALLS"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.WASMMaxCalls = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_WASM_CACHE_DIR"); val != "" {
+		config.WASMCacheDir = val
+	}
+
+	if val := os.Getenv("SCRAPER_ADMIN_TOKEN"); val != "" {
+		config.AdminToken = val
+	}
+
+	if val := os.Getenv("SCRAPER_WEBHOOK_SECRET"); val != "" {
+		config.WebhookSecret = val
+	}
+
+	if val := os.Getenv("SCRAPER_WEBHOOK_RETRIES"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil {
+			config.WebhookRetries = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_WEBHOOK_BACKOFF"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil {
+			config.WebhookBackoff = parsed
+		}
+	}
+
+	if val := os.Getenv("SCRAPER_WEBHOOK_TIMEOUT"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil {
+			config.WebhookTimeout = parsed
+		}
+	}
+
 	return config
 }
 
@@ -178,6 +496,137 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log_level: %s, must be one of: debug, info, warn, error", c.LogLevel)
 	}
 
+	validReplayModes := map[string]bool{"": true, "record": true, "replay": true}
+	if !validReplayModes[c.ReplayMode] {
+		return fmt.Errorf("invalid replay_mode: %s, must be one of: record, replay", c.ReplayMode)
+	}
+
+	if err := ValidateStrategy(c.Strategy); err != nil {
+		return err
+	}
+	for prefix, strategy := range c.URLStrategies {
+		if err := ValidateStrategy(strategy); err != nil {
+			return fmt.Errorf("url_strategies[%s]: %v", prefix, err)
+		}
+	}
+
+	for domain, profile := range c.TLSProfiles {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("invalid TLS profile for %s: %v", domain, err)
+		}
+	}
+
+	if c.Schema != nil {
+		if err := c.Schema.Validate(); err != nil {
+			return fmt.Errorf("invalid extraction schema: %v", err)
+		}
+	}
+
+	if spec := c.TableSpec(); spec != nil {
+		if err := spec.Validate(); err != nil {
+			return fmt.Errorf("invalid table selection: %v", err)
+		}
+	}
+
+	if err := ValidateDedupMode(c.Dedup); err != nil {
+		return err
+	}
+	if c.DedupThreshold < 0 || c.DedupThreshold > 64 {
+		return fmt.Errorf("dedup_threshold must be between 0 and 64, got %d", c.DedupThreshold)
+	}
+
+	validStorageBackends := map[string]bool{"json": true, "memory": true, "sqlite": true, "postgres": true}
+	if !validStorageBackends[c.StorageBackend] {
+		return fmt.Errorf("invalid storage_backend: %s, must be one of: json, memory, sqlite, postgres", c.StorageBackend)
+	}
+	if c.StorageBackend == "sqlite" && c.SQLitePath == "" {
+		return fmt.Errorf("sqlite_path is required for the sqlite storage backend")
+	}
+	if c.StorageBackend == "postgres" && c.PostgresDSN == "" {
+		return fmt.Errorf("postgres_dsn is required for the postgres storage backend")
+	}
+	if c.PostgresMaxConns <= 0 {
+		return fmt.Errorf("postgres_max_conns must be positive, got %d", c.PostgresMaxConns)
+	}
+	if c.PostgresMinConns < 0 || c.PostgresMinConns > c.PostgresMaxConns {
+		return fmt.Errorf("postgres_min_conns must be between 0 and postgres_max_conns, got %d", c.PostgresMinConns)
+	}
+	if c.PostgresMaxConnLifetime < 0 || c.PostgresMaxConnIdleTime < 0 {
+		return fmt.Errorf("postgres connection lifetimes cannot be negative")
+	}
+
+	if err := parser.ValidateAssetKinds(c.AssetKinds); err != nil {
+		return err
+	}
+	if c.DownloadAssets && c.AssetDir == "" {
+		return fmt.Errorf("asset_dir is required to download assets")
+	}
+	if c.AssetMaxSize <= 0 {
+		return fmt.Errorf("asset_max_size must be positive, got %d", c.AssetMaxSize)
+	}
+
+	if err := ValidateBodyMode(c.BodyMode); err != nil {
+		return err
+	}
+	if c.BodyMode == BodyStore && c.BodyDir == "" {
+		return fmt.Errorf("body_dir is required to store bodies")
+	}
+
+	if err := ValidatePluginPolicy(c.PluginErrorPolicy); err != nil {
+		return err
+	}
+	for name, policy := range c.PluginPolicies {
+		if err := ValidatePluginPolicy(policy); err != nil {
+			return fmt.Errorf("invalid error policy for plugin %s: %v", name, err)
+		}
+	}
+	if c.PluginTimeout < 0 {
+		return fmt.Errorf("plugin_timeout must not be negative, got %v", c.PluginTimeout)
+	}
+	pluginNames := make(map[string]bool)
+	for i, plugin := range c.ExternalPlugins {
+		if err := plugin.Validate(); err != nil {
+			return fmt.Errorf("invalid external plugin %d: %v", i, err)
+		}
+		if pluginNames[plugin.Name] {
+			return fmt.Errorf("duplicate external plugin name: %s", plugin.Name)
+		}
+		pluginNames[plugin.Name] = true
+	}
+	// Linear memory is limited in 64 KiB pages, at most 4 GiB
+	if c.WASMMemoryLimitMB <= 0 || c.WASMMemoryLimitMB > 4096 {
+		return fmt.Errorf("wasm_memory_limit_mb must be between 1 and 4096, got %d", c.WASMMemoryLimitMB)
+	}
+	if c.WASMMaxCalls <= 0 {
+		return fmt.Errorf("wasm_max_calls must be positive, got %d", c.WASMMaxCalls)
+	}
+	// Instructions are not metered, so the timeout is the only bound on the
+	// CPU time of WASM plugins
+	if c.WASMPluginDir != "" && c.PluginTimeout <= 0 {
+		return fmt.Errorf("plugin_timeout must be positive when WASM plugins are enabled, got %v", c.PluginTimeout)
+	}
+
+	if c.WebhookRetries < 0 {
+		return fmt.Errorf("webhook_retries cannot be negative, got %d", c.WebhookRetries)
+	}
+
+	if c.WebhookBackoff < 0 {
+		return fmt.Errorf("webhook_backoff cannot be negative, got %v", c.WebhookBackoff)
+	}
+
+	if c.WebhookTimeout <= 0 {
+		return fmt.Errorf("webhook_timeout must be positive, got %v", c.WebhookTimeout)
+	}
+
+	for domain, profile := range c.AuthProfiles {
+		if domain == DefaultTLSProfile {
+			return fmt.Errorf("auth profiles must name their domains, %q would send credentials to every host", domain)
+		}
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("invalid auth profile for %s: %v", domain, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+// Modes of the dedup stage
+const (
+	DedupMark = "mark" // Keep near-duplicates, setting DuplicateOf
+	DedupDrop = "drop" // Remove near-duplicates from the results
+)
+
+// DefaultDedupThreshold is the Hamming distance up to which two SimHash
+// fingerprints are considered near-duplicates
+const DefaultDedupThreshold = 3
+
+// ValidateDedupMode ensures mode is a known dedup mode ("" disables dedup)
+func ValidateDedupMode(mode string) error {
+	if mode != "" && mode != DedupMark && mode != DedupDrop {
+		return fmt.Errorf("invalid dedup mode: %s, must be one of: mark, drop", mode)
+	}
+	return nil
+}
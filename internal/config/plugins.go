@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Plugin error policies accepted in PluginErrorPolicy and PluginPolicies
+const (
+	PluginAbort    = "abort"    // Stop the pipeline and fail the record
+	PluginSkip     = "skip"     // Stop the pipeline and drop the record
+	PluginContinue = "continue" // Record the error and run the next plugin
+)
+
+// ValidatePluginPolicy ensures policy is a known plugin error policy
+func ValidatePluginPolicy(policy string) error {
+	switch policy {
+	case PluginAbort, PluginSkip, PluginContinue:
+		return nil
+	}
+	return fmt.Errorf("invalid plugin error policy: %s, must be one of: abort, skip, continue", policy)
+}
+
+// ExternalPlugin configures a plugin running as a separate executable that
+// exchanges records as JSON lines over stdin and stdout
+type ExternalPlugin struct {
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`                // Executable, looked up in PATH
+	Args        []string          `json:"args,omitempty"`         // Command-line arguments
+	Env         map[string]string `json:"env,omitempty"`          // Added to the scraper's environment
+	Dir         string            `json:"dir,omitempty"`          // Working directory
+	Timeout     string            `json:"timeout,omitempty"`      // Per record, e.g. "5s"; PluginTimeout when empty
+	ErrorPolicy string            `json:"error_policy,omitempty"` // abort, skip or continue; PluginErrorPolicy when empty
+	Priority    *int              `json:"priority,omitempty"`     // Position in the pipeline, lower runs first
+	DependsOn   []string          `json:"depends_on,omitempty"`   // Plugins that must run before this one
+	MaxRestarts *int              `json:"max_restarts,omitempty"` // Consecutive crashes tolerated, 3 when unset
+}
+
+// DefaultPluginRestarts is the number of consecutive crashes after which an
+// external plugin is no longer restarted
+const DefaultPluginRestarts = 3
+
+// Validate ensures the external plugin is usable
+func (p *ExternalPlugin) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Command == "" {
+		return fmt.Errorf("command is required")
+	}
+	if p.Timeout != "" {
+		if timeout, err := time.ParseDuration(p.Timeout); err != nil || timeout < 0 {
+			return fmt.Errorf("invalid timeout: %s", p.Timeout)
+		}
+	}
+	if p.ErrorPolicy != "" {
+		if err := ValidatePluginPolicy(p.ErrorPolicy); err != nil {
+			return err
+		}
+	}
+	if p.MaxRestarts != nil && *p.MaxRestarts < 0 {
+		return fmt.Errorf("max_restarts must not be negative, got %d", *p.MaxRestarts)
+	}
+	return nil
+}
+
+// Restarts returns the number of consecutive crashes tolerated
+func (p *ExternalPlugin) Restarts() int {
+	if p.MaxRestarts == nil {
+		return DefaultPluginRestarts
+	}
+	return *p.MaxRestarts
+}
+
+// pluginConfigFile is the layout of PluginConfigFile
+type pluginConfigFile struct {
+	Plugins []ExternalPlugin `json:"plugins"`
+}
+
+// LoadPluginConfig loads the external plugins from PluginConfigFile, if set.
+// The file is a JSON object with a "plugins" list, run in the configured order
+// unless priorities or dependencies say otherwise.
+func (c *Config) LoadPluginConfig() error {
+	if c.PluginConfigFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.PluginConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin config: %v", err)
+	}
+
+	var file pluginConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse plugin config: %v", err)
+	}
+
+	c.ExternalPlugins = file.Plugins
+	return nil
+}
+
+// PluginSettings returns the error policy and timeout of the named plugin.
+// PluginPolicies and PluginTimeouts entries win over the plugin config file,
+// which wins over the defaults.
+func (c *Config) PluginSettings(name string) (string, time.Duration) {
+	policy := c.PluginErrorPolicy
+	timeout := c.PluginTimeout
+	for _, plugin := range c.ExternalPlugins {
+		if plugin.Name != name {
+			continue
+		}
+		if plugin.ErrorPolicy != "" {
+			policy = plugin.ErrorPolicy
+		}
+		if parsed, err := time.ParseDuration(plugin.Timeout); err == nil {
+			timeout = parsed
+		}
+	}
+
+	if override, ok := c.PluginPolicies[name]; ok {
+		policy = override
+	}
+	if override, ok := c.PluginTimeouts[name]; ok {
+		timeout = override
+	}
+	return policy, timeout
+}
+
+// parsePluginTimeouts parses "name=duration" pairs separated by commas,
+// dropping invalid durations
+func parsePluginTimeouts(value string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for name, raw := range parsePairs(value) {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			timeouts[name] = parsed
+		}
+	}
+	return timeouts
+}
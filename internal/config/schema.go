@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"arachne/pkg/parser"
+)
+
+// LoadSchema loads the extraction schema from SchemaFile, if set
+func (c *Config) LoadSchema() error {
+	if c.SchemaFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.SchemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %v", err)
+	}
+
+	var schema parser.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("failed to parse schema: %v", err)
+	}
+
+	c.Schema = &schema
+	return nil
+}
+
+// LoadValidationSchema loads the JSON Schema records are validated against
+// from ValidationSchemaFile, if set
+func (c *Config) LoadValidationSchema() error {
+	if c.ValidationSchemaFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.ValidationSchemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read validation schema: %v", err)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("failed to parse validation schema: invalid JSON")
+	}
+
+	c.ValidationSchema = data
+	return nil
+}
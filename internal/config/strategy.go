@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategy names accepted in Strategy and URLStrategies
+const (
+	StrategyHTTP     = "http"
+	StrategyHeadless = "headless"
+	StrategyFeed     = "feed"
+)
+
+// validStrategies lists the strategy names that can be selected explicitly
+var validStrategies = map[string]bool{StrategyHTTP: true, StrategyHeadless: true, StrategyFeed: true}
+
+// ValidateStrategy ensures name is a known strategy ("" means automatic)
+func ValidateStrategy(name string) error {
+	if name != "" && !validStrategies[name] {
+		return fmt.Errorf("invalid strategy: %s, must be one of: http, headless, feed", name)
+	}
+	return nil
+}
+
+// StrategyFor returns the strategy name for urlStr: the longest matching
+// URLStrategies prefix wins, then Strategy, then the UseHeadless switch
+func (c *Config) StrategyFor(urlStr string) string {
+	best := ""
+	name := ""
+	for prefix, strategy := range c.URLStrategies {
+		if strings.HasPrefix(urlStr, prefix) && len(prefix) > len(best) {
+			best = prefix
+			name = strategy
+		}
+	}
+	if name != "" {
+		return name
+	}
+
+	if c.Strategy != "" {
+		return c.Strategy
+	}
+	if c.UseHeadless {
+		return StrategyHeadless
+	}
+	return StrategyHTTP
+}
+
+// parsePairs parses "key=value" pairs separated by commas, such as the
+// "prefix=strategy" pairs of SCRAPER_URL_STRATEGIES
+func parsePairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && key != "" {
+			pairs[key] = val
+		}
+	}
+	return pairs
+}
+
+// ParseList parses a comma-separated list, dropping empty entries
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
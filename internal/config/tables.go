@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"arachne/pkg/parser"
+)
+
+// TableSpec returns the tables to extract from HTML pages, or nil when table
+// extraction is disabled
+func (c *Config) TableSpec() *parser.TableSpec {
+	if !c.ExtractTables {
+		return nil
+	}
+	return &parser.TableSpec{Selector: c.TableSelector, Indexes: c.TableIndexes}
+}
+
+// ParseTableIndexes parses a comma-separated list of zero-based table indexes
+func ParseTableIndexes(value string) ([]int, error) {
+	var indexes []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid table index %q", part)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
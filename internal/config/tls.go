@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultTLSProfile is the profile key applied to hosts without a more specific profile
+const DefaultTLSProfile = "*"
+
+// TLSProfile describes the TLS settings used when connecting to a domain
+type TLSProfile struct {
+	CAFiles            []string `json:"ca_files,omitempty"`             // Extra PEM root CAs trusted in addition to the system pool
+	ClientCertFile     string   `json:"client_cert_file,omitempty"`     // PEM client certificate for mTLS
+	ClientKeyFile      string   `json:"client_key_file,omitempty"`      // PEM private key for the client certificate
+	MinVersion         string   `json:"min_version,omitempty"`          // "1.0", "1.1", "1.2" or "1.3"
+	InsecureSkipVerify bool     `json:"insecure_skip_verify,omitempty"` // Disable certificate verification (never the default)
+}
+
+// tlsVersions maps configuration values to crypto/tls version constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSVersion returns the crypto/tls constant for the profile's minimum version
+func (p *TLSProfile) TLSVersion() uint16 {
+	return tlsVersions[p.MinVersion]
+}
+
+// HasCustomTrust reports whether the profile needs more than the system trust store
+func (p *TLSProfile) HasCustomTrust() bool {
+	return len(p.CAFiles) > 0 || p.ClientCertFile != ""
+}
+
+// HasSettings reports whether the profile sets any field, so connections
+// need a transport built from it
+func (p *TLSProfile) HasSettings() bool {
+	return p.HasCustomTrust() || p.MinVersion != "" || p.InsecureSkipVerify
+}
+
+// Validate ensures the TLS profile is usable
+func (p *TLSProfile) Validate() error {
+	if p.MinVersion != "" {
+		if _, ok := tlsVersions[p.MinVersion]; !ok {
+			return fmt.Errorf("invalid min_version: %s, must be one of: 1.0, 1.1, 1.2, 1.3", p.MinVersion)
+		}
+	}
+
+	if (p.ClientCertFile == "") != (p.ClientKeyFile == "") {
+		return fmt.Errorf("client_cert_file and client_key_file must be set together")
+	}
+
+	return nil
+}
+
+// LoadTLSProfiles loads per-domain TLS profiles from TLSConfigFile, if set.
+// The file is a JSON object keyed by domain, with "*" as the fallback profile.
+func (c *Config) LoadTLSProfiles() error {
+	if c.TLSConfigFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.TLSConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS config: %v", err)
+	}
+
+	var profiles map[string]TLSProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse TLS config: %v", err)
+	}
+
+	c.TLSProfiles = profiles
+	return nil
+}
+
+// TLSProfileFor returns the profile key and profile that apply to host.
+// Exact matches win over parent domains, which win over the "*" profile.
+// A nil profile means the default, fully verified TLS settings.
+func (c *Config) TLSProfileFor(host string) (string, *TLSProfile) {
+	return profileFor(c.TLSProfiles, host)
+}
+
+// profileFor finds the per-domain profile for host: an exact match, then the
+// closest parent domain, then the DefaultTLSProfile ("*") fallback
+func profileFor[P any](profiles map[string]P, host string) (string, *P) {
+	if len(profiles) == 0 {
+		return "", nil
+	}
+
+	host = strings.ToLower(host)
+	for domain := host; domain != ""; {
+		if profile, ok := profiles[domain]; ok {
+			return domain, &profile
+		}
+		dot := strings.Index(domain, ".")
+		if dot == -1 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+
+	if profile, ok := profiles[DefaultTLSProfile]; ok {
+		return DefaultTLSProfile, &profile
+	}
+	return "", nil
+}
@@ -0,0 +1,143 @@
+// Package dedup fingerprints scraped content and detects near-duplicate pages
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+	"arachne/pkg/parser"
+)
+
+// shingleSize is the number of words hashed together as one SimHash feature
+const shingleSize = 3
+
+// ContentHash returns the hex SHA-256 of text with whitespace collapsed
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash returns the 64-bit SimHash of text over lower-cased word
+// shingles. Similar texts get fingerprints with a small Hamming distance.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	addFeature := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(words) < shingleSize {
+		addFeature(strings.Join(words, " "))
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		addFeature(strings.Join(words[i:i+shingleSize], " "))
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// Distance returns the number of bits in which two fingerprints differ
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Fingerprint sets the content hash and SimHash of a result from its text
+func Fingerprint(data *types.ScrapedData, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	data.ContentHash = ContentHash(text)
+	data.SimHash = fmt.Sprintf("%016x", SimHash(text))
+}
+
+// FingerprintBody fingerprints a result from its response body: the visible
+// text of HTML pages, the raw body otherwise. Results already fingerprinted
+// are left alone.
+func FingerprintBody(data *types.ScrapedData) {
+	if data.ContentHash != "" || data.Body == "" {
+		return
+	}
+	text := data.Body
+	if parser.IsHTML(data.Body, data.ContentType) {
+		if doc, err := goquery.NewDocumentFromReader(strings.NewReader(data.Body)); err == nil {
+			text = parser.VisibleText(doc)
+		}
+	}
+	Fingerprint(data, text)
+}
+
+// Filter marks or drops the results that duplicate an earlier result of the
+// same job: an identical content hash, or a SimHash within threshold bits.
+// Failed results and results without a fingerprint are always kept.
+func Filter(results []types.ScrapedData, mode string, threshold int) []types.ScrapedData {
+	if mode == "" {
+		return results
+	}
+
+	type original struct {
+		url     string
+		simhash uint64
+		hasHash bool
+		hash    string
+	}
+	var originals []original
+
+	kept := make([]types.ScrapedData, 0, len(results))
+	for _, data := range results {
+		if data.Error != "" || data.ContentHash == "" {
+			kept = append(kept, data)
+			continue
+		}
+		simhash, err := strconv.ParseUint(data.SimHash, 16, 64)
+
+		duplicateOf := ""
+		for _, o := range originals {
+			if o.hash == data.ContentHash || (err == nil && o.hasHash && Distance(o.simhash, simhash) <= threshold) {
+				duplicateOf = o.url
+				break
+			}
+		}
+
+		if duplicateOf == "" {
+			originals = append(originals, original{url: data.URL, simhash: simhash, hasHash: err == nil, hash: data.ContentHash})
+			kept = append(kept, data)
+			continue
+		}
+		if mode == config.DedupMark {
+			data.DuplicateOf = duplicateOf
+			kept = append(kept, data)
+		}
+	}
+	return kept
+}
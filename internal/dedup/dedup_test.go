@@ -0,0 +1,103 @@
+package dedup
+
+import (
+	"strings"
+	"testing"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+const article = `Arachne is a concurrent web scraper written in Go. It supports plain HTTP
+requests, headless browsing for JavaScript-heavy sites and feed parsing. Results can be
+stored as JSON, in memory or in Redis, and a plugin pipeline cleans titles, validates URLs
+and extracts the main article text of every page it visits.`
+
+func TestSimHash(t *testing.T) {
+	base := SimHash(article)
+	if base == 0 {
+		t.Fatal("SimHash() = 0 for non-empty text")
+	}
+	if SimHash(strings.ToUpper(article)) != base {
+		t.Error("SimHash() is not case-insensitive")
+	}
+
+	nearCopy := SimHash(article + " Share this page.")
+	unrelated := SimHash("Weather today: sunny with light winds, highs of twenty degrees and a cool evening breeze along the coast.")
+	if near, far := Distance(base, nearCopy), Distance(base, unrelated); near >= far {
+		t.Errorf("Distance() near copy = %d, unrelated = %d, want near copy closer", near, far)
+	}
+	if Distance(base, nearCopy) > 10 {
+		t.Errorf("Distance() near copy = %d, want a small distance", Distance(base, nearCopy))
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	if ContentHash("a  b\n c") != ContentHash("a b c") {
+		t.Error("ContentHash() depends on whitespace")
+	}
+	if ContentHash("a b c") == ContentHash("a b d") {
+		t.Error("ContentHash() collides for different text")
+	}
+}
+
+func TestFingerprintBody(t *testing.T) {
+	page := types.ScrapedData{Body: "<html><head><script>var x = 1;</script></head><body><p>" + article + "</p></body></html>"}
+	FingerprintBody(&page)
+	if page.ContentHash != ContentHash(article) || page.SimHash == "" {
+		t.Errorf("FingerprintBody() hash = %q, want the hash of the visible text", page.ContentHash)
+	}
+
+	api := types.ScrapedData{Body: `{"id": 1}`, ContentType: "application/json"}
+	FingerprintBody(&api)
+	if api.ContentHash != ContentHash(`{"id": 1}`) {
+		t.Errorf("FingerprintBody() hash = %q, want the hash of the raw body", api.ContentHash)
+	}
+
+	kept := types.ScrapedData{Body: "other", ContentHash: "given"}
+	if FingerprintBody(&kept); kept.ContentHash != "given" {
+		t.Errorf("FingerprintBody() replaced an existing fingerprint")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	results := make([]types.ScrapedData, 4)
+	results[0].URL = "https://example.com/post"
+	Fingerprint(&results[0], article)
+	results[1].URL = "https://example.com/post?utm_source=feed"
+	Fingerprint(&results[1], article)
+	results[2].URL = "https://example.com/post?ref=sidebar"
+	Fingerprint(&results[2], article+" Share this page.")
+	results[3] = types.ScrapedData{URL: "https://example.com/broken", Error: "timeout"}
+
+	// The sidebar variant differs by a few bits, so the threshold decides
+	simhash0, simhash2 := SimHash(article), SimHash(article+" Share this page.")
+	threshold := Distance(simhash0, simhash2)
+
+	marked := Filter(results, config.DedupMark, threshold)
+	if len(marked) != 4 {
+		t.Fatalf("Filter(mark) returned %d results, want 4", len(marked))
+	}
+	for i, expected := range []string{"", results[0].URL, results[0].URL, ""} {
+		if marked[i].DuplicateOf != expected {
+			t.Errorf("Filter(mark)[%d].DuplicateOf = %q, want %q", i, marked[i].DuplicateOf, expected)
+		}
+	}
+
+	// Below the distance only the exact copy is a duplicate
+	if threshold > 0 {
+		strict := Filter(results, config.DedupDrop, threshold-1)
+		if len(strict) != 3 || strict[1].URL != results[2].URL {
+			t.Errorf("Filter(drop, %d) = %d results, want the exact copy dropped", threshold-1, len(strict))
+		}
+	}
+
+	dropped := Filter(results, config.DedupDrop, threshold)
+	if len(dropped) != 2 || dropped[0].URL != results[0].URL || dropped[1].Error == "" {
+		t.Errorf("Filter(drop) kept %d results, want the original and the failed one", len(dropped))
+	}
+
+	if unchanged := Filter(results, "", threshold); len(unchanged) != 4 || unchanged[1].DuplicateOf != "" {
+		t.Error("Filter() with dedup disabled changed the results")
+	}
+}
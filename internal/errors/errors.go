@@ -167,10 +167,26 @@ func GetErrorType(err error) string {
 		return "none"
 	}
 
-	if IsTimeoutError(err) {
+	if IsTimeoutError(err) || contains(err.Error(), "deadline exceeded") {
 		return "timeout"
 	}
 
+	if contains(err.Error(), "context canceled") {
+		return "canceled"
+	}
+
+	if contains(err.Error(), "circuit breaker is open") {
+		return "circuit_open"
+	}
+
+	if contains(err.Error(), "no such host") {
+		return "dns"
+	}
+
+	if contains(err.Error(), "x509:") || contains(err.Error(), "tls:") {
+		return "tls"
+	}
+
 	if IsConnectionError(err) {
 		return "connection"
 	}
@@ -0,0 +1,432 @@
+package har
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"arachne/internal/types"
+)
+
+// HAR is the root object of a HAR 1.2 document
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+// Log holds all recorded entries
+type Log struct {
+	Version string   `json:"version"`
+	Creator *Creator `json:"creator"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Creator identifies the application that produced the log
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry represents a single request/response pair
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // Total elapsed time in milliseconds
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         *Timings  `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+// Request describes the performed request
+type Request struct {
+	Method      string     `json:"method"`
+	URL         string     `json:"url"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []*NameVal `json:"cookies"`
+	Headers     []*NameVal `json:"headers"`
+	QueryString []*NameVal `json:"queryString"`
+	PostData    *PostData  `json:"postData,omitempty"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int        `json:"bodySize"`
+}
+
+// Response describes the received response
+type Response struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []*NameVal `json:"cookies"`
+	Headers     []*NameVal `json:"headers"`
+	Content     *Content   `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int        `json:"bodySize"`
+}
+
+// NameVal is a generic name/value pair used for headers, cookies and query strings
+type NameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData describes a request body
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content describes a response body
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings holds the per-phase durations in milliseconds; -1 means not applicable
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Recorder collects HAR entries, safe for concurrent use
+type Recorder struct {
+	mu            sync.Mutex
+	entries       []*Entry
+	includeBodies bool
+}
+
+// NewRecorder creates a new HAR recorder
+func NewRecorder(includeBodies bool) *Recorder {
+	return &Recorder{
+		entries:       make([]*Entry, 0),
+		includeBodies: includeBodies,
+	}
+}
+
+// IncludeBodies reports whether response bodies should be captured
+func (r *Recorder) IncludeBodies() bool {
+	return r.includeBodies
+}
+
+// Add records an entry
+func (r *Recorder) Add(entry *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Len returns the number of recorded entries
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// HAR returns a HAR document with all entries recorded so far, ordered by start time
+func (r *Recorder) HAR() *HAR {
+	r.mu.Lock()
+	entries := make([]*Entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	return &HAR{
+		Log: &Log{
+			Version: "1.2",
+			Creator: &Creator{Name: "Arachne", Version: "2.0.0"},
+			Entries: entries,
+		},
+	}
+}
+
+// WriteFile writes the HAR document to a file
+func (r *Recorder) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %v", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Transport wraps an http.RoundTripper and records every exchange
+type Transport struct {
+	Base     http.RoundTripper
+	Recorder *Recorder
+}
+
+// NewTransport creates a recording transport around base
+func NewTransport(base http.RoundTripper, recorder *Recorder) *Transport {
+	return &Transport{Base: base, Recorder: recorder}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	phases := &phaseTimes{}
+	trace := phases.clientTrace()
+
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		// Record requests that never got a response (DNS, TLS, timeouts) too
+		t.record(req, reqBody, nil, nil, start, phases, err)
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	t.record(req, reqBody, resp, respBody, start, phases, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// record adds the entry for one exchange; resp is nil when the request failed
+// before a response arrived, and reqErr is kept as the entry comment
+func (t *Transport) record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, start time.Time, phases *phaseTimes, reqErr error) {
+	end := time.Now()
+
+	phases.mu.Lock()
+	defer phases.mu.Unlock()
+
+	entry := &Entry{
+		StartedDateTime: start,
+		Time:            millis(start, end),
+		Request:         NewRequest(req, reqBody),
+		Response: &Response{
+			Cookies: make([]*NameVal, 0),
+			Headers: make([]*NameVal, 0),
+			Content: &Content{},
+		},
+		Timings: &Timings{
+			Blocked: millis(start, firstNonZero(phases.dnsStart, phases.connectStart, phases.gotConn)),
+			DNS:     optionalMillis(phases.dnsStart, phases.dnsDone),
+			Connect: optionalMillis(phases.connectStart, phases.connectDone),
+			SSL:     optionalMillis(phases.tlsStart, phases.tlsDone),
+			Send:    millis(phases.gotConn, phases.wroteRequest),
+			Wait:    millis(phases.wroteRequest, phases.firstByte),
+			Receive: millis(phases.firstByte, end),
+		},
+		ServerIPAddress: phases.serverIP,
+	}
+	if resp != nil {
+		entry.Response = NewResponse(resp, respBody, t.Recorder.IncludeBodies())
+	}
+	if reqErr != nil {
+		entry.Comment = reqErr.Error()
+	}
+	t.Recorder.Add(entry)
+}
+
+// Trace times the phases of a request outside of HAR recording
+type Trace struct {
+	start  time.Time
+	phases phaseTimes
+}
+
+// PhaseTimings is a compact phase breakdown in milliseconds
+type PhaseTimings = types.PhaseTimings
+
+// NewTrace starts timing a request; the returned context carries the trace
+func NewTrace(ctx context.Context) (*Trace, context.Context) {
+	t := &Trace{start: time.Now()}
+	return t, httptrace.WithClientTrace(ctx, t.phases.clientTrace())
+}
+
+// Timings returns the phases recorded so far, with Total measured up to now.
+// After redirects the connection phases are those of the first request.
+func (t *Trace) Timings() *PhaseTimings {
+	end := time.Now()
+	t.phases.mu.Lock()
+	defer t.phases.mu.Unlock()
+	return &PhaseTimings{
+		DNS:     optionalMillis(t.phases.dnsStart, t.phases.dnsDone),
+		Connect: optionalMillis(t.phases.connectStart, t.phases.connectDone),
+		TLS:     optionalMillis(t.phases.tlsStart, t.phases.tlsDone),
+		TTFB:    millis(t.start, t.phases.firstByte),
+		Total:   millis(t.start, end),
+	}
+}
+
+// phaseTimes collects httptrace timestamps; dial callbacks may run concurrently
+type phaseTimes struct {
+	mu                        sync.Mutex
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn, wroteRequest     time.Time
+	firstByte                 time.Time
+	serverIP                  string
+}
+
+// mark stores the current time in field
+func (p *phaseTimes) mark(field *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+// clientTrace returns an httptrace.ClientTrace that fills in p
+func (p *phaseTimes) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { p.mark(&p.dnsDone) },
+		ConnectStart:      func(string, string) { p.mark(&p.connectStart) },
+		ConnectDone:       func(string, string, error) { p.mark(&p.connectDone) },
+		TLSHandshakeStart: func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { p.mark(&p.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.mark(&p.gotConn)
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				p.serverIP = addr.String()
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { p.mark(&p.wroteRequest) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+	}
+}
+
+// readBody buffers a body so it can be both recorded and consumed
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// NewRequest builds a HAR request from an http.Request
+func NewRequest(req *http.Request, body []byte) *Request {
+	harReq := &Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     make([]*NameVal, 0),
+		Headers:     HeaderPairs(req.Header),
+		QueryString: make([]*NameVal, 0),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if harReq.HTTPVersion == "" {
+		harReq.HTTPVersion = "HTTP/1.1"
+	}
+
+	for _, cookie := range req.Cookies() {
+		harReq.Cookies = append(harReq.Cookies, &NameVal{Name: cookie.Name, Value: cookie.Value})
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			harReq.QueryString = append(harReq.QueryString, &NameVal{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		harReq.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	return harReq
+}
+
+// NewResponse builds a HAR response from an http.Response
+func NewResponse(resp *http.Response, body []byte, includeBody bool) *Response {
+	harResp := &Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     make([]*NameVal, 0),
+		Headers:     HeaderPairs(resp.Header),
+		Content: &Content{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	for _, cookie := range resp.Cookies() {
+		harResp.Cookies = append(harResp.Cookies, &NameVal{Name: cookie.Name, Value: cookie.Value})
+	}
+	if includeBody {
+		if utf8.Valid(body) {
+			harResp.Content.Text = string(body)
+		} else {
+			harResp.Content.Text = base64.StdEncoding.EncodeToString(body)
+			harResp.Content.Encoding = "base64"
+		}
+	}
+
+	return harResp
+}
+
+// HeaderPairs flattens an http.Header into sorted HAR name/value pairs
+func HeaderPairs(header http.Header) []*NameVal {
+	pairs := make([]*NameVal, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, &NameVal{Name: name, Value: value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// millis returns the duration between two instants in milliseconds
+func millis(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+// optionalMillis is like millis but returns -1 when the phase did not happen
+func optionalMillis(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return -1
+	}
+	return millis(from, to)
+}
+
+// firstNonZero returns the earliest set instant of the given phases
+func firstNonZero(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,110 @@
+package har
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransportRecordsExchanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		includeBodies bool
+		expectedText  string
+	}{
+		{name: "Without bodies", includeBodies: false, expectedText: ""},
+		{name: "With bodies", includeBodies: true, expectedText: `{"ok": true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := NewRecorder(tt.includeBodies)
+			client := &http.Client{Transport: NewTransport(http.DefaultTransport, recorder)}
+
+			resp, err := client.Get(server.URL + "/items?page=2")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			// The caller must still see the full body
+			if string(body) != `{"ok": true}` {
+				t.Errorf("body = %q, want the original response body", body)
+			}
+
+			doc := recorder.HAR()
+			if len(doc.Log.Entries) != 1 {
+				t.Fatalf("expected 1 entry, got %d", len(doc.Log.Entries))
+			}
+
+			entry := doc.Log.Entries[0]
+			if entry.Request.Method != "GET" || len(entry.Request.QueryString) != 1 {
+				t.Errorf("unexpected request: %+v", entry.Request)
+			}
+			if entry.Response.Status != 200 || entry.Response.Content.MimeType != "application/json" {
+				t.Errorf("unexpected response: %+v", entry.Response)
+			}
+			if entry.Response.Content.Text != tt.expectedText {
+				t.Errorf("content text = %q, want %q", entry.Response.Content.Text, tt.expectedText)
+			}
+			if entry.Timings.SSL != -1 || entry.Timings.Wait < 0 {
+				t.Errorf("unexpected timings for plain HTTP: %+v", entry.Timings)
+			}
+		})
+	}
+}
+
+func TestTransportRecordsBinaryBodies(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0xff}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(true)
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, recorder)}
+	resp, err := client.Get(server.URL + "/logo.png")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	content := recorder.HAR().Log.Entries[0].Response.Content
+	if content.Encoding != "base64" || content.Text != base64.StdEncoding.EncodeToString(png) {
+		t.Errorf("content = %+v, want the body base64-encoded", content)
+	}
+	if content.Size != len(png) {
+		t.Errorf("content size = %d, want %d", content.Size, len(png))
+	}
+}
+
+func TestTransportRecordsFailedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	recorder := NewRecorder(false)
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, recorder)}
+	if _, err := client.Get(url); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	doc := recorder.HAR()
+	if len(doc.Log.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(doc.Log.Entries))
+	}
+	entry := doc.Log.Entries[0]
+	if entry.Request.URL != url || entry.Response.Status != 0 || entry.Comment == "" {
+		t.Errorf("entry = %+v, want the request with an empty response and the error", entry)
+	}
+}
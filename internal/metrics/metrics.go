@@ -34,6 +34,12 @@ type Metrics struct {
 
 	// Status code distribution
 	StatusCodeCounts map[int]int64
+
+	// Per-plugin statistics
+	PluginStats map[string]*PluginMetrics
+
+	// Per-schema record validation statistics
+	ValidationStats map[string]*ValidationMetrics
 }
 
 // DomainMetrics tracks statistics for a specific domain
@@ -46,6 +52,24 @@ type DomainMetrics struct {
 	ResponseTimes   []time.Duration
 }
 
+// PluginMetrics tracks the runs of one data processing plugin
+type PluginMetrics struct {
+	Runs         int64         `json:"runs"`
+	Errors       int64         `json:"errors"`
+	Timeouts     int64         `json:"timeouts"` // Runs that exceeded the plugin timeout, also counted as errors
+	TotalLatency time.Duration `json:"total_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+	AvgLatency   time.Duration `json:"avg_latency"`
+}
+
+// ValidationMetrics tracks the records checked against one JSON Schema
+type ValidationMetrics struct {
+	Records  int64   `json:"records"`
+	Passed   int64   `json:"passed"`
+	Failed   int64   `json:"failed"`
+	PassRate float64 `json:"pass_rate"` // Percentage of records that passed
+}
+
 // NewMetrics creates a new metrics tracker
 func NewMetrics() *Metrics {
 	return &Metrics{
@@ -53,6 +77,8 @@ func NewMetrics() *Metrics {
 		DomainStats:      make(map[string]*DomainMetrics),
 		StatusCodeCounts: make(map[int]int64),
 		ResponseTimes:    make([]time.Duration, 0),
+		PluginStats:      make(map[string]*PluginMetrics),
+		ValidationStats:  make(map[string]*ValidationMetrics),
 	}
 }
 
@@ -119,6 +145,48 @@ func (m *Metrics) RecordRetry() {
 	atomic.AddInt64(&m.RetryAttempts, 1)
 }
 
+// RecordPlugin records one plugin run with its latency and outcome
+func (m *Metrics) RecordPlugin(name string, latency time.Duration, failed, timedOut bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pm := m.PluginStats[name]
+	if pm == nil {
+		pm = &PluginMetrics{}
+		m.PluginStats[name] = pm
+	}
+	pm.Runs++
+	if failed {
+		pm.Errors++
+	}
+	if timedOut {
+		pm.Timeouts++
+	}
+	pm.TotalLatency += latency
+	if latency > pm.MaxLatency {
+		pm.MaxLatency = latency
+	}
+	pm.AvgLatency = pm.TotalLatency / time.Duration(pm.Runs)
+}
+
+// RecordValidation records the outcome of validating records against a schema
+func (m *Metrics) RecordValidation(schema string, passed, failed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm := m.ValidationStats[schema]
+	if vm == nil {
+		vm = &ValidationMetrics{}
+		m.ValidationStats[schema] = vm
+	}
+	vm.Records += int64(passed + failed)
+	vm.Passed += int64(passed)
+	vm.Failed += int64(failed)
+	if vm.Records > 0 {
+		vm.PassRate = float64(vm.Passed) / float64(vm.Records) * 100
+	}
+}
+
 // Finish marks the end of scraping and calculates final statistics
 func (m *Metrics) Finish() {
 	m.mu.Lock()
@@ -209,6 +277,21 @@ func (m *Metrics) PrintSummary() {
 				domain, stats.Successes, stats.Requests, successRate, stats.AvgResponseTime)
 		}
 	}
+
+	if len(m.PluginStats) > 0 {
+		fmt.Printf("\n🔌 Per-Plugin Statistics:\n")
+		for name, stats := range m.PluginStats {
+			fmt.Printf("   %s: %d runs, %d errors (%d timeouts) - %v avg, %v max\n",
+				name, stats.Runs, stats.Errors, stats.Timeouts, stats.AvgLatency, stats.MaxLatency)
+		}
+	}
+
+	if len(m.ValidationStats) > 0 {
+		fmt.Printf("\n✔️  Record Validation:\n")
+		for schema, stats := range m.ValidationStats {
+			fmt.Printf("   %s: %d/%d records passed (%.1f%%)\n", schema, stats.Passed, stats.Records, stats.PassRate)
+		}
+	}
 }
 
 // GetMetrics returns a copy of current metrics for JSON serialization
@@ -232,7 +315,28 @@ func (m *Metrics) GetMetrics() map[string]interface{} {
 			"max": m.MaxResponseTime.String(),
 			"avg": m.AvgResponseTime.String(),
 		},
-		"status_codes": m.StatusCodeCounts,
-		"domains":      m.DomainStats,
+		"status_codes": copyMap(m.StatusCodeCounts),
+		"domains":      copyStats(m.DomainStats),
+		"plugins":      copyStats(m.PluginStats),
+		"validation":   copyStats(m.ValidationStats),
+	}
+}
+
+// copyMap returns a shallow copy of a map, so it can be serialized while
+// requests keep updating the original
+func copyMap[K comparable, V any](stats map[K]V) map[K]V {
+	copied := make(map[K]V, len(stats))
+	for key, value := range stats {
+		copied[key] = value
+	}
+	return copied
+}
+
+// copyStats returns a copy of a map of statistics, values included
+func copyStats[V any](stats map[string]*V) map[string]V {
+	copied := make(map[string]V, len(stats))
+	for key, value := range stats {
+		copied[key] = *value
 	}
+	return copied
 }
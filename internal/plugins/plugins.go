@@ -2,11 +2,23 @@ package plugins
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"arachne/internal/changes"
+	"arachne/internal/config"
 	"arachne/internal/errors"
+	"arachne/internal/metrics"
+	"arachne/internal/storage"
 	"arachne/internal/types"
+	"arachne/internal/validation"
+	"arachne/pkg/parser"
 )
 
 // DataProcessor defines the interface for processing scraped data
@@ -15,38 +27,452 @@ type DataProcessor interface {
 	Name() string
 }
 
+// Prioritized is implemented by plugins declaring where they run: lower
+// priorities run first, plugins without one use DefaultPriority
+type Prioritized interface {
+	Priority() int
+}
+
+// Dependent is implemented by plugins that must run after other plugins,
+// given by name
+type Dependent interface {
+	DependsOn() []string
+}
+
+// MetricsReporter is implemented by plugins recording metrics of their own;
+// the manager hands them the metrics given to SetMetrics
+type MetricsReporter interface {
+	SetMetrics(m *metrics.Metrics)
+}
+
+// DefaultPriority is the priority of plugins that do not declare one
+const DefaultPriority = 100
+
+// ErrSkipRecord is wrapped by ProcessData errors when a plugin with the skip
+// policy fails; the caller should drop the record
+var ErrSkipRecord = stderrors.New("record skipped")
+
+// PluginOptions controls how the pipeline runs one plugin
+type PluginOptions struct {
+	ErrorPolicy string        // config.PluginAbort, PluginSkip or PluginContinue
+	Timeout     time.Duration // Deadline of one Process call, 0 for none
+}
+
+// registeredPlugin is a plugin with its options and registration position
+type registeredPlugin struct {
+	processor DataProcessor
+	options   PluginOptions
+	priority  int
+	index     int
+}
+
 // PluginManager manages data processing plugins
 type PluginManager struct {
-	processors []DataProcessor
+	mu         sync.Mutex
+	processors []*registeredPlugin
+	ordered    []*registeredPlugin // Run order, computed on first use
+	orderErr   error
+	defaults   PluginOptions
+	metrics    *metrics.Metrics
+	cfg        *config.Config // Settings of plugins loaded at runtime
+	wasm       *WASMRuntime   // Created with the first WASM plugin
 }
 
-// NewPluginManager creates a new plugin manager
+// NewPluginManager creates a new plugin manager. Plugins registered without
+// options abort on error and have no timeout.
 func NewPluginManager() *PluginManager {
 	return &PluginManager{
-		processors: make([]DataProcessor, 0),
+		processors: make([]*registeredPlugin, 0),
+		defaults:   PluginOptions{ErrorPolicy: config.PluginAbort},
+		cfg:        config.DefaultConfig(),
+	}
+}
+
+// SetMetrics records per-plugin latency and error counters into m
+func (pm *PluginManager) SetMetrics(m *metrics.Metrics) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.metrics = m
+	for _, p := range pm.processors {
+		if reporter, ok := p.processor.(MetricsReporter); ok {
+			reporter.SetMetrics(m)
+		}
 	}
 }
 
-// RegisterPlugin registers a new data processor plugin
+// RegisterPlugin registers a new data processor plugin with the default options
 func (pm *PluginManager) RegisterPlugin(processor DataProcessor) {
-	pm.processors = append(pm.processors, processor)
+	pm.RegisterPluginWithOptions(processor, pm.defaults)
+}
+
+// RegisterPluginWithOptions registers a plugin with its own error policy and timeout
+func (pm *PluginManager) RegisterPluginWithOptions(processor DataProcessor, options PluginOptions) {
+	priority := DefaultPriority
+	if p, ok := processor.(Prioritized); ok {
+		priority = p.Priority()
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if reporter, ok := processor.(MetricsReporter); ok && pm.metrics != nil {
+		reporter.SetMetrics(pm.metrics)
+	}
+	pm.processors = append(pm.processors, &registeredPlugin{
+		processor: processor,
+		options:   options,
+		priority:  priority,
+		index:     len(pm.processors),
+	})
+	pm.ordered = nil
+	pm.orderErr = nil
 }
 
-// ProcessData processes data through all registered plugins
+// ProcessData processes data through all registered plugins in order. A
+// failing plugin stops the pipeline unless its policy is to continue, in
+// which case the error is recorded in data.PluginErrors. Errors of plugins
+// with the skip policy wrap ErrSkipRecord.
 func (pm *PluginManager) ProcessData(ctx context.Context, data *types.ScrapedData) error {
-	for _, processor := range pm.processors {
-		if err := processor.Process(ctx, data); err != nil {
-			return fmt.Errorf("plugin %s failed: %v", processor.Name(), err)
+	ordered, err := pm.order()
+	if err != nil {
+		return err
+	}
+
+	for _, plugin := range ordered {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := pm.run(ctx, plugin, data)
+		if err == nil {
+			continue
+		}
+
+		name := plugin.processor.Name()
+		switch plugin.options.ErrorPolicy {
+		case config.PluginContinue:
+			if data.PluginErrors == nil {
+				data.PluginErrors = make(map[string]string)
+			}
+			data.PluginErrors[name] = err.Error()
+		case config.PluginSkip:
+			return fmt.Errorf("plugin %s failed: %v: %w", name, err, ErrSkipRecord)
+		default:
+			return fmt.Errorf("plugin %s failed: %v", name, err)
 		}
 	}
 	return nil
 }
 
+// run calls one plugin under its timeout and records its metrics. A plugin
+// still running past its deadline fails even if it ignores the context.
+func (pm *PluginManager) run(ctx context.Context, plugin *registeredPlugin, data *types.ScrapedData) error {
+	runCtx := ctx
+	if plugin.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, plugin.options.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := plugin.processor.Process(runCtx, data)
+	latency := time.Since(start)
+
+	timedOut := ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded
+	if timedOut {
+		if err == nil {
+			err = fmt.Errorf("timed out after %v", plugin.options.Timeout)
+		} else {
+			err = fmt.Errorf("timed out after %v: %w", plugin.options.Timeout, err)
+		}
+	}
+
+	pm.mu.Lock()
+	m := pm.metrics
+	pm.mu.Unlock()
+	if m != nil {
+		m.RecordPlugin(plugin.processor.Name(), latency, err != nil, timedOut)
+	}
+	return err
+}
+
+// order returns the plugins sorted so that dependencies run first, then by
+// priority, then by registration order
+func (pm *PluginManager) order() ([]*registeredPlugin, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.ordered != nil || pm.orderErr != nil {
+		return pm.ordered, pm.orderErr
+	}
+
+	byName := make(map[string]*registeredPlugin)
+	for _, plugin := range pm.processors {
+		byName[plugin.processor.Name()] = plugin
+	}
+
+	// Count the unmet dependencies of each plugin
+	pending := make(map[*registeredPlugin]int)
+	dependents := make(map[*registeredPlugin][]*registeredPlugin)
+	for _, plugin := range pm.processors {
+		dependent, ok := plugin.processor.(Dependent)
+		if !ok {
+			continue
+		}
+		for _, name := range dependent.DependsOn() {
+			dependency, ok := byName[name]
+			if !ok {
+				pm.orderErr = fmt.Errorf("plugin %s depends on unregistered plugin %s", plugin.processor.Name(), name)
+				return nil, pm.orderErr
+			}
+			pending[plugin]++
+			dependents[dependency] = append(dependents[dependency], plugin)
+		}
+	}
+
+	var ready []*registeredPlugin
+	for _, plugin := range pm.processors {
+		if pending[plugin] == 0 {
+			ready = append(ready, plugin)
+		}
+	}
+
+	ordered := make([]*registeredPlugin, 0, len(pm.processors))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			if ready[i].priority != ready[j].priority {
+				return ready[i].priority < ready[j].priority
+			}
+			return ready[i].index < ready[j].index
+		})
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, next)
+		for _, plugin := range dependents[next] {
+			if pending[plugin]--; pending[plugin] == 0 {
+				ready = append(ready, plugin)
+			}
+		}
+	}
+
+	if len(ordered) < len(pm.processors) {
+		var cycle []string
+		for _, plugin := range pm.processors {
+			if pending[plugin] > 0 {
+				cycle = append(cycle, plugin.processor.Name())
+			}
+		}
+		pm.orderErr = fmt.Errorf("plugin dependency cycle among: %s", strings.Join(cycle, ", "))
+		return nil, pm.orderErr
+	}
+
+	pm.ordered = ordered
+	return ordered, nil
+}
+
+// PluginNames returns the names of the registered plugins in run order
+func (pm *PluginManager) PluginNames() ([]string, error) {
+	ordered, err := pm.order()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ordered))
+	for i, plugin := range ordered {
+		names[i] = plugin.processor.Name()
+	}
+	return names, nil
+}
+
+// Close releases the plugins holding resources, such as external processes
+func (pm *PluginManager) Close() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	var firstErr error
+	for _, plugin := range pm.processors {
+		if closer, ok := plugin.processor.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("plugin %s: %v", plugin.processor.Name(), err)
+			}
+		}
+	}
+	if pm.wasm != nil {
+		if err := pm.wasm.Close(context.Background()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		pm.wasm = nil
+	}
+	return firstErr
+}
+
+// LoadWASMPlugin compiles a WASM module and registers it under name with
+// the configured options, replacing a WASM plugin of the same name
+func (pm *PluginManager) LoadWASMPlugin(name string, binary []byte) (*WASMPlugin, error) {
+	pm.mu.Lock()
+	for _, plugin := range pm.processors {
+		if _, isWASM := plugin.processor.(*WASMPlugin); plugin.processor.Name() == name && !isWASM {
+			pm.mu.Unlock()
+			return nil, fmt.Errorf("plugin %s already exists and is not a WASM plugin", name)
+		}
+	}
+	if pm.wasm == nil {
+		runtime, err := NewWASMRuntime(context.Background(), pm.cfg)
+		if err != nil {
+			pm.mu.Unlock()
+			return nil, err
+		}
+		pm.wasm = runtime
+	}
+	runtime := pm.wasm
+	pm.mu.Unlock()
+
+	options, err := pm.wasmOptions(name)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := runtime.Load(name, binary)
+	if err != nil {
+		return nil, err
+	}
+	pm.UnloadWASMPlugin(name)
+	pm.RegisterPluginWithOptions(plugin, options)
+	return plugin, nil
+}
+
+// wasmOptions returns the configured options of a WASM plugin, which must
+// have a time limit as nothing else bounds its CPU time
+func (pm *PluginManager) wasmOptions(name string) (PluginOptions, error) {
+	policy, timeout := pm.cfg.PluginSettings(name)
+	if timeout <= 0 {
+		return PluginOptions{}, fmt.Errorf("WASM plugin %s needs a positive timeout, got %v", name, timeout)
+	}
+	return PluginOptions{ErrorPolicy: policy, Timeout: timeout}, nil
+}
+
+// UnloadWASMPlugin removes the named WASM plugin from the pipeline and
+// reports whether it was registered
+func (pm *PluginManager) UnloadWASMPlugin(name string) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for i, plugin := range pm.processors {
+		if _, isWASM := plugin.processor.(*WASMPlugin); isWASM && plugin.processor.Name() == name {
+			pm.processors = append(pm.processors[:i], pm.processors[i+1:]...)
+			pm.ordered = nil
+			pm.orderErr = nil
+			return true
+		}
+	}
+	return false
+}
+
 // GetPluginCount returns the number of registered plugins
 func (pm *PluginManager) GetPluginCount() int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	return len(pm.processors)
 }
 
+// NewPluginManagerFromConfig creates a plugin manager with the built-in
+// plugins, plus the optional ones the configuration switches on
+func NewPluginManagerFromConfig(cfg *config.Config) *PluginManager {
+	pm := NewPluginManager()
+	pm.defaults = PluginOptions{ErrorPolicy: cfg.PluginErrorPolicy, Timeout: cfg.PluginTimeout}
+	pm.cfg = cfg
+	if !cfg.EnablePlugins {
+		return pm
+	}
+
+	register := func(processor DataProcessor) {
+		policy, timeout := cfg.PluginSettings(processor.Name())
+		pm.RegisterPluginWithOptions(processor, PluginOptions{ErrorPolicy: policy, Timeout: timeout})
+	}
+	register(NewTitleCleanerPlugin())
+	register(NewURLValidatorPlugin())
+	register(NewContentTypePlugin())
+	pm.registerJobPlugins(cfg)
+	if cfg.DetectChanges {
+		backend, err := storage.NewBackend(cfg)
+		if err != nil {
+			fmt.Printf("Failed to enable change detection: %v\n", err)
+		} else {
+			register(NewChangeDetectorPlugin(changes.NewHistory(backend)))
+		}
+	}
+	for _, spec := range cfg.ExternalPlugins {
+		register(NewProcessPlugin(spec))
+	}
+	if cfg.WASMPluginDir != "" {
+		if err := pm.loadWASMDir(cfg.WASMPluginDir); err != nil {
+			fmt.Printf("Failed to load WASM plugins: %v\n", err)
+		}
+	}
+	return pm
+}
+
+// ForJob returns the pipeline of one job: the plugins of pm, which keep their
+// state such as external processes, WASM modules and change history, with
+// the readability and validation plugins set up from the job's configuration
+func (pm *PluginManager) ForJob(cfg *config.Config) *PluginManager {
+	job := NewPluginManager()
+	pm.mu.Lock()
+	job.defaults = pm.defaults
+	job.metrics = pm.metrics
+	processors := append([]*registeredPlugin(nil), pm.processors...)
+	pm.mu.Unlock()
+	job.cfg = cfg
+
+	for _, plugin := range processors {
+		switch plugin.processor.(type) {
+		case *ReadabilityPlugin, *ValidationPlugin:
+			continue
+		}
+		job.RegisterPluginWithOptions(plugin.processor, plugin.options)
+	}
+	if cfg.EnablePlugins {
+		job.registerJobPlugins(cfg)
+	}
+	return job
+}
+
+// registerJobPlugins registers the plugins a job can switch on by itself
+func (pm *PluginManager) registerJobPlugins(cfg *config.Config) {
+	register := func(processor DataProcessor) {
+		policy, timeout := cfg.PluginSettings(processor.Name())
+		pm.RegisterPluginWithOptions(processor, PluginOptions{ErrorPolicy: policy, Timeout: timeout})
+	}
+	if cfg.Readability {
+		register(NewReadabilityPlugin(cfg.ReadabilityMarkdown))
+	}
+	if cfg.ValidationSchema != nil {
+		schema, err := validation.Compile(cfg.ValidationSchema)
+		if err != nil {
+			fmt.Printf("Failed to enable record validation: %v\n", err)
+		} else {
+			register(NewValidationPlugin(schema))
+		}
+	}
+}
+
+// loadWASMDir registers the WASM plugins found in dir; a missing directory
+// has no plugins yet
+func (pm *PluginManager) loadWASMDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	runtime, err := NewWASMRuntime(context.Background(), pm.cfg)
+	if err != nil {
+		return err
+	}
+	pm.wasm = runtime
+
+	loaded, err := runtime.LoadDir(dir)
+	for _, plugin := range loaded {
+		options, optionsErr := pm.wasmOptions(plugin.Name())
+		if optionsErr != nil {
+			return optionsErr
+		}
+		pm.RegisterPluginWithOptions(plugin, options)
+	}
+	return err
+}
+
 // Built-in plugins
 
 // TitleCleanerPlugin cleans and normalizes titles
@@ -75,6 +501,11 @@ func (t *TitleCleanerPlugin) Name() string {
 	return "TitleCleaner"
 }
 
+// Priority returns the plugin priority, after URL validation
+func (t *TitleCleanerPlugin) Priority() int {
+	return 30
+}
+
 // URLValidatorPlugin validates URLs in the scraped data
 type URLValidatorPlugin struct{}
 
@@ -98,7 +529,13 @@ func (u *URLValidatorPlugin) Name() string {
 	return "URLValidator"
 }
 
-// ContentTypePlugin adds content type information
+// Priority returns the plugin priority, after content type detection
+func (u *URLValidatorPlugin) Priority() int {
+	return 20
+}
+
+// ContentTypePlugin detects the type of the response from its Content-Type
+// header and its body, flagging responses whose body contradicts the header
 type ContentTypePlugin struct{}
 
 // NewContentTypePlugin creates a new content type plugin
@@ -106,10 +543,9 @@ func NewContentTypePlugin() *ContentTypePlugin {
 	return &ContentTypePlugin{}
 }
 
-// Process adds content type information
+// Process records the detected MIME type, charset and category
 func (c *ContentTypePlugin) Process(ctx context.Context, data *types.ScrapedData) error {
-	// This plugin could be extended to add content type detection
-	// For now, it's a placeholder for future functionality
+	data.ContentInfo = parser.DetectContentType(data.Body, data.ContentType)
 	return nil
 }
 
@@ -117,3 +553,112 @@ func (c *ContentTypePlugin) Process(ctx context.Context, data *types.ScrapedData
 func (c *ContentTypePlugin) Name() string {
 	return "ContentType"
 }
+
+// Priority returns the plugin priority; detection runs first for the plugins relying on it
+func (c *ContentTypePlugin) Priority() int {
+	return 10
+}
+
+// ReadabilityPlugin extracts the main article content of HTML pages
+type ReadabilityPlugin struct {
+	markdown bool
+}
+
+// NewReadabilityPlugin creates a readability plugin, optionally rendering Markdown
+func NewReadabilityPlugin(markdown bool) *ReadabilityPlugin {
+	return &ReadabilityPlugin{markdown: markdown}
+}
+
+// Process extracts the article from the response body
+func (r *ReadabilityPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	// Strategies only attach metadata to HTML pages
+	if data.Body == "" || (data.Metadata == nil && !parser.IsHTML(data.Body, "")) {
+		return nil
+	}
+
+	article, err := parser.ExtractArticle(data.Body, data.URL, r.markdown)
+	if err != nil {
+		return err
+	}
+	data.Article = article
+	return nil
+}
+
+// Name returns the plugin name
+func (r *ReadabilityPlugin) Name() string {
+	return "Readability"
+}
+
+// Priority returns the plugin priority, after the clean-up plugins
+func (r *ReadabilityPlugin) Priority() int {
+	return 50
+}
+
+// ValidationPlugin checks the records of each result against a JSON Schema,
+// marking violations on the result and tracking pass rates per schema
+type ValidationPlugin struct {
+	schema  *validation.Schema
+	metrics *metrics.Metrics
+}
+
+// NewValidationPlugin creates a validation plugin for schema
+func NewValidationPlugin(schema *validation.Schema) *ValidationPlugin {
+	return &ValidationPlugin{schema: schema}
+}
+
+// SetMetrics records pass rates into m
+func (v *ValidationPlugin) SetMetrics(m *metrics.Metrics) {
+	v.metrics = m
+}
+
+// Process validates the extracted records; results without records are left alone
+func (v *ValidationPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	data.Validation = v.schema.Validate(data.Records)
+	if data.Validation != nil && v.metrics != nil {
+		failed := data.Validation.Failed
+		v.metrics.RecordValidation(v.schema.Name(), data.Validation.Records-failed, failed)
+	}
+	return nil
+}
+
+// Name returns the plugin name
+func (v *ValidationPlugin) Name() string {
+	return "Validation"
+}
+
+// Priority returns the plugin priority: after the plugins that may change
+// records, before change detection
+func (v *ValidationPlugin) Priority() int {
+	return 900
+}
+
+// ChangeDetectorPlugin compares each page with its previous scrape and
+// annotates it with the change status and diffs
+type ChangeDetectorPlugin struct {
+	history *changes.History
+}
+
+// NewChangeDetectorPlugin creates a change detector comparing against history
+func NewChangeDetectorPlugin(history *changes.History) *ChangeDetectorPlugin {
+	return &ChangeDetectorPlugin{history: history}
+}
+
+// Process sets data.Change from the latest earlier scrape of the same canonical URL
+func (c *ChangeDetectorPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	return c.history.Observe(ctx, data)
+}
+
+// Close releases the storage backend of the history
+func (c *ChangeDetectorPlugin) Close() error {
+	return c.history.Close()
+}
+
+// Name returns the plugin name
+func (c *ChangeDetectorPlugin) Name() string {
+	return "ChangeDetector"
+}
+
+// Priority returns the plugin priority: last, so every extracted value is compared
+func (c *ChangeDetectorPlugin) Priority() int {
+	return 1000
+}
@@ -0,0 +1,245 @@
+package plugins
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"arachne/internal/changes"
+	"arachne/internal/config"
+	"arachne/internal/metrics"
+	"arachne/internal/storage"
+	"arachne/internal/types"
+)
+
+// testPlugin is a configurable plugin recording its runs in a shared log
+type testPlugin struct {
+	name      string
+	priority  int
+	dependsOn []string
+	err       error
+	delay     time.Duration
+	log       *[]string
+}
+
+func (p *testPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	*p.log = append(*p.log, p.name)
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.err
+}
+
+func (p *testPlugin) Name() string        { return p.name }
+func (p *testPlugin) Priority() int       { return p.priority }
+func (p *testPlugin) DependsOn() []string { return p.dependsOn }
+
+func TestPluginOrder(t *testing.T) {
+	var log []string
+	pm := NewPluginManager()
+	pm.RegisterPlugin(&testPlugin{name: "export", priority: 10, dependsOn: []string{"enrich"}, log: &log})
+	pm.RegisterPlugin(&testPlugin{name: "enrich", priority: 50, log: &log})
+	pm.RegisterPlugin(&testPlugin{name: "clean", priority: 20, log: &log})
+	pm.RegisterPlugin(&testPlugin{name: "validate", priority: 20, log: &log})
+
+	if err := pm.ProcessData(context.Background(), &types.ScrapedData{}); err != nil {
+		t.Fatalf("ProcessData() error = %v", err)
+	}
+	// Priority decides among ready plugins, registration order breaks ties
+	expected := []string{"clean", "validate", "enrich", "export"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("run order = %v, want %v", log, expected)
+	}
+
+	pm.RegisterPlugin(&testPlugin{name: "a", dependsOn: []string{"b"}, log: &log})
+	pm.RegisterPlugin(&testPlugin{name: "b", dependsOn: []string{"a"}, log: &log})
+	if _, err := pm.PluginNames(); err == nil {
+		t.Error("PluginNames() accepted a dependency cycle")
+	}
+
+	missing := NewPluginManager()
+	missing.RegisterPlugin(&testPlugin{name: "a", dependsOn: []string{"absent"}, log: &log})
+	if err := missing.ProcessData(context.Background(), &types.ScrapedData{}); err == nil {
+		t.Error("ProcessData() ran a plugin with an unregistered dependency")
+	}
+}
+
+func TestPluginErrorPolicies(t *testing.T) {
+	failure := fmt.Errorf("boom")
+	tests := []struct {
+		policy   string
+		wantErr  bool
+		wantSkip bool
+		wantRuns int
+	}{
+		{policy: config.PluginAbort, wantErr: true, wantRuns: 1},
+		{policy: config.PluginSkip, wantErr: true, wantSkip: true, wantRuns: 1},
+		{policy: config.PluginContinue, wantRuns: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var log []string
+			pm := NewPluginManager()
+			pm.RegisterPluginWithOptions(&testPlugin{name: "failing", priority: 1, err: failure, log: &log},
+				PluginOptions{ErrorPolicy: tt.policy})
+			pm.RegisterPlugin(&testPlugin{name: "next", priority: 2, log: &log})
+
+			data := &types.ScrapedData{}
+			err := pm.ProcessData(context.Background(), data)
+			if (err != nil) != tt.wantErr || stderrors.Is(err, ErrSkipRecord) != tt.wantSkip {
+				t.Errorf("ProcessData() error = %v", err)
+			}
+			if len(log) != tt.wantRuns {
+				t.Errorf("ran %v, want %d plugins", log, tt.wantRuns)
+			}
+			if tt.policy == config.PluginContinue && data.PluginErrors["failing"] != "boom" {
+				t.Errorf("PluginErrors = %v, want the failure recorded", data.PluginErrors)
+			}
+		})
+	}
+}
+
+func TestPluginTimeoutAndMetrics(t *testing.T) {
+	var log []string
+	m := metrics.NewMetrics()
+	pm := NewPluginManager()
+	pm.SetMetrics(m)
+	pm.RegisterPluginWithOptions(&testPlugin{name: "slow", priority: 1, delay: time.Second, log: &log},
+		PluginOptions{ErrorPolicy: config.PluginContinue, Timeout: 20 * time.Millisecond})
+	pm.RegisterPlugin(&testPlugin{name: "fast", priority: 2, log: &log})
+
+	start := time.Now()
+	data := &types.ScrapedData{}
+	if err := pm.ProcessData(context.Background(), data); err != nil {
+		t.Fatalf("ProcessData() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("ProcessData() took %v, want the slow plugin cut off", elapsed)
+	}
+	if data.PluginErrors["slow"] == "" {
+		t.Error("timeout was not recorded on the data")
+	}
+
+	slow, fast := m.PluginStats["slow"], m.PluginStats["fast"]
+	if slow == nil || slow.Runs != 1 || slow.Errors != 1 || slow.Timeouts != 1 || slow.MaxLatency < 20*time.Millisecond {
+		t.Errorf("slow plugin metrics = %+v", slow)
+	}
+	if fast == nil || fast.Runs != 1 || fast.Errors != 0 {
+		t.Errorf("fast plugin metrics = %+v", fast)
+	}
+}
+
+func TestNewPluginManagerFromConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Readability = true
+	cfg.PluginPolicies = map[string]string{"Readability": config.PluginContinue}
+	pm := NewPluginManagerFromConfig(cfg)
+
+	names, err := pm.PluginNames()
+	if err != nil {
+		t.Fatalf("PluginNames() error = %v", err)
+	}
+	expected := []string{"ContentType", "URLValidator", "TitleCleaner", "Readability"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("PluginNames() = %v, want %v", names, expected)
+	}
+	for _, plugin := range pm.processors {
+		wantPolicy := config.PluginAbort
+		if plugin.processor.Name() == "Readability" {
+			wantPolicy = config.PluginContinue
+		}
+		if plugin.options.ErrorPolicy != wantPolicy || plugin.options.Timeout != cfg.PluginTimeout {
+			t.Errorf("%s options = %+v", plugin.processor.Name(), plugin.options)
+		}
+	}
+}
+
+func TestPluginManagerForJob(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Readability = true
+	pm := NewPluginManagerFromConfig(cfg)
+	var log []string
+	pm.RegisterPlugin(&testPlugin{name: "Custom", priority: 100, log: &log})
+
+	jobCfg := *cfg
+	jobCfg.Readability = false
+	jobCfg.ValidationSchema = []byte(`{"type": "object"}`)
+	names, err := pm.ForJob(&jobCfg).PluginNames()
+	if err != nil {
+		t.Fatalf("PluginNames() error = %v", err)
+	}
+	expected := []string{"ContentType", "URLValidator", "TitleCleaner", "Custom", "Validation"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("job PluginNames() = %v, want %v", names, expected)
+	}
+
+	// The shared pipeline is left as it was
+	if count := pm.GetPluginCount(); count != 5 {
+		t.Errorf("GetPluginCount() = %d, want 5", count)
+	}
+}
+
+func TestContentTypePlugin(t *testing.T) {
+	data := &types.ScrapedData{ContentType: "application/json", Body: "<html><body>Service unavailable</body></html>"}
+	if err := NewContentTypePlugin().Process(context.Background(), data); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if data.ContentInfo == nil || data.ContentInfo.Category != "html" || !data.ContentInfo.Mismatch {
+		t.Errorf("ContentInfo = %+v, want html flagged as a mismatch", data.ContentInfo)
+	}
+}
+
+func TestValidationPlugin(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ValidationSchema = []byte(`{"$id": "https://example.com/price.json", "type": "object", "required": ["price"]}`)
+	pm := NewPluginManagerFromConfig(cfg)
+	m := metrics.NewMetrics()
+	pm.SetMetrics(m)
+
+	data := &types.ScrapedData{
+		URL:     "https://example.com/pricing",
+		Records: []map[string]interface{}{{"price": 10}, {"plan": "Pro"}, {"price": 20}},
+	}
+	if err := pm.ProcessData(context.Background(), data); err != nil {
+		t.Fatalf("ProcessData() error = %v", err)
+	}
+	if data.Validation == nil || data.Validation.Valid || data.Validation.Violations[0].Path != "/price" {
+		t.Errorf("Validation = %+v, want the second record missing /price", data.Validation)
+	}
+
+	stats := m.ValidationStats["https://example.com/price.json"]
+	if stats == nil || stats.Records != 3 || stats.Passed != 2 || stats.Failed != 1 {
+		t.Fatalf("validation metrics = %+v", stats)
+	}
+	if stats.PassRate < 66 || stats.PassRate > 67 {
+		t.Errorf("PassRate = %v, want two thirds", stats.PassRate)
+	}
+}
+
+// closeRecorder is a results backend recording whether it was closed
+type closeRecorder struct {
+	storage.StorageBackend
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestChangeDetectorClose(t *testing.T) {
+	backend := &closeRecorder{StorageBackend: storage.NewMemoryStorage()}
+	pm := NewPluginManager()
+	pm.RegisterPlugin(NewChangeDetectorPlugin(changes.NewHistory(backend)))
+	if err := pm.Close(); err != nil || !backend.closed {
+		t.Errorf("Close() = %v, closed = %v, want the history's backend closed", err, backend.closed)
+	}
+}
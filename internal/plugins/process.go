@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// processRequest is the JSON line sent to an external plugin for each record.
+// The body is sent separately because it is not part of the record's JSON.
+type processRequest struct {
+	Record *types.ScrapedData `json:"record"`
+	Body   string             `json:"body,omitempty"`
+}
+
+// maxResponseLine bounds a response line, so a runaway plugin cannot exhaust
+// memory; a longer line counts as a crash
+const maxResponseLine = 32 << 20
+
+// processResponse is the JSON line an external plugin answers with: the full
+// modified record, or an error. The record's URL and error cannot be changed;
+// plugins fail a record by answering with an error.
+type processResponse struct {
+	Record *types.ScrapedData `json:"record"`
+	Error  string             `json:"error"`
+}
+
+// ProcessPlugin runs an executable as a long-lived subprocess and exchanges
+// one JSON line per record with it. Records are sent one at a time. A
+// process that crashes or overruns its timeout is restarted for the next
+// record, until it crashes more than MaxRestarts times in a row.
+type ProcessPlugin struct {
+	spec    config.ExternalPlugin
+	maxLine int // Longest response line accepted
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	output   *os.File // Read end of the process's stdout
+	stdout   *bufio.Reader
+	exited   chan struct{} // Closed when the running process exits
+	crashes  int           // Consecutive crashes and timeouts
+	disabled error         // Set once the restart budget is exhausted
+}
+
+// NewProcessPlugin creates a plugin for the configured executable; the
+// process starts with the first record
+func NewProcessPlugin(spec config.ExternalPlugin) *ProcessPlugin {
+	return &ProcessPlugin{spec: spec, maxLine: maxResponseLine}
+}
+
+// Process sends the record to the subprocess and replaces it with the
+// returned one, keeping its URL, error and body. The context deadline bounds
+// the whole exchange; on expiry the process is killed.
+func (p *ProcessPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.ensureRunning(); err != nil {
+		return err
+	}
+
+	request, err := json.Marshal(processRequest{Record: data, Body: data.Body})
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %v", err)
+	}
+
+	// Write and read in the background so a stuck process cannot outlive the deadline
+	replies := make(chan []byte, 1)
+	failures := make(chan error, 1)
+	stdin, stdout, maxLine := p.stdin, p.stdout, p.maxLine
+	go func() {
+		if _, err := stdin.Write(append(request, '\n')); err != nil {
+			failures <- err
+			return
+		}
+		line, err := readLine(stdout, maxLine)
+		if err != nil {
+			failures <- err
+			return
+		}
+		replies <- line
+	}()
+
+	var line []byte
+	select {
+	case line = <-replies:
+	case err := <-failures:
+		p.crashed()
+		return fmt.Errorf("process failed: %v", err)
+	case <-ctx.Done():
+		p.crashed()
+		return ctx.Err()
+	}
+
+	var response processResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		p.crashed() // The output stream can no longer be trusted
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	p.crashes = 0
+
+	if response.Error != "" {
+		return fmt.Errorf("%s", response.Error)
+	}
+	if response.Record == nil {
+		return fmt.Errorf("response has neither a record nor an error")
+	}
+
+	url, failure, body := data.URL, data.Error, data.Body
+	*data = *response.Record
+	data.URL, data.Error, data.Body = url, failure, body
+	return nil
+}
+
+// readLine reads a line of at most limit bytes, newline included
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > limit {
+			return nil, fmt.Errorf("response exceeds %d bytes", limit)
+		}
+		line = append(line, chunk...)
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// Name returns the configured plugin name
+func (p *ProcessPlugin) Name() string {
+	return p.spec.Name
+}
+
+// Priority returns the configured priority, DefaultPriority when unset
+func (p *ProcessPlugin) Priority() int {
+	if p.spec.Priority == nil {
+		return DefaultPriority
+	}
+	return *p.spec.Priority
+}
+
+// DependsOn returns the plugins this plugin runs after
+func (p *ProcessPlugin) DependsOn() []string {
+	return p.spec.DependsOn
+}
+
+// Close stops the subprocess, giving it a moment to exit after its stdin closes
+func (p *ProcessPlugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		return nil
+	}
+
+	p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(2 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+	p.output.Close()
+	p.cmd = nil
+	return nil
+}
+
+// ensureRunning starts the subprocess if it is not running, counting
+// restarts against the crash budget
+func (p *ProcessPlugin) ensureRunning() error {
+	if p.disabled != nil {
+		return p.disabled
+	}
+	if p.cmd != nil {
+		select {
+		case <-p.exited:
+			p.crashed() // Exited between records
+		default:
+			return nil
+		}
+	}
+	if p.disabled != nil {
+		return p.disabled
+	}
+
+	cmd := exec.Command(p.spec.Command, p.spec.Args...)
+	cmd.Dir = p.spec.Dir
+	cmd.Env = os.Environ()
+	for key, value := range p.spec.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Stderr = os.Stderr
+
+	// A plain pipe rather than StdoutPipe, which Wait closes even while a
+	// reply is still being read
+	output, stdout, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %v", p.spec.Command, err)
+	}
+	cmd.Stdout = stdout
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	stdout.Close()
+	if err != nil {
+		output.Close()
+		return fmt.Errorf("failed to start %s: %v", p.spec.Command, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.output = output
+	p.stdout = bufio.NewReader(output)
+	p.exited = exited
+	return nil
+}
+
+// crashed kills the current process and disables the plugin once it has
+// failed more than the allowed number of times in a row
+func (p *ProcessPlugin) crashed() {
+	if p.cmd != nil {
+		p.cmd.Process.Kill()
+		<-p.exited
+		p.output.Close()
+		p.cmd = nil
+	}
+	p.crashes++
+	if p.crashes > p.spec.Restarts() {
+		p.disabled = fmt.Errorf("process crashed %d times in a row, not restarting", p.crashes)
+	}
+}
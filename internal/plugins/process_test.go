@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// TestMain lets the test binary act as an external plugin when asked to
+func TestMain(m *testing.M) {
+	if os.Getenv("ARACHNE_TEST_PLUGIN") == "1" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin upper-cases titles and appends the body length; the titles
+// "crash", "hang", "fail", "huge" and "rewrite" trigger the failure modes
+func runTestPlugin() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	encoder := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var request struct {
+			Record map[string]interface{} `json:"record"`
+			Body   string                 `json:"body"`
+		}
+		json.Unmarshal(scanner.Bytes(), &request)
+
+		title, _ := request.Record["title"].(string)
+		switch title {
+		case "crash":
+			os.Exit(1)
+		case "hang":
+			time.Sleep(time.Minute)
+		case "fail":
+			encoder.Encode(map[string]string{"error": "cannot process"})
+			continue
+		case "huge":
+			request.Record["title"] = strings.Repeat("x", 1<<20)
+			encoder.Encode(map[string]interface{}{"record": request.Record})
+			continue
+		case "rewrite":
+			request.Record["url"] = "https://attacker.example"
+			request.Record["error"] = "overwritten"
+		}
+		request.Record["title"] = fmt.Sprintf("%s (%d)", strings.ToUpper(title), len(request.Body))
+		encoder.Encode(map[string]interface{}{"record": request.Record})
+	}
+}
+
+func newTestProcessPlugin(t *testing.T, restarts int) *ProcessPlugin {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin := NewProcessPlugin(config.ExternalPlugin{
+		Name:        "upper",
+		Command:     executable,
+		Env:         map[string]string{"ARACHNE_TEST_PLUGIN": "1"},
+		MaxRestarts: &restarts,
+	})
+	t.Cleanup(func() { plugin.Close() })
+	return plugin
+}
+
+func TestProcessPlugin(t *testing.T) {
+	plugin := newTestProcessPlugin(t, 3)
+
+	data := &types.ScrapedData{URL: "https://example.com", Title: "hello", Status: 200, Body: "<html></html>"}
+	if err := plugin.Process(context.Background(), data); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if data.Title != "HELLO (13)" || data.Status != 200 || data.Body != "<html></html>" {
+		t.Errorf("Process() = %+v, want the returned record with the body kept", data)
+	}
+	pid := plugin.cmd.Process.Pid
+
+	failing := &types.ScrapedData{Title: "fail"}
+	if err := plugin.Process(context.Background(), failing); err == nil || err.Error() != "cannot process" {
+		t.Errorf("Process() error = %v, want the plugin's error", err)
+	}
+	if plugin.cmd.Process.Pid != pid {
+		t.Error("a reported error restarted the process")
+	}
+
+	// A crash fails the record and the next record gets a new process
+	if err := plugin.Process(context.Background(), &types.ScrapedData{Title: "crash"}); err == nil {
+		t.Error("Process() succeeded although the process crashed")
+	}
+	again := &types.ScrapedData{Title: "again"}
+	if err := plugin.Process(context.Background(), again); err != nil || again.Title != "AGAIN (0)" {
+		t.Errorf("Process() after a crash = %q, %v", again.Title, err)
+	}
+
+	// The record keeps its identity whatever the plugin answers
+	rewritten := &types.ScrapedData{URL: "https://example.com", Title: "rewrite"}
+	if err := plugin.Process(context.Background(), rewritten); err != nil || rewritten.URL != "https://example.com" || rewritten.Error != "" {
+		t.Errorf("Process() = %+v, %v, want the URL and error kept", rewritten, err)
+	}
+
+	// An oversized response line is a crash
+	plugin.maxLine = 1024
+	if err := plugin.Process(context.Background(), &types.ScrapedData{Title: "huge"}); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Process() error = %v, want the response rejected", err)
+	}
+	if plugin.cmd != nil {
+		t.Error("the process survived an oversized response")
+	}
+}
+
+func TestProcessPluginTimeoutAndRestartLimit(t *testing.T) {
+	plugin := newTestProcessPlugin(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := plugin.Process(ctx, &types.ScrapedData{Title: "hang"}); err != context.DeadlineExceeded {
+		t.Errorf("Process() error = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Process() took %v after its deadline", elapsed)
+	}
+
+	// The second consecutive failure exhausts the single restart
+	plugin.Process(context.Background(), &types.ScrapedData{Title: "crash"})
+	if err := plugin.Process(context.Background(), &types.ScrapedData{Title: "ok"}); err == nil || !strings.Contains(err.Error(), "not restarting") {
+		t.Errorf("Process() error = %v, want the plugin disabled", err)
+	}
+}
@@ -0,0 +1,402 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// WASM plugins are sandboxed modules run with wazero. A module exports its
+// memory and a "process" function returning 0 on success, and reads and
+// writes the record through the "arachne" host module:
+//
+//	get_field(name_ptr, name_len, buf_ptr, buf_len i32) i32
+//	    Copies the JSON value of a field, such as "title" or "metadata", into
+//	    the buffer and returns its full length, which may exceed buf_len.
+//	    Unset fields read as null; returns -1 for unknown fields.
+//	set_field(name_ptr, name_len, value_ptr, value_len i32) i32
+//	    Replaces a field with a JSON value. Returns 0, -1 for unknown fields
+//	    or -2 when the value does not fit the field.
+//	fail(msg_ptr, msg_len i32)
+//	    Fails the record with the message.
+//
+// Fields use the record's JSON names; the raw response body is "raw_body".
+// Modules may import WASI, which has no filesystem, network or environment.
+//
+// Guest function calls are counted against WASMMaxCalls, but instructions are
+// not metered: the plugin timeout is the only bound on CPU time, so a loop
+// without calls runs until it expires.
+const wasmHostModule = "arachne"
+
+// rawBodyField names the response body, which the record's JSON omits
+const rawBodyField = "raw_body"
+
+// pluginNamePattern restricts plugin names, which are also file names
+var pluginNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidatePluginName ensures name can name an uploaded plugin and its file
+func ValidatePluginName(name string) error {
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid plugin name: %q, must be 1-64 letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// WASMRuntime compiles and runs WASM plugins under shared limits. Compiled
+// modules are cached by the SHA-256 of their binary.
+type WASMRuntime struct {
+	runtime  wazero.Runtime
+	maxCalls int64
+
+	mu      sync.Mutex
+	modules map[string]wazero.CompiledModule
+}
+
+// NewWASMRuntime creates a runtime applying the configured memory limit and
+// compilation cache
+func NewWASMRuntime(ctx context.Context, cfg *config.Config) (*WASMRuntime, error) {
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(cfg.WASMMemoryLimitMB) * 16). // 64 KiB pages
+		WithCloseOnContextDone(true)
+	if cfg.WASMCacheDir != "" {
+		cache, err := wazero.NewCompilationCacheWithDir(cfg.WASMCacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WASM cache: %v", err)
+		}
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %v", err)
+	}
+	_, err := runtime.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().WithFunc(hostGetField).Export("get_field").
+		NewFunctionBuilder().WithFunc(hostSetField).Export("set_field").
+		NewFunctionBuilder().WithFunc(hostFail).Export("fail").
+		Instantiate(ctx)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host module: %v", err)
+	}
+
+	return &WASMRuntime{
+		runtime:  runtime,
+		maxCalls: cfg.WASMMaxCalls,
+		modules:  make(map[string]wazero.CompiledModule),
+	}, nil
+}
+
+// Load compiles a module, or reuses the cached compilation of an identical
+// binary, and returns it as a plugin
+func (r *WASMRuntime) Load(name string, binary []byte) (*WASMPlugin, error) {
+	if err := ValidatePluginName(name); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	hash := hex.EncodeToString(sum[:])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	compiled, ok := r.modules[hash]
+	if !ok {
+		// Guest function calls are counted against the call limit
+		ctx := experimental.WithFunctionListenerFactory(context.Background(), callLimiter{})
+		var err error
+		compiled, err = r.runtime.CompileModule(ctx, binary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %s: %v", name, err)
+		}
+		if _, ok := compiled.ExportedFunctions()["process"]; !ok {
+			compiled.Close(context.Background())
+			return nil, fmt.Errorf("module %s does not export a process function", name)
+		}
+		if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+			compiled.Close(context.Background())
+			return nil, fmt.Errorf("module %s does not export its memory", name)
+		}
+		r.modules[hash] = compiled
+	}
+
+	return &WASMPlugin{name: name, hash: hash, runtime: r, compiled: compiled}, nil
+}
+
+// LoadDir loads every *.wasm file of dir, named after the file
+func (r *WASMRuntime) LoadDir(dir string) ([]*WASMPlugin, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var loaded []*WASMPlugin
+	for _, path := range paths {
+		binary, err := os.ReadFile(path)
+		if err != nil {
+			return loaded, fmt.Errorf("failed to read WASM plugin: %v", err)
+		}
+		plugin, err := r.Load(strings.TrimSuffix(filepath.Base(path), ".wasm"), binary)
+		if err != nil {
+			return loaded, err
+		}
+		loaded = append(loaded, plugin)
+	}
+	return loaded, nil
+}
+
+// Close releases the compiled modules and the runtime
+func (r *WASMRuntime) Close(ctx context.Context) error {
+	return r.runtime.Close(ctx)
+}
+
+// WASMPlugin runs one compiled module, in a fresh instance per record
+type WASMPlugin struct {
+	name     string
+	hash     string
+	runtime  *WASMRuntime
+	compiled wazero.CompiledModule
+}
+
+// Name returns the plugin name
+func (p *WASMPlugin) Name() string {
+	return p.name
+}
+
+// Hash returns the SHA-256 of the module binary
+func (p *WASMPlugin) Hash() string {
+	return p.hash
+}
+
+// Process instantiates the module and calls its process function with the
+// record. The call ends when it fails, reaches the call limit or the context
+// is done; the record is only changed when it succeeds.
+func (p *WASMPlugin) Process(ctx context.Context, data *types.ScrapedData) error {
+	fields, err := newRecordFields(data)
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	call := &wasmCall{fields: fields, calls: p.runtime.maxCalls, cancel: cancel}
+	callCtx = context.WithValue(callCtx, wasmCallKey{}, call)
+
+	// WASI output is discarded and reactor modules are initialized
+	moduleConfig := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+	module, err := p.runtime.runtime.InstantiateModule(callCtx, p.compiled, moduleConfig)
+	if err == nil {
+		defer module.Close(context.Background())
+		var results []uint64
+		results, err = module.ExportedFunction("process").Call(callCtx)
+		if err == nil && call.failure == "" && len(results) == 1 && int32(results[0]) != 0 {
+			err = fmt.Errorf("process returned %d", int32(results[0]))
+		}
+	}
+
+	switch {
+	case call.exhausted:
+		return fmt.Errorf("call limit of %d guest function calls reached", p.runtime.maxCalls)
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case err != nil:
+		return err
+	case call.failure != "":
+		return fmt.Errorf("%s", call.failure)
+	}
+	return fields.apply(data)
+}
+
+// wasmCallKey is the context key of the running wasmCall
+type wasmCallKey struct{}
+
+// wasmCall is the state of one process call, reached by host functions and
+// the call limiter through the call context
+type wasmCall struct {
+	fields    *recordFields
+	failure   string
+	calls     int64 // Guest function calls left
+	exhausted bool
+	cancel    context.CancelFunc
+}
+
+// callLimiter counts guest function calls and stops the call, through its
+// context, once the limit is reached. It does not bound loops without calls.
+type callLimiter struct{}
+
+// NewFunctionListener counts the calls of every guest function
+func (callLimiter) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+	return callLimiter{}
+}
+
+// Before counts the call
+func (callLimiter) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	call, ok := ctx.Value(wasmCallKey{}).(*wasmCall)
+	if !ok || call.exhausted {
+		return
+	}
+	if call.calls--; call.calls < 0 {
+		call.exhausted = true
+		call.cancel()
+	}
+}
+
+// After is a no-op
+func (callLimiter) After(context.Context, api.Module, api.FunctionDefinition, []uint64) {}
+
+// Abort is a no-op
+func (callLimiter) Abort(context.Context, api.Module, api.FunctionDefinition, error) {}
+
+// hostGetField implements get_field
+func hostGetField(ctx context.Context, module api.Module, namePtr, nameLen, bufPtr, bufLen uint32) int32 {
+	call := ctx.Value(wasmCallKey{}).(*wasmCall)
+	name, ok := module.Memory().Read(namePtr, nameLen)
+	if !ok {
+		return -1
+	}
+	value, ok := call.fields.get(string(name))
+	if !ok {
+		return -1
+	}
+	if !module.Memory().Write(bufPtr, value[:min(uint32(len(value)), bufLen)]) {
+		return -1
+	}
+	return int32(len(value))
+}
+
+// hostSetField implements set_field
+func hostSetField(ctx context.Context, module api.Module, namePtr, nameLen, valuePtr, valueLen uint32) int32 {
+	call := ctx.Value(wasmCallKey{}).(*wasmCall)
+	name, ok := module.Memory().Read(namePtr, nameLen)
+	if !ok {
+		return -1
+	}
+	value, ok := module.Memory().Read(valuePtr, valueLen)
+	if !ok {
+		return -2
+	}
+	return call.fields.set(string(name), value)
+}
+
+// hostFail implements fail
+func hostFail(ctx context.Context, module api.Module, msgPtr, msgLen uint32) {
+	call := ctx.Value(wasmCallKey{}).(*wasmCall)
+	call.failure = "plugin failed"
+	if msg, ok := module.Memory().Read(msgPtr, msgLen); ok && len(msg) > 0 {
+		call.failure = string(msg)
+	}
+}
+
+// recordFieldNames lists the JSON names of the record's fields
+var recordFieldNames = func() map[string]bool {
+	names := map[string]bool{rawBodyField: true}
+	recordType := reflect.TypeOf(types.ScrapedData{})
+	for i := 0; i < recordType.NumField(); i++ {
+		name, _, _ := strings.Cut(recordType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// recordFields is the JSON view of a record that WASM plugins read and write
+type recordFields struct {
+	values map[string]json.RawMessage
+	dirty  bool
+}
+
+// newRecordFields encodes a record field by field
+func newRecordFields(data *types.ScrapedData) (*recordFields, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %v", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return nil, fmt.Errorf("failed to encode record: %v", err)
+	}
+	if values[rawBodyField], err = json.Marshal(data.Body); err != nil {
+		return nil, fmt.Errorf("failed to encode record: %v", err)
+	}
+	return &recordFields{values: values}, nil
+}
+
+// get returns the JSON value of a field, null when unset
+func (f *recordFields) get(name string) (json.RawMessage, bool) {
+	if !recordFieldNames[name] {
+		return nil, false
+	}
+	if value, ok := f.values[name]; ok {
+		return value, true
+	}
+	return json.RawMessage("null"), true
+}
+
+// set replaces a field after checking that the value decodes into it
+func (f *recordFields) set(name string, value []byte) int32 {
+	if !recordFieldNames[name] {
+		return -1
+	}
+	if name == rawBodyField {
+		var body string
+		if json.Unmarshal(value, &body) != nil {
+			return -2
+		}
+	} else {
+		trial, err := json.Marshal(map[string]json.RawMessage{name: value})
+		if err != nil || json.Unmarshal(trial, &types.ScrapedData{}) != nil {
+			return -2
+		}
+	}
+	f.values[name] = append(json.RawMessage(nil), value...)
+	f.dirty = true
+	return 0
+}
+
+// apply decodes the fields back onto the record if any were set
+func (f *recordFields) apply(data *types.ScrapedData) error {
+	if !f.dirty {
+		return nil
+	}
+
+	var body string
+	if err := json.Unmarshal(f.values[rawBodyField], &body); err != nil {
+		return fmt.Errorf("invalid raw_body: %v", err)
+	}
+	values := make(map[string]json.RawMessage, len(f.values))
+	for name, value := range f.values {
+		if name != rawBodyField {
+			values[name] = value
+		}
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("invalid record: %v", err)
+	}
+
+	var updated types.ScrapedData
+	if err := json.Unmarshal(encoded, &updated); err != nil {
+		return fmt.Errorf("invalid record: %v", err)
+	}
+	updated.Body = body
+	*data = updated
+	return nil
+}
@@ -0,0 +1,261 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// Minimal WASM binary encoding, enough to build test guests without a toolchain
+
+func uleb(v uint32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func wasmVec(items ...[]byte) []byte {
+	out := uleb(uint32(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func wasmName(s string) []byte {
+	return append(uleb(uint32(len(s))), s...)
+}
+
+func wasmSection(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint32(len(content)))...), content...)
+}
+
+func i32Const(v int32) []byte  { return append([]byte{0x41}, sleb(v)...) }
+func call(index uint32) []byte { return append([]byte{0x10}, uleb(index)...) }
+
+func code(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// Function indexes of the test module: the three host imports, then process and noop
+const (
+	fnGetField = 0
+	fnSetField = 1
+	fnFail     = 2
+	fnNoop     = 4
+)
+
+// testModule builds a guest importing the host ABI, with one i32 local in
+// process and data segments at fixed offsets
+func testModule(memoryPages uint32, process []byte, data map[int32]string) []byte {
+	i32 := byte(0x7f)
+	types := wasmVec(
+		code([]byte{0x60}, wasmVec([]byte{i32}, []byte{i32}, []byte{i32}, []byte{i32}), wasmVec([]byte{i32})),
+		code([]byte{0x60}, wasmVec([]byte{i32}, []byte{i32}), wasmVec()),
+		code([]byte{0x60}, wasmVec(), wasmVec([]byte{i32})),
+		code([]byte{0x60}, wasmVec(), wasmVec()),
+	)
+	imports := wasmVec(
+		code(wasmName("arachne"), wasmName("get_field"), []byte{0x00}, uleb(0)),
+		code(wasmName("arachne"), wasmName("set_field"), []byte{0x00}, uleb(0)),
+		code(wasmName("arachne"), wasmName("fail"), []byte{0x00}, uleb(1)),
+	)
+	functions := wasmVec(uleb(2), uleb(3))
+	memory := wasmVec(code([]byte{0x00}, uleb(memoryPages)))
+	exports := wasmVec(
+		code(wasmName("memory"), []byte{0x02}, uleb(0)),
+		code(wasmName("process"), []byte{0x00}, uleb(3)),
+	)
+	processBody := code(wasmVec(code(uleb(1), []byte{i32})), process, []byte{0x0b})
+	noopBody := code(wasmVec(), []byte{0x0b})
+	bodies := wasmVec(
+		code(uleb(uint32(len(processBody))), processBody),
+		code(uleb(uint32(len(noopBody))), noopBody),
+	)
+	var segments [][]byte
+	for offset, content := range data {
+		segments = append(segments, code([]byte{0x00}, i32Const(offset), []byte{0x0b}, wasmName(content)))
+	}
+
+	return code(
+		[]byte("\x00asm\x01\x00\x00\x00"),
+		wasmSection(1, types),
+		wasmSection(2, imports),
+		wasmSection(3, functions),
+		wasmSection(5, memory),
+		wasmSection(7, exports),
+		wasmSection(10, bodies),
+		wasmSection(11, wasmVec(segments...)),
+	)
+}
+
+var testStrings = map[int32]string{0: "title", 16: "next_url", 32: `"from wasm"`, 48: "status", 80: "nope"}
+
+// transformModule copies the title into next_url and sets a new title
+var transformModule = testModule(1, code(
+	i32Const(0), i32Const(5), i32Const(128), i32Const(64), call(fnGetField), []byte{0x21, 0x00},
+	i32Const(16), i32Const(8), i32Const(128), []byte{0x20, 0x00}, call(fnSetField), []byte{0x1a},
+	i32Const(0), i32Const(5), i32Const(32), i32Const(11), call(fnSetField), []byte{0x1a},
+	i32Const(0),
+), testStrings)
+
+func newTestWASMRuntime(t *testing.T, maxCalls int64) *WASMRuntime {
+	cfg := config.DefaultConfig()
+	cfg.WASMMemoryLimitMB = 1
+	cfg.WASMMaxCalls = maxCalls
+	runtime, err := NewWASMRuntime(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewWASMRuntime() error = %v", err)
+	}
+	t.Cleanup(func() { runtime.Close(context.Background()) })
+	return runtime
+}
+
+func TestWASMPlugin(t *testing.T) {
+	runtime := newTestWASMRuntime(t, 1000)
+	plugin, err := runtime.Load("transform", transformModule)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	data := &types.ScrapedData{URL: "https://example.com", Title: "hello", Status: 200, Body: "<html></html>"}
+	if err := plugin.Process(context.Background(), data); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if data.Title != "from wasm" || data.NextURL != "hello" || data.Status != 200 || data.Body != "<html></html>" {
+		t.Errorf("Process() = %+v", data)
+	}
+
+	// The same binary is compiled once
+	again, err := runtime.Load("copy", transformModule)
+	if err != nil || again.compiled != plugin.compiled || again.Hash() != plugin.Hash() {
+		t.Errorf("Load() of an identical module = %v, want the cached compilation", err)
+	}
+}
+
+func TestWASMPluginFailures(t *testing.T) {
+	runtime := newTestWASMRuntime(t, 1000)
+	tests := []struct {
+		name    string
+		module  []byte
+		timeout time.Duration
+		wantErr string
+	}{
+		{
+			name:    "reported failure",
+			module:  testModule(1, code(i32Const(80), i32Const(4), call(fnFail), i32Const(1)), testStrings),
+			wantErr: "nope",
+		},
+		{
+			name:    "value of the wrong type",
+			module:  testModule(1, code(i32Const(48), i32Const(6), i32Const(32), i32Const(11), call(fnSetField)), testStrings),
+			wantErr: "process returned -2",
+		},
+		{
+			name:    "call limit",
+			module:  testModule(1, code([]byte{0x03, 0x40}, call(fnNoop), []byte{0x0c, 0x00, 0x0b}, i32Const(0)), testStrings),
+			wantErr: "call limit",
+		},
+		{
+			name:    "timeout",
+			module:  testModule(1, code([]byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, i32Const(0)), testStrings),
+			timeout: 50 * time.Millisecond,
+			wantErr: "deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := runtime.Load(strings.ReplaceAll(tt.name, " ", "_"), tt.module)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			data := &types.ScrapedData{Title: "unchanged"}
+			err = plugin.Process(ctx, data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Process() error = %v, want %q", err, tt.wantErr)
+			}
+			if data.Title != "unchanged" {
+				t.Errorf("failed call changed the record: %+v", data)
+			}
+		})
+	}
+
+	// Modules declaring more memory than the limit are rejected
+	if _, err := runtime.Load("greedy", testModule(32, i32Const(0), testStrings)); err == nil {
+		t.Error("Load() accepted a module above the memory limit")
+	}
+	if _, err := runtime.Load("../escape", transformModule); err == nil {
+		t.Error("Load() accepted an invalid plugin name")
+	}
+}
+
+func TestPluginManagerWASM(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "transform.wasm"), transformModule, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.WASMPluginDir = dir
+	pm := NewPluginManagerFromConfig(cfg)
+	defer pm.Close()
+
+	names, _ := pm.PluginNames()
+	if names[len(names)-1] != "transform" {
+		t.Fatalf("PluginNames() = %v, want the directory's plugin", names)
+	}
+
+	if _, err := pm.LoadWASMPlugin("ContentType", transformModule); err == nil {
+		t.Error("LoadWASMPlugin() replaced a built-in plugin")
+	}
+	if _, err := pm.LoadWASMPlugin("transform", transformModule); err != nil {
+		t.Fatalf("LoadWASMPlugin() error = %v", err)
+	}
+	if pm.GetPluginCount() != len(names) {
+		t.Errorf("re-uploading registered %d plugins, want %d", pm.GetPluginCount(), len(names))
+	}
+	if !pm.UnloadWASMPlugin("transform") || pm.UnloadWASMPlugin("transform") {
+		t.Error("UnloadWASMPlugin() did not remove the plugin exactly once")
+	}
+
+	// Nothing but the timeout bounds the CPU time of a WASM plugin
+	cfg.PluginTimeouts["unbounded"] = 0
+	if _, err := pm.LoadWASMPlugin("unbounded", transformModule); err == nil {
+		t.Error("LoadWASMPlugin() accepted a plugin without a timeout")
+	}
+}
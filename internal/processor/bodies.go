@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+// AttachBodies exposes the response bodies of successful results according
+// to mode. Stored bodies are named by their SHA-256 in dir, so identical
+// pages share one file.
+func AttachBodies(results []types.ScrapedData, mode, dir string) error {
+	if mode == "" {
+		return nil
+	}
+	if mode == config.BodyStore {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create body directory: %w", err)
+		}
+	}
+
+	for i := range results {
+		data := &results[i]
+		if data.Error != "" || data.Body == "" {
+			continue
+		}
+		if mode == config.BodyInline {
+			data.InlineBody = data.Body
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(data.Body))
+		path := filepath.Join(dir, hex.EncodeToString(sum[:]))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.WriteFile(path, []byte(data.Body), 0644); err != nil {
+				return fmt.Errorf("failed to store body of %s: %w", data.URL, err)
+			}
+		}
+		data.BodyRef = path
+	}
+	return nil
+}
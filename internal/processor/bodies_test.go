@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+)
+
+func TestAttachBodies(t *testing.T) {
+	newResults := func() []types.ScrapedData {
+		return []types.ScrapedData{
+			{URL: "https://example.com/a", Body: "<html>same</html>"},
+			{URL: "https://example.com/b", Body: "<html>same</html>"},
+			{URL: "https://example.com/c", Body: "partial", Error: "timeout"},
+		}
+	}
+
+	inline := newResults()
+	if err := AttachBodies(inline, config.BodyInline, ""); err != nil {
+		t.Fatalf("AttachBodies(inline) error = %v", err)
+	}
+	if inline[0].InlineBody != inline[0].Body || inline[2].InlineBody != "" {
+		t.Errorf("inline bodies = %q, %q", inline[0].InlineBody, inline[2].InlineBody)
+	}
+
+	dir := filepath.Join(t.TempDir(), "bodies")
+	stored := newResults()
+	if err := AttachBodies(stored, config.BodyStore, dir); err != nil {
+		t.Fatalf("AttachBodies(store) error = %v", err)
+	}
+	if stored[0].BodyRef == "" || stored[0].BodyRef != stored[1].BodyRef || stored[0].InlineBody != "" {
+		t.Fatalf("stored refs = %q, %q, want one shared file", stored[0].BodyRef, stored[1].BodyRef)
+	}
+	if content, err := os.ReadFile(stored[0].BodyRef); err != nil || string(content) != stored[0].Body {
+		t.Errorf("stored body = %q, %v", content, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("body directory has %d files, want 1", len(entries))
+	}
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"arachne/internal/types"
+	"arachne/pkg/parser"
+)
+
+// WriteTableCSV writes a table as CSV, headers first. Empty cells are written
+// as empty fields and numbers without exponent notation.
+func WriteTableCSV(w io.Writer, table parser.Table) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Headers); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.Headers))
+	for _, row := range table.Rows {
+		for i, header := range table.Headers {
+			record[i] = csvField(row[header])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteTableCSVFile writes a table to a CSV file
+func WriteTableCSVFile(filename string, table parser.Table) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	if err := WriteTableCSV(file, table); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return file.Close()
+}
+
+// ExportTablesToCSV writes every extracted table next to the JSON output, as
+// <output>_<result>_table<index>.csv
+func (rp *ResultProcessor) ExportTablesToCSV(results []types.ScrapedData, outputFile string) error {
+	base := strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+	count := 0
+	for i, data := range results {
+		for _, table := range data.Tables {
+			filename := fmt.Sprintf("%s_%d_table%d.csv", base, i, table.Index)
+			if err := WriteTableCSVFile(filename, table); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	if count > 0 {
+		fmt.Printf("✅ %d table(s) saved as %s_*.csv\n", count, base)
+	}
+	return nil
+}
+
+// csvField formats a cell value for CSV output
+func csvField(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,203 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Modes supported by the replay transport
+const (
+	ModeRecord = "record" // Perform real requests and store them as fixtures
+	ModeReplay = "replay" // Serve stored fixtures, never touching the network
+)
+
+// ErrNoFixture is returned in replay mode when no fixture matches a request
+var ErrNoFixture = errors.New("no recorded fixture")
+
+// Interaction is a stored request/response pair
+type Interaction struct {
+	Request    RecordedRequest  `json:"request"`
+	Response   RecordedResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+// RecordedRequest identifies the request a fixture answers
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is the stored response
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for non-UTF-8 bodies
+}
+
+// body returns the decoded response body
+func (r *RecordedResponse) body() ([]byte, error) {
+	if r.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// Transport records or replays HTTP exchanges as JSON fixtures, VCR style
+type Transport struct {
+	Base http.RoundTripper
+	Dir  string
+	Mode string
+}
+
+// NewTransport creates a record/replay transport around base
+func NewTransport(base http.RoundTripper, dir, mode string) *Transport {
+	return &Transport{Base: base, Dir: dir, Mode: mode}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	path := FixturePath(t.Dir, req.Method, req.URL.String(), reqBody)
+
+	switch t.Mode {
+	case ModeReplay:
+		return t.replay(req, path)
+	case ModeRecord:
+		return t.record(req, reqBody, path)
+	default:
+		return t.Base.RoundTrip(req)
+	}
+}
+
+// replay serves a stored fixture
+func (t *Transport) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w for %s %s", ErrNoFixture, req.Method, req.URL)
+		}
+		return nil, fmt.Errorf("failed to read fixture: %v", err)
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+
+	body, err := interaction.Response.body()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode fixture body %s: %v", path, err)
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return resp, nil
+}
+
+// record performs the real request and stores it as a fixture
+func (t *Transport) record(req *http.Request, reqBody []byte, path string) (*http.Response, error) {
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Body:   string(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       string(body),
+		},
+		RecordedAt: time.Now().UTC(),
+	}
+	if !utf8.Valid(body) {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(body)
+		interaction.Response.BodyEncoding = "base64"
+	}
+
+	if err := SaveInteraction(path, &interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SaveInteraction writes an interaction to a fixture file
+func SaveInteraction(path string, interaction *Interaction) error {
+	// Keep markup in bodies readable so fixtures can be reviewed in diffs
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(interaction); err != nil {
+		return fmt.Errorf("failed to marshal fixture: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %v", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// FixturePath returns the fixture file for a request. Fixtures are grouped
+// per host and named by a hash of method, URL and body.
+func FixturePath(dir, method, rawURL string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + rawURL + "\n"))
+	hash.Write(body)
+	sum := hex.EncodeToString(hash.Sum(nil))[:16]
+
+	host := "unknown"
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		host = strings.ReplaceAll(parsed.Host, ":", "_")
+	}
+
+	return filepath.Join(dir, host, strings.ToLower(method)+"_"+sum+".json")
+}
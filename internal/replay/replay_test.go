@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0xff, 0x00})
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Recorded</title></head></html>`))
+		}
+	}))
+
+	dir := t.TempDir()
+	recordClient := &http.Client{Transport: NewTransport(http.DefaultTransport, dir, ModeRecord)}
+	for _, path := range []string{"/page", "/image"} {
+		resp, err := recordClient.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("record request failed: %v", err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	server.Close()
+
+	if requests != 2 {
+		t.Fatalf("expected 2 live requests while recording, got %d", requests)
+	}
+
+	replayClient := &http.Client{Transport: NewTransport(http.DefaultTransport, dir, ModeReplay)}
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedBody string
+		wantErr      bool
+	}{
+		{name: "Text body", path: "/page", expectedBody: `<html><head><title>Recorded</title></head></html>`},
+		{name: "Binary body", path: "/image", expectedBody: string([]byte{0x89, 'P', 'N', 'G', 0xff, 0x00})},
+		{name: "Unmatched request", path: "/other", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := replayClient.Get(server.URL + tt.path)
+			if tt.wantErr {
+				if err == nil || !errors.Is(err, ErrNoFixture) {
+					t.Fatalf("expected ErrNoFixture, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("replay request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.expectedBody {
+				t.Errorf("body = %q, want %q", body, tt.expectedBody)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+
+	if requests != 2 {
+		t.Errorf("replay must not touch the network, got %d live requests", requests)
+	}
+}
+
+func TestFixturePath(t *testing.T) {
+	get := FixturePath("fixtures", "GET", "https://httpbin.org:443/get", nil)
+	post := FixturePath("fixtures", "POST", "https://httpbin.org:443/get", []byte(`{"a":1}`))
+
+	if !strings.HasPrefix(get, "fixtures/httpbin.org_443/get_") {
+		t.Errorf("unexpected fixture path %s", get)
+	}
+	if get == post {
+		t.Errorf("different requests must map to different fixtures")
+	}
+}
@@ -0,0 +1,458 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+	"arachne/internal/webhook"
+)
+
+// postgresMigrationLock is the advisory lock key serializing migrations of
+// instances starting together
+const postgresMigrationLock = 7_305_617_312
+
+// postgresMigrations are applied in order; each one runs once per database,
+// so released migrations must not be edited, only followed by new ones
+var postgresMigrations = []string{
+	// 1: jobs, results, change events and webhook deliveries
+	`CREATE TABLE jobs (
+		id         TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		data       JSONB NOT NULL
+	);
+	CREATE INDEX jobs_status_idx ON jobs (status);
+
+	CREATE TABLE results (
+		id         BIGSERIAL PRIMARY KEY,
+		job_id     TEXT,
+		url        TEXT NOT NULL,
+		domain     TEXT NOT NULL,
+		status     INTEGER NOT NULL,
+		error      TEXT NOT NULL,
+		scraped_at TIMESTAMPTZ NOT NULL,
+		data       JSONB NOT NULL
+	);
+	CREATE INDEX results_url_idx ON results (url);
+	CREATE INDEX results_domain_idx ON results (domain);
+	CREATE INDEX results_status_idx ON results (status);
+	CREATE INDEX results_job_id_idx ON results (job_id);
+	CREATE INDEX results_scraped_at_idx ON results (scraped_at);
+
+	CREATE TABLE change_events (
+		id         BIGSERIAL PRIMARY KEY,
+		job_id     TEXT NOT NULL,
+		url        TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		scraped_at TIMESTAMPTZ NOT NULL,
+		data       JSONB NOT NULL
+	);
+	CREATE INDEX change_events_url_idx ON change_events (url, scraped_at);
+	CREATE INDEX change_events_scraped_at_idx ON change_events (scraped_at);
+
+	CREATE TABLE webhook_deliveries (
+		id     BIGSERIAL PRIMARY KEY,
+		job_id TEXT NOT NULL,
+		data   JSONB NOT NULL
+	);
+	CREATE INDEX webhook_deliveries_job_id_idx ON webhook_deliveries (job_id);`,
+
+	// 2: results keyed by the canonical URL change detection compares them
+	// under; earlier results take it from their recorded change, else their URL
+	`ALTER TABLE results ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+	UPDATE results SET canonical_url = COALESCE(NULLIF(data->'change'->>'canonical_url', ''), url);
+	CREATE INDEX results_canonical_url_idx ON results (canonical_url, scraped_at);`,
+}
+
+// resultColumns are the columns results are copied into
+var resultColumns = []string{"job_id", "url", "canonical_url", "domain", "status", "error", "scraped_at", "data"}
+
+// PostgresStorage keeps results as JSONB and jobs in PostgreSQL. It
+// implements both StorageBackend and the API job store.
+type PostgresStorage struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStorage connects to the database of the postgres_dsn setting
+// with the configured pool limits and applies pending migrations
+func NewPostgresStorage(cfg *config.Config) (*PostgresStorage, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.PostgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres_dsn: %w", err)
+	}
+	poolConfig.MaxConns = cfg.PostgresMaxConns
+	poolConfig.MinConns = cfg.PostgresMinConns
+	if cfg.PostgresMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.PostgresMaxConnLifetime
+	}
+	if cfg.PostgresMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.PostgresMaxConnIdleTime
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PostgreSQL pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	p := &PostgresStorage{pool: pool}
+	if err := p.migrate(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// migrate applies the migrations the database has not seen yet, under an
+// advisory lock so concurrent instances apply each one once
+func (p *PostgresStorage) migrate(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", postgresMigrationLock); err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+		var version int
+		if err := tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		if version > len(postgresMigrations) {
+			return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(postgresMigrations))
+		}
+		for i := version; i < len(postgresMigrations); i++ {
+			if _, err := tx.Exec(ctx, postgresMigrations[i]); err != nil {
+				return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+			}
+			if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", i+1); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Save appends results that belong to no job
+func (p *PostgresStorage) Save(ctx context.Context, data []types.ScrapedData) error {
+	err := pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		return copyResults(ctx, tx, nil, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	return nil
+}
+
+// Load loads every stored result, jobs' included, oldest first
+func (p *PostgresStorage) Load(ctx context.Context) ([]types.ScrapedData, error) {
+	return p.queryResults(ctx, "SELECT data FROM results ORDER BY scraped_at, id")
+}
+
+// LatestResult implements LatestResultStore
+func (p *PostgresStorage) LatestResult(ctx context.Context, canonicalURL string) (*types.ScrapedData, error) {
+	results, err := p.queryResults(ctx, `SELECT data FROM results WHERE canonical_url = $1 AND error = ''
+		ORDER BY scraped_at DESC, id DESC LIMIT 1`, canonicalURL)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// copyResults adds results in one COPY, indexed by URL, canonical URL, domain,
+// status and job
+func copyResults(ctx context.Context, tx pgx.Tx, jobID *string, data []types.ScrapedData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	rows := make([][]interface{}, 0, len(data))
+	for _, result := range data {
+		resultData, err := jsonb(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		rows = append(rows, []interface{}{jobID, result.URL, resultCanonicalURL(result), resultDomain(result.URL), result.Status,
+			strings.ReplaceAll(result.Error, "\x00", ""), result.Scraped, resultData})
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"results"}, resultColumns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// queryResults decodes the results selected by query
+func (p *PostgresStorage) queryResults(ctx context.Context, query string, args ...interface{}) ([]types.ScrapedData, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	results, err := pgx.CollectRows(rows, scanJSON[types.ScrapedData])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+	return results, nil
+}
+
+// SaveJob stores a job, its results in the results table
+func (p *PostgresStorage) SaveJob(ctx context.Context, job *ScrapingJob) error {
+	stored := *job
+	stored.Results = nil
+	jobData, err := jsonb(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `INSERT INTO jobs (id, status, created_at, data) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE SET status = excluded.status, data = excluded.data`,
+			job.ID, job.Status, job.CreatedAt, jobData); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM results WHERE job_id = $1", job.ID); err != nil {
+			return err
+		}
+		return copyResults(ctx, tx, &job.ID, job.Results)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save job to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetJob retrieves a job with its results
+func (p *PostgresStorage) GetJob(ctx context.Context, jobID string) (*ScrapingJob, error) {
+	var job ScrapingJob
+	err := p.pool.QueryRow(ctx, "SELECT data FROM jobs WHERE id = $1", jobID).Scan(&job)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job from PostgreSQL: %w", err)
+	}
+
+	results, err := p.queryResults(ctx, "SELECT data FROM results WHERE job_id = $1 ORDER BY id", jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > 0 {
+		job.Results = results
+	}
+	return &job, nil
+}
+
+// UpdateJob updates an existing job
+func (p *PostgresStorage) UpdateJob(ctx context.Context, job *ScrapingJob) error {
+	return p.SaveJob(ctx, job)
+}
+
+// ListJobs retrieves all job IDs, oldest first
+func (p *PostgresStorage) ListJobs(ctx context.Context) ([]string, error) {
+	rows, err := p.pool.Query(ctx, "SELECT id FROM jobs ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs from PostgreSQL: %w", err)
+	}
+	jobIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job IDs: %w", err)
+	}
+	return jobIDs, nil
+}
+
+// GetJobsByStatus retrieves jobs filtered by status
+func (p *PostgresStorage) GetJobsByStatus(ctx context.Context, status string) ([]*ScrapingJob, error) {
+	rows, err := p.pool.Query(ctx, "SELECT id FROM jobs WHERE status = $1 ORDER BY created_at", status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs from PostgreSQL: %w", err)
+	}
+	jobIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job IDs: %w", err)
+	}
+
+	var jobs []*ScrapingJob
+	for _, jobID := range jobIDs {
+		job, err := p.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// DeleteJob removes a job with its results and delivery log
+func (p *PostgresStorage) DeleteJob(ctx context.Context, jobID string) error {
+	err := pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM jobs WHERE id = $1",
+			"DELETE FROM results WHERE job_id = $1",
+			"DELETE FROM webhook_deliveries WHERE job_id = $1",
+		} {
+			if _, err := tx.Exec(ctx, query, jobID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete job from PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// SaveChanges appends change events, keeping the newest maxChangeEvents
+func (p *PostgresStorage) SaveChanges(ctx context.Context, events []ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([][]interface{}, 0, len(events))
+	for _, event := range events {
+		eventData, err := jsonb(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal change event: %w", err)
+		}
+		rows = append(rows, []interface{}{event.JobID, event.Change.CanonicalURL, event.Change.Status, event.Scraped, eventData})
+	}
+
+	err := pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"change_events"},
+			[]string{"job_id", "url", "status", "scraped_at", "data"}, pgx.CopyFromRows(rows)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM change_events WHERE id IN
+			(SELECT id FROM change_events ORDER BY scraped_at DESC, id DESC OFFSET $1)`, maxChangeEvents)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save change events to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// ListChanges retrieves the change events selected by filter, newest first
+func (p *PostgresStorage) ListChanges(ctx context.Context, filter ChangeFilter) ([]ChangeEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.URL != "" {
+		args = append(args, filter.URL)
+		conditions = append(conditions, fmt.Sprintf("url = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conditions = append(conditions, fmt.Sprintf("scraped_at >= $%d", len(args)))
+	}
+	query := "SELECT data FROM change_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY scraped_at DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change events from PostgreSQL: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, scanJSON[ChangeEvent])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change events: %w", err)
+	}
+	return events, nil
+}
+
+// SaveDelivery appends to a job's delivery log
+func (p *PostgresStorage) SaveDelivery(ctx context.Context, jobID string, delivery webhook.Delivery) error {
+	deliveryData, err := jsonb(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery: %w", err)
+	}
+	if _, err := p.pool.Exec(ctx, "INSERT INTO webhook_deliveries (job_id, data) VALUES ($1, $2)", jobID, deliveryData); err != nil {
+		return fmt.Errorf("failed to save delivery to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves a job's delivery log, oldest first
+func (p *PostgresStorage) ListDeliveries(ctx context.Context, jobID string) ([]webhook.Delivery, error) {
+	rows, err := p.pool.Query(ctx, "SELECT data FROM webhook_deliveries WHERE job_id = $1 ORDER BY id", jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries from PostgreSQL: %w", err)
+	}
+	deliveries, err := pgx.CollectRows(rows, scanJSON[webhook.Delivery])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Close closes the connection pool
+func (p *PostgresStorage) Close() error {
+	p.pool.Close()
+	return nil
+}
+
+// scanJSON decodes a row holding one JSONB column
+func scanJSON[T any](row pgx.CollectableRow) (T, error) {
+	var value T
+	err := row.Scan(&value)
+	return value, err
+}
+
+// jsonb encodes v for a JSONB column, which cannot hold NUL characters
+func jsonb(v interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return stripNUL(data), nil
+}
+
+// stripNUL removes the \u0000 escapes of encoded JSON, leaving escaped
+// backslashes followed by "u0000" alone
+func stripNUL(data []byte) []byte {
+	escape := []byte(`\u0000`)
+	if !bytes.Contains(data, escape) {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			out = append(out, data[i])
+			continue
+		}
+		if bytes.HasPrefix(data[i:], escape) {
+			i += len(escape) - 1
+			continue
+		}
+		// Any other escape is copied whole, so its second byte is not
+		// mistaken for the start of an escape
+		out = append(out, data[i])
+		if i+1 < len(data) {
+			i++
+			out = append(out, data[i])
+		}
+	}
+	return out
+}
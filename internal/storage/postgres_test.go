@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/types"
+	"arachne/internal/webhook"
+)
+
+func TestStripNUL(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"a\x00b", "ab"},
+		{`a\u0000b`, `a\u0000b`}, // An escaped backslash, not a NUL
+		{"\\\x00", "\\"},
+	}
+
+	for _, tt := range tests {
+		encoded, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded string
+		if err := json.Unmarshal(stripNUL(encoded), &decoded); err != nil || decoded != tt.expected {
+			t.Errorf("stripNUL(%s) decodes to %q, %v, want %q", encoded, decoded, err, tt.expected)
+		}
+	}
+}
+
+// TestPostgresStorage runs against the database of SCRAPER_TEST_POSTGRES_DSN,
+// such as the one of docker-compose (make test-postgres)
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("SCRAPER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("SCRAPER_TEST_POSTGRES_DSN not set")
+	}
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.PostgresDSN = dsn
+
+	store, err := NewPostgresStorage(cfg)
+	if err != nil {
+		t.Fatalf("NewPostgresStorage() error = %v", err)
+	}
+	defer store.Close()
+	for _, table := range []string{"jobs", "results", "change_events", "webhook_deliveries"} {
+		if _, err := store.pool.Exec(ctx, "TRUNCATE "+table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Migrations are applied once
+	again, err := NewPostgresStorage(cfg)
+	if err != nil {
+		t.Fatalf("reconnecting: NewPostgresStorage() error = %v", err)
+	}
+	again.Close()
+
+	scraped := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Save(ctx, []types.ScrapedData{{URL: "https://Example.com/a", Title: "A\x00", Status: 200, Scraped: scraped}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	job := &ScrapingJob{ID: "job-1", Status: "running", CreatedAt: scraped}
+	if err := store.SaveJob(ctx, job); err != nil {
+		t.Fatalf("SaveJob() error = %v", err)
+	}
+	job.Status = "completed"
+	job.Results = []types.ScrapedData{{URL: "https://example.org/b", Title: "B", Status: 404, Scraped: scraped.Add(-time.Hour)}}
+	if err := store.UpdateJob(ctx, job); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	stored, err := store.GetJob(ctx, "job-1")
+	if err != nil || stored.Status != "completed" || len(stored.Results) != 1 || stored.Results[0].Title != "B" {
+		t.Fatalf("GetJob() = %+v, %v, want the completed job with its result", stored, err)
+	}
+	if jobs, err := store.GetJobsByStatus(ctx, "completed"); err != nil || len(jobs) != 1 {
+		t.Errorf("GetJobsByStatus() = %d jobs, %v, want 1", len(jobs), err)
+	}
+
+	results, err := store.Load(ctx)
+	if err != nil || len(results) != 2 || results[0].Title != "B" || results[1].Title != "A" {
+		t.Fatalf("Load() = %+v, %v, want both results oldest first", results, err)
+	}
+	var count int
+	if err := store.pool.QueryRow(ctx, "SELECT COUNT(*) FROM results WHERE domain = $1 AND status = $2", "example.org", 404).Scan(&count); err != nil || count != 1 {
+		t.Errorf("results indexed under example.org and 404 = %d, %v, want 1", count, err)
+	}
+
+	if err := store.SaveDelivery(ctx, "job-1", webhook.Delivery{ID: "d-1", Delivered: true}); err != nil {
+		t.Fatalf("SaveDelivery() error = %v", err)
+	}
+	if deliveries, err := store.ListDeliveries(ctx, "job-1"); err != nil || len(deliveries) != 1 {
+		t.Errorf("ListDeliveries() = %+v, %v", deliveries, err)
+	}
+
+	events := []ChangeEvent{
+		{JobID: "job-1", Scraped: scraped, Change: types.Change{Status: types.ChangeNew, CanonicalURL: "https://example.com/"}},
+		{JobID: "job-2", Scraped: scraped.Add(time.Hour), Change: types.Change{Status: types.ChangeChanged, CanonicalURL: "https://example.com/"}},
+	}
+	if err := store.SaveChanges(ctx, events); err != nil {
+		t.Fatalf("SaveChanges() error = %v", err)
+	}
+	listed, err := store.ListChanges(ctx, ChangeFilter{URL: "https://example.com/", Since: scraped.Add(time.Minute), Limit: 10})
+	if err != nil || len(listed) != 1 || listed[0].JobID != "job-2" {
+		t.Errorf("ListChanges() = %+v, %v, want the later change", listed, err)
+	}
+
+	if err := store.DeleteJob(ctx, "job-1"); err != nil {
+		t.Fatalf("DeleteJob() error = %v", err)
+	}
+	if _, err := store.GetJob(ctx, "job-1"); err == nil {
+		t.Error("GetJob() after DeleteJob() succeeded")
+	}
+}
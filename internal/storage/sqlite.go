@@ -0,0 +1,476 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"arachne/internal/types"
+	"arachne/internal/webhook"
+)
+
+// sqliteTime is the layout of stored times: UTC and fixed width, so they sort
+// as text and SQLite's date functions understand them
+const sqliteTime = "2006-01-02 15:04:05.000000000"
+
+// sqliteMigrations are applied in order; each one runs once per database, so
+// released migrations must not be edited, only followed by new ones
+var sqliteMigrations = []string{
+	// 1: jobs, results, change events and webhook deliveries
+	`CREATE TABLE jobs (
+		id         TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX idx_jobs_status ON jobs (status);
+
+	CREATE TABLE results (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id     TEXT,
+		url        TEXT NOT NULL,
+		domain     TEXT NOT NULL,
+		status     INTEGER NOT NULL,
+		error      TEXT NOT NULL,
+		scraped_at TEXT NOT NULL,
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX idx_results_url ON results (url);
+	CREATE INDEX idx_results_job_id ON results (job_id);
+	CREATE INDEX idx_results_domain ON results (domain);
+	CREATE INDEX idx_results_scraped_at ON results (scraped_at);
+
+	CREATE TABLE change_events (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id     TEXT NOT NULL,
+		url        TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		scraped_at TEXT NOT NULL,
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX idx_change_events_url ON change_events (url, scraped_at);
+	CREATE INDEX idx_change_events_scraped_at ON change_events (scraped_at);
+
+	CREATE TABLE webhook_deliveries (
+		id     INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id TEXT NOT NULL,
+		data   TEXT NOT NULL
+	);
+	CREATE INDEX idx_webhook_deliveries_job_id ON webhook_deliveries (job_id);`,
+
+	// 2: results keyed by the canonical URL change detection compares them
+	// under; earlier results take it from their recorded change, else their URL
+	`ALTER TABLE results ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+	UPDATE results SET canonical_url = COALESCE(NULLIF(json_extract(data, '$.change.canonical_url'), ''), url);
+	CREATE INDEX idx_results_canonical_url ON results (canonical_url, scraped_at);`,
+}
+
+// SQLiteStorage keeps results and jobs in a single SQLite database file. It
+// implements both StorageBackend and the API job store.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens, creating if needed, the database at path and
+// applies pending migrations
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection queues them
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStorage{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate applies the migrations the database has not seen yet
+func (s *SQLiteStorage) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var version int
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", i+1, formatTime(time.Now()))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing when it succeeds
+func (s *SQLiteStorage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Save appends results that belong to no job
+func (s *SQLiteStorage) Save(ctx context.Context, data []types.ScrapedData) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return insertResults(ctx, tx, nil, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	return nil
+}
+
+// Load loads every stored result, jobs' included, oldest first
+func (s *SQLiteStorage) Load(ctx context.Context) ([]types.ScrapedData, error) {
+	return s.queryResults(ctx, "SELECT data FROM results ORDER BY scraped_at, id")
+}
+
+// LatestResult implements LatestResultStore
+func (s *SQLiteStorage) LatestResult(ctx context.Context, canonicalURL string) (*types.ScrapedData, error) {
+	results, err := s.queryResults(ctx, `SELECT data FROM results WHERE canonical_url = ? AND error = ''
+		ORDER BY scraped_at DESC, id DESC LIMIT 1`, canonicalURL)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// insertResults adds results, indexed by URL, canonical URL, job, domain and
+// scrape time
+func insertResults(ctx context.Context, tx *sql.Tx, jobID *string, data []types.ScrapedData) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO results (job_id, url, canonical_url, domain, status, error, scraped_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, result := range data {
+		resultData, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, jobID, result.URL, resultCanonicalURL(result), resultDomain(result.URL), result.Status,
+			result.Error, formatTime(result.Scraped), resultData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryResults decodes the results selected by query
+func (s *SQLiteStorage) queryResults(ctx context.Context, query string, args ...interface{}) ([]types.ScrapedData, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []types.ScrapedData{}
+	for rows.Next() {
+		var resultData []byte
+		if err := rows.Scan(&resultData); err != nil {
+			return nil, fmt.Errorf("failed to read result: %w", err)
+		}
+		var result types.ScrapedData
+		if err := json.Unmarshal(resultData, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// SaveJob stores a job, its results in the results table
+func (s *SQLiteStorage) SaveJob(ctx context.Context, job *ScrapingJob) error {
+	stored := *job
+	stored.Results = nil
+	jobData, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO jobs (id, status, created_at, data) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET status = excluded.status, data = excluded.data`,
+			job.ID, job.Status, formatTime(job.CreatedAt), jobData); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM results WHERE job_id = ?", job.ID); err != nil {
+			return err
+		}
+		return insertResults(ctx, tx, &job.ID, job.Results)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save job to SQLite: %w", err)
+	}
+	return nil
+}
+
+// GetJob retrieves a job with its results
+func (s *SQLiteStorage) GetJob(ctx context.Context, jobID string) (*ScrapingJob, error) {
+	var jobData []byte
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM jobs WHERE id = ?", jobID).Scan(&jobData)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job from SQLite: %w", err)
+	}
+
+	var job ScrapingJob
+	if err := json.Unmarshal(jobData, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	results, err := s.queryResults(ctx, "SELECT data FROM results WHERE job_id = ? ORDER BY id", jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > 0 {
+		job.Results = results
+	}
+	return &job, nil
+}
+
+// UpdateJob updates an existing job
+func (s *SQLiteStorage) UpdateJob(ctx context.Context, job *ScrapingJob) error {
+	return s.SaveJob(ctx, job)
+}
+
+// ListJobs retrieves all job IDs, oldest first
+func (s *SQLiteStorage) ListJobs(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM jobs ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs from SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	var jobIDs []string
+	for rows.Next() {
+		var jobID string
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, fmt.Errorf("failed to read job ID: %w", err)
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	return jobIDs, rows.Err()
+}
+
+// GetJobsByStatus retrieves jobs filtered by status
+func (s *SQLiteStorage) GetJobsByStatus(ctx context.Context, status string) ([]*ScrapingJob, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM jobs WHERE status = ? ORDER BY created_at", status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs from SQLite: %w", err)
+	}
+	var jobIDs []string
+	for rows.Next() {
+		var jobID string
+		if err := rows.Scan(&jobID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read job ID: %w", err)
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Jobs are loaded once the listing is closed, as there is one connection
+	var jobs []*ScrapingJob
+	for _, jobID := range jobIDs {
+		job, err := s.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// DeleteJob removes a job with its results and delivery log
+func (s *SQLiteStorage) DeleteJob(ctx context.Context, jobID string) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM jobs WHERE id = ?",
+			"DELETE FROM results WHERE job_id = ?",
+			"DELETE FROM webhook_deliveries WHERE job_id = ?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, jobID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete job from SQLite: %w", err)
+	}
+	return nil
+}
+
+// SaveChanges appends change events, keeping the newest maxChangeEvents
+func (s *SQLiteStorage) SaveChanges(ctx context.Context, events []ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, event := range events {
+			eventData, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal change event: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO change_events (job_id, url, status, scraped_at, data) VALUES (?, ?, ?, ?, ?)",
+				event.JobID, event.Change.CanonicalURL, event.Change.Status, formatTime(event.Scraped), eventData); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM change_events WHERE id NOT IN
+			(SELECT id FROM change_events ORDER BY scraped_at DESC, id DESC LIMIT ?)`, maxChangeEvents)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save change events to SQLite: %w", err)
+	}
+	return nil
+}
+
+// ListChanges retrieves the change events selected by filter, newest first
+func (s *SQLiteStorage) ListChanges(ctx context.Context, filter ChangeFilter) ([]ChangeEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.URL != "" {
+		conditions = append(conditions, "url = ?")
+		args = append(args, filter.URL)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "scraped_at >= ?")
+		args = append(args, formatTime(filter.Since))
+	}
+	query := "SELECT data FROM change_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY scraped_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change events from SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ChangeEvent
+	for rows.Next() {
+		var eventData []byte
+		if err := rows.Scan(&eventData); err != nil {
+			return nil, fmt.Errorf("failed to read change event: %w", err)
+		}
+		var event ChangeEvent
+		if err := json.Unmarshal(eventData, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal change event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// SaveDelivery appends to a job's delivery log
+func (s *SQLiteStorage) SaveDelivery(ctx context.Context, jobID string, delivery webhook.Delivery) error {
+	deliveryData, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "INSERT INTO webhook_deliveries (job_id, data) VALUES (?, ?)", jobID, deliveryData); err != nil {
+		return fmt.Errorf("failed to save delivery to SQLite: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves a job's delivery log, oldest first
+func (s *SQLiteStorage) ListDeliveries(ctx context.Context, jobID string) ([]webhook.Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT data FROM webhook_deliveries WHERE job_id = ? ORDER BY id", jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries from SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []webhook.Delivery{}
+	for rows.Next() {
+		var deliveryData []byte
+		if err := rows.Scan(&deliveryData); err != nil {
+			return nil, fmt.Errorf("failed to read delivery: %w", err)
+		}
+		var delivery webhook.Delivery
+		if err := json.Unmarshal(deliveryData, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// formatTime formats t for storage
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTime)
+}
+
+// resultCanonicalURL returns the key change detection compared a result
+// under, its URL when it was not compared
+func resultCanonicalURL(result types.ScrapedData) string {
+	if result.Change != nil && result.Change.CanonicalURL != "" {
+		return result.Change.CanonicalURL
+	}
+	return result.URL
+}
+
+// resultDomain returns the lower-cased host of a result URL
+func resultDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"arachne/internal/types"
+	"arachne/internal/webhook"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "arachne.db")
+	store, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+
+	scraped := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Save(ctx, []types.ScrapedData{{URL: "https://Example.com/a", Title: "A", Status: 200, Scraped: scraped}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	job := &ScrapingJob{ID: "job-1", Status: "running", Request: ScrapeRequest{URLs: []string{"https://example.org/b"}}, CreatedAt: scraped}
+	if err := store.SaveJob(ctx, job); err != nil {
+		t.Fatalf("SaveJob() error = %v", err)
+	}
+	job.Status = "completed"
+	job.Results = []types.ScrapedData{{URL: "https://example.org/b", Title: "B", Status: 200, Scraped: scraped.Add(-time.Hour)}}
+	if err := store.UpdateJob(ctx, job); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+	if err := store.SaveDelivery(ctx, job.ID, webhook.Delivery{ID: "d-1", Event: webhook.JobCompleted, Delivered: true}); err != nil {
+		t.Fatalf("SaveDelivery() error = %v", err)
+	}
+	store.Close()
+
+	// Everything survives reopening, which must not re-apply migrations
+	store, err = NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("reopening: NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	stored, err := store.GetJob(ctx, "job-1")
+	if err != nil || stored.Status != "completed" || len(stored.Results) != 1 || stored.Results[0].Title != "B" {
+		t.Fatalf("GetJob() = %+v, %v, want the completed job with its result", stored, err)
+	}
+	if _, err := store.GetJob(ctx, "missing"); err == nil {
+		t.Error("GetJob() of a missing job succeeded")
+	}
+	if jobs, err := store.GetJobsByStatus(ctx, "completed"); err != nil || len(jobs) != 1 {
+		t.Errorf("GetJobsByStatus() = %d jobs, %v, want 1", len(jobs), err)
+	}
+
+	results, err := store.Load(ctx)
+	if err != nil || len(results) != 2 || results[0].Title != "B" || results[1].Title != "A" {
+		t.Fatalf("Load() = %+v, %v, want both results oldest first", results, err)
+	}
+
+	var count int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM results WHERE domain = ?", "example.com").Scan(&count); err != nil || count != 1 {
+		t.Errorf("results indexed under example.com = %d, %v, want 1", count, err)
+	}
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil || count != len(sqliteMigrations) {
+		t.Errorf("applied migrations = %d, %v, want %d", count, err, len(sqliteMigrations))
+	}
+
+	if deliveries, err := store.ListDeliveries(ctx, "job-1"); err != nil || len(deliveries) != 1 || deliveries[0].ID != "d-1" {
+		t.Errorf("ListDeliveries() = %+v, %v", deliveries, err)
+	}
+
+	if err := store.DeleteJob(ctx, "job-1"); err != nil {
+		t.Fatalf("DeleteJob() error = %v", err)
+	}
+	if results, _ := store.Load(ctx); len(results) != 1 {
+		t.Errorf("Load() after DeleteJob() = %d results, want the job's removed", len(results))
+	}
+}
+
+func TestSQLiteLatestResult(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "arachne.db")
+
+	// A result stored before results had a canonical URL column
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		"CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)",
+		sqliteMigrations[0],
+		"INSERT INTO schema_migrations (version, applied_at) VALUES (1, '2024-01-01 00:00:00.000000000')",
+		`INSERT INTO results (url, domain, status, error, scraped_at, data) VALUES ('https://example.com/a?utm=x', 'example.com', 200, '',
+			'2024-05-01 10:00:00.000000000', '{"url": "https://example.com/a?utm=x", "title": "Old", "change": {"canonical_url": "https://example.com/a"}}')`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	db.Close()
+
+	store, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	latest, err := store.LatestResult(ctx, "https://example.com/a")
+	if err != nil || latest == nil || latest.Title != "Old" {
+		t.Fatalf("LatestResult() of a migrated result = %+v, %v", latest, err)
+	}
+
+	scraped := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	change := &types.Change{CanonicalURL: "https://example.com/a"}
+	if err := store.Save(ctx, []types.ScrapedData{
+		{URL: "https://example.com/a", Title: "New", Scraped: scraped, Change: change},
+		{URL: "https://example.com/a", Error: "timeout", Scraped: scraped.Add(time.Hour)},
+		{URL: "https://example.com/b", Title: "B", Scraped: scraped.Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if latest, err := store.LatestResult(ctx, "https://example.com/a"); err != nil || latest == nil || latest.Title != "New" {
+		t.Errorf("LatestResult() = %+v, %v, want the latest successful result", latest, err)
+	}
+	if latest, err := store.LatestResult(ctx, "https://example.com/missing"); err != nil || latest != nil {
+		t.Errorf("LatestResult() of an unknown page = %+v, %v, want nil", latest, err)
+	}
+}
+
+func TestSQLiteChanges(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "arachne.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	events := []ChangeEvent{
+		{JobID: "job-1", Scraped: base, Change: types.Change{Status: types.ChangeNew, CanonicalURL: "https://example.com/"}},
+		{JobID: "job-2", Scraped: base.Add(time.Hour), Change: types.Change{Status: types.ChangeChanged, CanonicalURL: "https://example.com/"}},
+		{JobID: "job-2", Scraped: base.Add(time.Hour), Change: types.Change{Status: types.ChangeNew, CanonicalURL: "https://example.com/terms"}},
+	}
+	if err := store.SaveChanges(ctx, events); err != nil {
+		t.Fatalf("SaveChanges() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   ChangeFilter
+		expected int
+	}{
+		{"all", ChangeFilter{}, 3},
+		{"by URL", ChangeFilter{URL: "https://example.com/"}, 2},
+		{"by status", ChangeFilter{Status: types.ChangeNew}, 2},
+		{"since", ChangeFilter{Since: base.Add(time.Minute)}, 2},
+		{"limit", ChangeFilter{Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := store.ListChanges(ctx, tt.filter)
+			if err != nil || len(listed) != tt.expected {
+				t.Fatalf("ListChanges() = %d events, %v, want %d", len(listed), err, tt.expected)
+			}
+			if len(listed) > 1 && listed[0].Scraped.Before(listed[len(listed)-1].Scraped) {
+				t.Errorf("ListChanges() not newest first: %+v", listed)
+			}
+		})
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"arachne/internal/config"
 	"arachne/internal/types"
+	"arachne/internal/webhook"
+	"arachne/pkg/parser"
 )
 
 // StorageBackend defines the interface for different storage backends
@@ -19,6 +24,69 @@ type StorageBackend interface {
 	Close() error
 }
 
+// NewBackend creates the results backend named by the storage_backend setting
+func NewBackend(cfg *config.Config) (StorageBackend, error) {
+	switch cfg.StorageBackend {
+	case "json":
+		return NewJSONStorage(cfg.OutputFile), nil
+	case "memory":
+		return NewMemoryStorage(), nil
+	case "sqlite":
+		return NewSQLiteStorage(cfg.SQLitePath)
+	case "postgres":
+		return NewPostgresStorage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.StorageBackend)
+	}
+}
+
+// LatestResultStore is implemented by result backends that can look up the
+// latest result of a page without loading every result
+type LatestResultStore interface {
+	// LatestResult returns the latest successful result stored under a
+	// canonical URL, or nil when there is none
+	LatestResult(ctx context.Context, canonicalURL string) (*types.ScrapedData, error)
+}
+
+// ChangeStore is implemented by job stores that also keep change events
+type ChangeStore interface {
+	SaveChanges(ctx context.Context, events []ChangeEvent) error
+	ListChanges(ctx context.Context, filter ChangeFilter) ([]ChangeEvent, error)
+}
+
+// DeliveryStore is implemented by job stores that also keep a job's webhook
+// delivery log
+type DeliveryStore interface {
+	SaveDelivery(ctx context.Context, jobID string, delivery webhook.Delivery) error
+	ListDeliveries(ctx context.Context, jobID string) ([]webhook.Delivery, error)
+}
+
+// ChangeEvent records a page that was new or changed when a job scraped it
+type ChangeEvent struct {
+	JobID   string       `json:"job_id"`
+	URL     string       `json:"url"`
+	Scraped time.Time    `json:"scraped"`
+	Change  types.Change `json:"change"`
+}
+
+// ChangeFilter selects change events; zero fields match everything
+type ChangeFilter struct {
+	URL    string    // Canonical URL
+	Status string    // new or changed
+	Since  time.Time // Events scraped at or after this time
+	Limit  int       // Maximum number of events, newest first
+}
+
+// maxChangeEvents bounds the change events kept by a store
+const maxChangeEvents = 10000
+
+// Matches reports whether event is selected by the filter
+func (f ChangeFilter) Matches(event ChangeEvent) bool {
+	return (f.URL == "" || event.Change.CanonicalURL == f.URL) &&
+		(f.Status == "" || event.Change.Status == f.Status) &&
+		!event.Scraped.Before(f.Since)
+}
+
 // JSONStorage implements StorageBackend for JSON file storage
 type JSONStorage struct {
 	filename string
@@ -224,8 +292,8 @@ func (r *RedisStorage) DeleteJob(ctx context.Context, jobID string) error {
 		return fmt.Errorf("failed to remove job from jobs set: %w", err)
 	}
 
-	// Remove the job data
-	err = r.client.Del(ctx, key).Err()
+	// Remove the job data and its delivery log
+	err = r.client.Del(ctx, key, fmt.Sprintf("deliveries:%s", jobID)).Err()
 	if err != nil {
 		return fmt.Errorf("failed to delete job from Redis: %w", err)
 	}
@@ -233,6 +301,90 @@ func (r *RedisStorage) DeleteJob(ctx context.Context, jobID string) error {
 	return nil
 }
 
+// SaveChanges appends change events to a sorted set scored by scrape time,
+// trimmed to the newest maxChangeEvents
+func (r *RedisStorage) SaveChanges(ctx context.Context, events []ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(events))
+	for _, event := range events {
+		eventData, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal change event: %w", err)
+		}
+		members = append(members, redis.Z{Score: float64(event.Scraped.UnixNano()), Member: eventData})
+	}
+	if err := r.client.ZAdd(ctx, "changes", members...).Err(); err != nil {
+		return fmt.Errorf("failed to save change events to Redis: %w", err)
+	}
+	if err := r.client.ZRemRangeByRank(ctx, "changes", 0, -maxChangeEvents-1).Err(); err != nil {
+		return fmt.Errorf("failed to trim change events: %w", err)
+	}
+	return nil
+}
+
+// ListChanges retrieves the change events selected by filter, newest first
+func (r *RedisStorage) ListChanges(ctx context.Context, filter ChangeFilter) ([]ChangeEvent, error) {
+	since := "-inf"
+	if !filter.Since.IsZero() {
+		since = fmt.Sprintf("%d", filter.Since.UnixNano())
+	}
+	members, err := r.client.ZRevRangeByScore(ctx, "changes", &redis.ZRangeBy{Min: since, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change events from Redis: %w", err)
+	}
+
+	var events []ChangeEvent
+	for _, member := range members {
+		var event ChangeEvent
+		if err := json.Unmarshal([]byte(member), &event); err != nil {
+			continue
+		}
+		if !filter.Matches(event) {
+			continue
+		}
+		events = append(events, event)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// SaveDelivery appends to a job's delivery log, which expires with the job
+func (r *RedisStorage) SaveDelivery(ctx context.Context, jobID string, delivery webhook.Delivery) error {
+	deliveryData, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery: %w", err)
+	}
+	key := fmt.Sprintf("deliveries:%s", jobID)
+	if err := r.client.RPush(ctx, key, deliveryData).Err(); err != nil {
+		return fmt.Errorf("failed to save delivery to Redis: %w", err)
+	}
+	if err := r.client.Expire(ctx, key, 24*time.Hour).Err(); err != nil {
+		return fmt.Errorf("failed to set delivery log expiry: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves a job's delivery log, oldest first
+func (r *RedisStorage) ListDeliveries(ctx context.Context, jobID string) ([]webhook.Delivery, error) {
+	members, err := r.client.LRange(ctx, fmt.Sprintf("deliveries:%s", jobID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries from Redis: %w", err)
+	}
+	deliveries := make([]webhook.Delivery, 0, len(members))
+	for _, member := range members {
+		var delivery webhook.Delivery
+		if err := json.Unmarshal([]byte(member), &delivery); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
 // Close closes the Redis connection
 func (r *RedisStorage) Close() error {
 	return r.client.Close()
@@ -240,7 +392,10 @@ func (r *RedisStorage) Close() error {
 
 // InMemoryStorage implements in-memory job storage (fallback)
 type InMemoryStorage struct {
-	jobs map[string]*ScrapingJob
+	jobs       map[string]*ScrapingJob
+	mu         sync.Mutex // Guards changes and deliveries
+	changes    []ChangeEvent
+	deliveries map[string][]webhook.Delivery
 }
 
 // NewInMemoryStorage creates a new in-memory storage instance
@@ -293,9 +448,59 @@ func (m *InMemoryStorage) GetJobsByStatus(ctx context.Context, status string) ([
 // DeleteJob removes a job from memory
 func (m *InMemoryStorage) DeleteJob(ctx context.Context, jobID string) error {
 	delete(m.jobs, jobID)
+	m.mu.Lock()
+	delete(m.deliveries, jobID)
+	m.mu.Unlock()
 	return nil
 }
 
+// SaveChanges appends change events, keeping the newest maxChangeEvents
+func (m *InMemoryStorage) SaveChanges(ctx context.Context, events []ChangeEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changes = append(m.changes, events...)
+	sort.SliceStable(m.changes, func(i, j int) bool { return m.changes[i].Scraped.Before(m.changes[j].Scraped) })
+	if excess := len(m.changes) - maxChangeEvents; excess > 0 {
+		m.changes = append([]ChangeEvent(nil), m.changes[excess:]...)
+	}
+	return nil
+}
+
+// ListChanges retrieves the change events selected by filter, newest first
+func (m *InMemoryStorage) ListChanges(ctx context.Context, filter ChangeFilter) ([]ChangeEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []ChangeEvent
+	for i := len(m.changes) - 1; i >= 0; i-- {
+		if !filter.Matches(m.changes[i]) {
+			continue
+		}
+		events = append(events, m.changes[i])
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// SaveDelivery appends to a job's delivery log
+func (m *InMemoryStorage) SaveDelivery(ctx context.Context, jobID string, delivery webhook.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deliveries == nil {
+		m.deliveries = make(map[string][]webhook.Delivery)
+	}
+	m.deliveries[jobID] = append(m.deliveries[jobID], delivery)
+	return nil
+}
+
+// ListDeliveries retrieves a job's delivery log, oldest first
+func (m *InMemoryStorage) ListDeliveries(ctx context.Context, jobID string) ([]webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]webhook.Delivery(nil), m.deliveries[jobID]...), nil
+}
+
 // Close is a no-op for in-memory storage
 func (m *InMemoryStorage) Close() error {
 	return nil
@@ -303,8 +508,26 @@ func (m *InMemoryStorage) Close() error {
 
 // ScrapeRequest represents a scraping request
 type ScrapeRequest struct {
-	URLs    []string `json:"urls"`
-	SiteURL string   `json:"site_url,omitempty"`
+	URLs             []string          `json:"urls"`
+	SiteURL          string            `json:"site_url,omitempty"`
+	RecordHAR        bool              `json:"record_har,omitempty"`
+	HARIncludeBodies bool              `json:"har_include_bodies,omitempty"`
+	Strategy         string            `json:"strategy,omitempty"`          // "http", "headless" or "feed"
+	URLStrategies    map[string]string `json:"url_strategies,omitempty"`    // URL prefix -> strategy
+	FeedFollowLinks  bool              `json:"feed_follow_links,omitempty"` // Scrape the linked page of every feed entry
+	Schema           *parser.Schema    `json:"schema,omitempty"`            // Extraction schema for structured records
+	ValidationSchema json.RawMessage   `json:"validation_schema,omitempty"` // JSON Schema the records are validated against
+	Readability      bool              `json:"readability,omitempty"`       // Extract the main article content
+	Markdown         bool              `json:"markdown,omitempty"`          // Render the article as Markdown
+	ExtractLinks     bool              `json:"extract_links,omitempty"`     // Return the outgoing links of HTML pages
+	Tables           *parser.TableSpec `json:"tables,omitempty"`            // Extract HTML tables, {} selects all of them
+	Dedup            string            `json:"dedup,omitempty"`             // Near-duplicate handling: "mark" or "drop"
+	DedupThreshold   *int              `json:"dedup_threshold,omitempty"`   // Max SimHash Hamming distance of near-duplicates
+	DownloadAssets   bool              `json:"download_assets,omitempty"`   // Download page assets into the asset store
+	AssetKinds       []string          `json:"asset_kinds,omitempty"`       // Asset kinds to download, all when empty
+	AssetExtensions  []string          `json:"asset_extensions,omitempty"`  // Link extensions downloaded as documents
+	Body             string            `json:"body,omitempty"`              // Include response bodies: "inline" or "store"
+	Webhooks         []webhook.Webhook `json:"webhooks,omitempty"`          // URLs notified of the job's events
 }
 
 // ScrapingJob represents an asynchronous scraping job
@@ -317,5 +540,6 @@ type ScrapingJob struct {
 	CreatedAt   time.Time           `json:"created_at"`
 	StartedAt   *time.Time          `json:"started_at,omitempty"`
 	CompletedAt *time.Time          `json:"completed_at,omitempty"`
-	Progress    int                 `json:"progress"` // 0-100
+	Progress    int                 `json:"progress"`            // 0-100
+	Artifacts   []string            `json:"artifacts,omitempty"` // Downloadable files produced by the job
 }
@@ -0,0 +1,133 @@
+package strategy
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/errors"
+	"arachne/internal/types"
+)
+
+// Crawler scrapes URLs with the strategy configured for each of them,
+// retrying retryable failures. It applies every per-job option of the
+// configuration, so it runs jobs for scrapers that cannot.
+type Crawler struct {
+	selector *Selector
+}
+
+// NewCrawler creates a crawler sharing its strategies across calls
+func NewCrawler(cfg *config.Config) *Crawler {
+	return &Crawler{selector: NewSelector(cfg)}
+}
+
+// ScrapeURLsWithConfig scrapes urls concurrently, results in input order.
+// With FeedFollowLinks the entry links of the feeds among them are scraped
+// afterwards; links found on followed pages are not followed again.
+func (c *Crawler) ScrapeURLsWithConfig(urls []string, cfg *config.Config) []types.ScrapedData {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TotalTimeout)
+	defer cancel()
+
+	seen := make(map[string]bool, len(urls))
+	results, follow := c.scrapeAll(ctx, unseen(urls, seen), cfg)
+	if cfg.FeedFollowLinks {
+		followed, _ := c.scrapeAll(ctx, unseen(follow, seen), cfg)
+		results = append(results, followed...)
+	}
+	return results
+}
+
+// ScrapeSiteWithConfig scrapes siteURL and the pages its NextURL links lead
+// to, up to MaxPages
+func (c *Crawler) ScrapeSiteWithConfig(siteURL string, cfg *config.Config) []types.ScrapedData {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TotalTimeout)
+	defer cancel()
+
+	var results []types.ScrapedData
+	seen := make(map[string]bool)
+	for next := siteURL; next != "" && !seen[next] && len(results) < cfg.MaxPages; {
+		seen[next] = true
+		data, _ := c.scrape(ctx, next, cfg)
+		results = append(results, data)
+		next = data.NextURL
+	}
+	return results
+}
+
+// scrapeAll scrapes urls with at most MaxConcurrent requests in flight,
+// returning the results and the feed entry links to follow
+func (c *Crawler) scrapeAll(ctx context.Context, urls []string, cfg *config.Config) ([]types.ScrapedData, []string) {
+	results := make([]types.ScrapedData, len(urls))
+	follow := make([][]string, len(urls))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(cfg.MaxConcurrent, 1))
+	for i, urlStr := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, urlStr string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], follow[i] = c.scrape(ctx, urlStr, cfg)
+		}(i, urlStr)
+	}
+	wg.Wait()
+
+	var links []string
+	for _, urls := range follow {
+		links = append(links, urls...)
+	}
+	return results, links
+}
+
+// scrape fetches one URL with its configured strategy, retrying retryable
+// errors up to RetryAttempts times. Attempts counts every request made.
+func (c *Crawler) scrape(ctx context.Context, urlStr string, cfg *config.Config) (types.ScrapedData, []string) {
+	data := types.ScrapedData{URL: urlStr, Scraped: time.Now()}
+	strategy := c.selector.ForURL(urlStr, cfg)
+
+	for {
+		data.Attempts++
+		result, err := strategy.Execute(ctx, urlStr, cfg)
+		if err == nil {
+			result.Fill(&data)
+			return data, result.FollowURLs
+		}
+		if data.Attempts > cfg.RetryAttempts || !retryable(err) || !sleep(ctx, cfg.RetryDelay) {
+			RecordError(&data, err)
+			return data, nil
+		}
+	}
+}
+
+// retryable reports whether a failed request is worth another attempt
+func retryable(err error) bool {
+	var scraperErr *errors.ScraperError
+	return stderrors.As(err, &scraperErr) && scraperErr.IsRetryable()
+}
+
+// sleep waits for d, reporting false when ctx ends first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// unseen returns the URLs not seen yet, marking them as seen
+func unseen(urls []string, seen map[string]bool) []string {
+	var fresh []string
+	for _, urlStr := range urls {
+		if !seen[urlStr] {
+			seen[urlStr] = true
+			fresh = append(fresh, urlStr)
+		}
+	}
+	return fresh
+}
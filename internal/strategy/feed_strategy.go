@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"arachne/internal/config"
+	"arachne/internal/errors"
+	"arachne/internal/har"
+	"arachne/pkg/parser"
+)
+
+// FeedStrategy implements scraping of RSS 2.0, Atom 1.0 and JSON Feed documents
+type FeedStrategy struct {
+	http *HTTPStrategy
+}
+
+// NewFeedStrategy creates a new feed strategy with the given configuration
+func NewFeedStrategy(cfg *config.Config) *FeedStrategy {
+	return &FeedStrategy{http: NewHTTPStrategy(cfg)}
+}
+
+// Execute fetches the URL and parses it as a feed, one item per entry
+func (s *FeedStrategy) Execute(ctx context.Context, urlStr string, cfg *config.Config) (*ScrapedResult, error) {
+	resp, body, timings, err := s.http.fetch(ctx, urlStr, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := parser.ParseFeed(string(body), urlStr)
+	if err != nil {
+		return nil, errors.NewScraperError(urlStr, "Failed to parse feed", err)
+	}
+
+	return newFeedResult(feed, resp, body, timings, cfg), nil
+}
+
+// newFeedResult builds the result for a parsed feed; entry links are queued
+// for a full scrape when FeedFollowLinks is enabled
+func newFeedResult(feed *parser.Feed, resp *http.Response, body []byte, timings *har.PhaseTimings, cfg *config.Config) *ScrapedResult {
+	title := feed.Title
+	if title == "" {
+		title = fmt.Sprintf("%s feed", feed.Format)
+	}
+
+	result := &ScrapedResult{
+		Title:      title,
+		Body:       string(body),
+		StatusCode: resp.StatusCode,
+		FeedItems:  feed.Items,
+		Strategy:   "feed",
+	}
+	describeResponse(result, resp, timings)
+	if cfg.FeedFollowLinks {
+		result.FollowURLs = feed.Links()
+	}
+	return result
+}
@@ -0,0 +1,229 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"arachne/internal/har"
+)
+
+// harListener converts CDP network events into HAR entries, including
+// every sub-resource the page loads
+type harListener struct {
+	recorder *har.Recorder
+	mu       sync.Mutex
+	pending  map[network.RequestID]*pendingExchange
+	wg       sync.WaitGroup
+}
+
+// pendingExchange tracks a request until it finishes loading
+type pendingExchange struct {
+	started  time.Time          // Wall-clock start, used for startedDateTime
+	sentAt   *cdp.MonotonicTime // Monotonic start, used for total time
+	request  *network.Request
+	response *network.Response
+}
+
+// newHARListener creates a listener that records into recorder
+func newHARListener(recorder *har.Recorder) *harListener {
+	return &harListener{
+		recorder: recorder,
+		pending:  make(map[network.RequestID]*pendingExchange),
+	}
+}
+
+// action returns the chromedp action that starts listening for network events
+func (l *harListener) action() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			l.handle(ctx, ev)
+		})
+		return network.Enable().Do(ctx)
+	})
+}
+
+// handle processes a single CDP event
+func (l *harListener) handle(ctx context.Context, ev interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID, so the previous hop ends here
+		if previous, exists := l.pending[ev.RequestID]; exists && ev.RedirectResponse != nil {
+			previous.response = ev.RedirectResponse
+			l.recorder.Add(previous.entry(ev.Timestamp, nil, ""))
+		}
+		exchange := &pendingExchange{request: ev.Request, sentAt: ev.Timestamp, started: time.Now()}
+		if ev.WallTime != nil {
+			exchange.started = ev.WallTime.Time()
+		}
+		l.pending[ev.RequestID] = exchange
+
+	case *network.EventResponseReceived:
+		if exchange, exists := l.pending[ev.RequestID]; exists {
+			exchange.response = ev.Response
+		}
+
+	case *network.EventLoadingFinished:
+		exchange, exists := l.pending[ev.RequestID]
+		if !exists {
+			return
+		}
+		delete(l.pending, ev.RequestID)
+
+		if !l.recorder.IncludeBodies() {
+			l.recorder.Add(exchange.entry(ev.Timestamp, nil, ""))
+			return
+		}
+
+		// CDP commands cannot be issued from inside the listener
+		l.wg.Add(1)
+		go func(requestID network.RequestID, finished *cdp.MonotonicTime) {
+			defer l.wg.Done()
+			execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+			body, _ := network.GetResponseBody(requestID).Do(execCtx)
+			l.recorder.Add(exchange.entry(finished, body, ""))
+		}(ev.RequestID, ev.Timestamp)
+
+	case *network.EventLoadingFailed:
+		if exchange, exists := l.pending[ev.RequestID]; exists {
+			delete(l.pending, ev.RequestID)
+			l.recorder.Add(exchange.entry(ev.Timestamp, nil, ev.ErrorText))
+		}
+	}
+}
+
+// wait blocks until pending body fetches are done, then records exchanges
+// that received a response but never reported completion
+func (l *harListener) wait() {
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for requestID, exchange := range l.pending {
+		if exchange.response != nil {
+			l.recorder.Add(exchange.entry(nil, nil, "incomplete"))
+		}
+		delete(l.pending, requestID)
+	}
+}
+
+// entry builds the HAR entry for a finished exchange
+func (e *pendingExchange) entry(finished *cdp.MonotonicTime, body []byte, comment string) *har.Entry {
+	entry := &har.Entry{
+		StartedDateTime: e.started,
+		Request:         e.harRequest(),
+		Response:        e.harResponse(body),
+		Timings:         &har.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+		Comment:         comment,
+	}
+
+	if finished != nil && e.sentAt != nil {
+		entry.Time = float64(finished.Time().Sub(e.sentAt.Time()).Microseconds()) / 1000
+	}
+
+	if e.response != nil {
+		entry.ServerIPAddress = e.response.RemoteIPAddress
+		if timing := e.response.Timing; timing != nil {
+			entry.Timings = cdpTimings(timing, finished)
+		}
+	}
+
+	return entry
+}
+
+// harRequest converts the CDP request into a HAR request
+func (e *pendingExchange) harRequest() *har.Request {
+	req := &http.Request{Method: e.request.Method, Header: cdpHeaders(e.request.Headers), Proto: "HTTP/1.1"}
+	if parsed, err := url.Parse(e.request.URL + e.request.URLFragment); err == nil {
+		req.URL = parsed
+	} else {
+		req.URL = &url.URL{Opaque: e.request.URL}
+	}
+	if e.response != nil && e.response.Protocol != "" {
+		req.Proto = e.response.Protocol
+	}
+	return har.NewRequest(req, nil)
+}
+
+// harResponse converts the CDP response into a HAR response
+func (e *pendingExchange) harResponse(body []byte) *har.Response {
+	if e.response == nil {
+		return &har.Response{
+			Cookies: make([]*har.NameVal, 0),
+			Headers: make([]*har.NameVal, 0),
+			Content: &har.Content{},
+		}
+	}
+
+	resp := &http.Response{
+		StatusCode: int(e.response.Status),
+		Proto:      e.response.Protocol,
+		Header:     cdpHeaders(e.response.Headers),
+	}
+	harResp := har.NewResponse(resp, body, body != nil)
+	if e.response.StatusText != "" {
+		harResp.StatusText = e.response.StatusText
+	}
+	harResp.Content.MimeType = e.response.MimeType
+	if body == nil {
+		harResp.BodySize = int(e.response.EncodedDataLength)
+		harResp.Content.Size = int(e.response.EncodedDataLength)
+	}
+	return harResp
+}
+
+// cdpTimings converts Chrome's resource timing (milliseconds relative to
+// RequestTime) into HAR phase durations
+func cdpTimings(t *network.ResourceTiming, finished *cdp.MonotonicTime) *har.Timings {
+	span := func(start, end float64) float64 {
+		if start < 0 || end < 0 {
+			return -1
+		}
+		return end - start
+	}
+
+	blocked := -1.0
+	for _, first := range []float64{t.DNSStart, t.ConnectStart, t.SendStart} {
+		if first >= 0 {
+			blocked = first
+			break
+		}
+	}
+
+	timings := &har.Timings{
+		Blocked: blocked,
+		DNS:     span(t.DNSStart, t.DNSEnd),
+		Connect: span(t.ConnectStart, t.ConnectEnd),
+		SSL:     span(t.SslStart, t.SslEnd),
+		Send:    span(t.SendStart, t.SendEnd),
+		Wait:    span(t.SendEnd, t.ReceiveHeadersEnd),
+	}
+
+	if finished != nil && cdp.MonotonicTimeEpoch != nil {
+		headersDone := cdp.MonotonicTimeEpoch.Add(time.Duration((t.RequestTime*1000 + t.ReceiveHeadersEnd) * float64(time.Millisecond)))
+		if receive := finished.Time().Sub(headersDone); receive > 0 {
+			timings.Receive = float64(receive.Microseconds()) / 1000
+		}
+	}
+
+	return timings
+}
+
+// cdpHeaders converts CDP headers into an http.Header
+func cdpHeaders(headers network.Headers) http.Header {
+	header := make(http.Header, len(headers))
+	for name, value := range headers {
+		header.Add(name, fmt.Sprint(value))
+	}
+	return header
+}
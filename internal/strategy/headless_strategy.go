@@ -23,13 +23,30 @@ func NewHeadlessStrategy() *HeadlessStrategy {
 	return &HeadlessStrategy{}
 }
 
+// interceptsRequests reports whether the browser's traffic to host must go
+// through the Go transport chain: TLS profiles, whose CAs, client
+// certificates and minimum version cannot be handed to Chrome, auth profiles,
+// and replayed fixtures, which must never hit the network
+func interceptsRequests(cfg *config.Config, host string) bool {
+	_, tlsProfile := cfg.TLSProfileFor(host)
+	_, authProfile := cfg.AuthProfileFor(host)
+	return (tlsProfile != nil && tlsProfile.HasSettings()) || authProfile != nil || cfg.ReplayMode != ""
+}
+
 // Execute performs headless browser-based scraping
 func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *config.Config) (*ScrapedResult, error) {
 	// Create a new chromedp context from the parent context with timeout
 	taskCtx, cancel := context.WithTimeout(ctx, cfg.RequestTimeout)
 	defer cancel()
 
-	// Create chromedp context with options that ignore SSL errors
+	// Certificate errors are only ignored when the domain's TLS profile asks for it
+	var host string
+	if parsedURL, err := url.Parse(urlStr); err == nil {
+		host = parsedURL.Hostname()
+	}
+	_, tlsProfile := cfg.TLSProfileFor(host)
+	insecure := tlsProfile != nil && tlsProfile.InsecureSkipVerify
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		// Create a temporary, disposable user profile for this scrape
 		chromedp.Flag("user-data-dir", os.TempDir()+"/go-scraper-profile"),
@@ -40,9 +57,9 @@ func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *conf
 		chromedp.Flag("disable-extensions", true),
 		chromedp.Flag("no-first-run", true),
 		chromedp.Flag("no-default-browser-check", true),
-		// SSL/security flags for test sites
-		chromedp.Flag("ignore-certificate-errors", true),
-		chromedp.Flag("ignore-ssl-errors", true),
+		// SSL/security flags, off unless explicitly configured
+		chromedp.Flag("ignore-certificate-errors", insecure),
+		chromedp.Flag("ignore-ssl-errors", insecure),
 	)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(taskCtx, opts...)
@@ -54,9 +71,22 @@ func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *conf
 	var title string
 	var body string
 	var nextURL string
+	var finalURL string
+
+	var actions []chromedp.Action
+	if interceptsRequests(cfg, host) {
+		actions = append(actions, interceptRequests(newTransport(cfg)))
+	}
+
+	// Record every request the page makes, including sub-resources
+	var harRecorder *harListener
+	if cfg.HARRecorder != nil {
+		harRecorder = newHARListener(cfg.HARRecorder)
+		actions = append(actions, harRecorder.action())
+	}
 
 	// Define the sequence of actions the browser will perform
-	err := chromedp.Run(chromeCtx,
+	actions = append(actions,
 		// Navigate to the URL
 		chromedp.Navigate(urlStr),
 
@@ -66,17 +96,28 @@ func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *conf
 		// Wait a bit for JavaScript to execute
 		chromedp.Sleep(3*time.Second),
 
-		// Extract the page title
+		// Extract the page title and the URL after redirects
 		chromedp.Title(&title),
+		chromedp.Location(&finalURL),
 
 		// Extract the full HTML body
 		chromedp.OuterHTML("html", &body),
 	)
 
+	err := chromedp.Run(chromeCtx, actions...)
+	if harRecorder != nil {
+		harRecorder.wait()
+	}
+
 	if err != nil {
 		return nil, errors.NewScraperError(urlStr, "Headless execution failed", err)
 	}
 
+	// Resolve URLs against the final URL, after redirects
+	if finalURL == "" {
+		finalURL = urlStr
+	}
+
 	// Use goquery to parse the HTML and extract content robustly
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
 	if err != nil {
@@ -92,7 +133,7 @@ func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *conf
 
 	// If we found a next URL, make it absolute
 	if nextURL != "" {
-		baseURL, _ := url.Parse(urlStr)
+		baseURL, _ := url.Parse(finalURL)
 		if nextURLRef, err := url.Parse(nextURL); err == nil {
 			nextURL = baseURL.ResolveReference(nextURLRef).String()
 		}
@@ -103,12 +144,21 @@ func (s *HeadlessStrategy) Execute(ctx context.Context, urlStr string, cfg *conf
 		title = s.extractTitleFromContent(doc)
 	}
 
-	return &ScrapedResult{
-		Title:      title,
-		Body:       body,
-		StatusCode: 200, // Chromedp doesn't easily expose status, 200 is safe on success
-		NextURL:    nextURL,
-	}, nil
+	result := &ScrapedResult{
+		Title:       title,
+		Body:        body,
+		StatusCode:  200, // Chromedp doesn't easily expose status, 200 is safe on success
+		NextURL:     nextURL,
+		Method:      "GET",
+		FinalURL:    finalURL,
+		ContentType: "text/html", // The serialized DOM, whatever the original content type
+		Strategy:    "headless",
+	}
+	if err := extractDocument(result, doc, finalURL, cfg); err != nil {
+		return nil, errors.NewScraperError(urlStr, "Schema extraction failed", err)
+	}
+
+	return result, nil
 }
 
 // extractTitleFromContent extracts a meaningful title from the HTML content using goquery
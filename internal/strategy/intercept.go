@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// interceptRequests pauses every request the browser makes and performs it
+// through rt instead, using the CDP Fetch domain. This lets Go-side transport
+// settings (TLS profiles and friends) apply to headless scrapes as well.
+func interceptRequests(rt http.RoundTripper) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			paused, ok := ev.(*fetch.EventRequestPaused)
+			if !ok {
+				return
+			}
+			// CDP commands cannot be issued from inside the listener
+			go func() {
+				execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+				_ = fulfillPausedRequest(execCtx, rt, paused)
+			}()
+		})
+		return fetch.Enable().Do(ctx)
+	})
+}
+
+// fulfillPausedRequest performs a paused browser request through rt and
+// hands the response back to the browser
+func fulfillPausedRequest(ctx context.Context, rt http.RoundTripper, ev *fetch.EventRequestPaused) error {
+	req, err := newRequestFromCDP(ctx, ev.Request)
+	if err != nil {
+		return fetch.FailRequest(ev.RequestID, network.ErrorReasonFailed).Do(ctx)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return fetch.FailRequest(ev.RequestID, network.ErrorReasonFailed).Do(ctx)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fetch.FailRequest(ev.RequestID, network.ErrorReasonFailed).Do(ctx)
+	}
+
+	headers := make([]*fetch.HeaderEntry, 0, len(resp.Header))
+	for name, values := range resp.Header {
+		for _, value := range values {
+			headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+		}
+	}
+
+	return fetch.FulfillRequest(ev.RequestID, int64(resp.StatusCode)).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(body)).
+		Do(ctx)
+}
+
+// newRequestFromCDP converts a CDP request description into an http.Request
+func newRequestFromCDP(ctx context.Context, r *network.Request) (*http.Request, error) {
+	var body bytes.Buffer
+	for _, entry := range r.PostDataEntries {
+		chunk, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid post data: %v", err)
+		}
+		body.Write(chunk)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range r.Headers {
+		// Let the Go transport negotiate and decode compression itself
+		if strings.EqualFold(name, "Accept-Encoding") {
+			continue
+		}
+		req.Header.Set(name, fmt.Sprint(value))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,35 @@
+package strategy
+
+import (
+	"arachne/internal/config"
+)
+
+// Selector picks the scraping strategy for each URL. Strategy instances are
+// shared so HTTP-based strategies reuse one connection pool.
+type Selector struct {
+	http     *HTTPStrategy
+	headless *HeadlessStrategy
+	feed     *FeedStrategy
+}
+
+// NewSelector creates a strategy selector with the given configuration
+func NewSelector(cfg *config.Config) *Selector {
+	httpStrategy := NewHTTPStrategy(cfg)
+	return &Selector{
+		http:     httpStrategy,
+		headless: NewHeadlessStrategy(),
+		feed:     &FeedStrategy{http: httpStrategy},
+	}
+}
+
+// ForURL returns the strategy configured for urlStr
+func (s *Selector) ForURL(urlStr string, cfg *config.Config) ScrapingStrategy {
+	switch cfg.StrategyFor(urlStr) {
+	case config.StrategyHeadless:
+		return s.headless
+	case config.StrategyFeed:
+		return s.feed
+	default:
+		return s.http
+	}
+}
@@ -1,14 +1,23 @@
 package strategy
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 
+	"github.com/PuerkitoBio/goquery"
+
+	"arachne/internal/auth"
 	"arachne/internal/config"
+	"arachne/internal/dedup"
 	"arachne/internal/errors"
+	"arachne/internal/har"
+	"arachne/internal/replay"
+	"arachne/internal/types"
 	"arachne/pkg/parser"
 )
 
@@ -18,7 +27,66 @@ type ScrapedResult struct {
 	Title      string
 	Body       string // The full HTML/JSON content
 	StatusCode int
-	NextURL    string // For pagination support
+	NextURL    string                   // For pagination support
+	FeedItems  []parser.FeedItem        // Entries when the response is a feed
+	FollowURLs []string                 // Feed entry links to scrape, when FeedFollowLinks is enabled
+	Metadata   *parser.Metadata         // Document metadata for HTML pages
+	Records    []map[string]interface{} // Records extracted with the configured schema
+	Links      []parser.Link            // Outgoing links, when link extraction is enabled
+	Tables     []parser.Table           // HTML tables, when table extraction is enabled
+	Structured parser.StructuredData    // schema.org items embedded in HTML pages
+	Text       string                   // Visible text of HTML pages, used for fingerprints
+	Assets     []types.Asset            // Assets referenced by HTML pages, when asset download is enabled
+
+	// Response details
+	Method      string
+	FinalURL    string            // URL after redirects
+	Redirects   []types.Redirect  // Redirect responses followed, in order
+	Headers     http.Header       // Headers of the final response
+	ContentType string            // Content-Type of the final response
+	Timings     *har.PhaseTimings // Phase breakdown, when the request was traced
+	Strategy    string            // Name of the strategy that produced the result
+}
+
+// Fill copies the strategy-produced fields onto the scraped data record
+func (r *ScrapedResult) Fill(data *types.ScrapedData) {
+	data.Title = r.Title
+	data.Status = r.StatusCode
+	data.Size = len(r.Body)
+	data.Body = r.Body
+	data.NextURL = r.NextURL
+	data.FeedItems = r.FeedItems
+	data.Metadata = r.Metadata
+	data.Records = r.Records
+	data.Links = r.Links
+	data.Tables = r.Tables
+	data.Structured = types.StructuredData(r.Structured)
+	data.Assets = r.Assets
+	data.Method = r.Method
+	data.FinalURL = r.FinalURL
+	data.Redirects = r.Redirects
+	data.Headers = r.Headers
+	data.ContentType = r.ContentType
+	data.Timings = r.Timings
+	data.Strategy = r.Strategy
+
+	// Non-HTML responses are fingerprinted on their raw body
+	text := r.Text
+	if text == "" {
+		text = r.Body
+	}
+	dedup.Fingerprint(data, text)
+}
+
+// RecordError sets the error of a failed scrape with its category; the status
+// is taken from a ScraperError when available
+func RecordError(data *types.ScrapedData, err error) {
+	data.Error = err.Error()
+	data.ErrorType = errors.GetErrorType(err)
+	var scraperErr *errors.ScraperError
+	if stderrors.As(err, &scraperErr) && scraperErr.StatusCode > 0 {
+		data.Status = scraperErr.StatusCode
+	}
 }
 
 // ScrapingStrategy defines the contract for different scraping methods.
@@ -33,61 +101,191 @@ type HTTPStrategy struct {
 
 // NewHTTPStrategy creates a new HTTP strategy with the given configuration
 func NewHTTPStrategy(cfg *config.Config) *HTTPStrategy {
-	// Create transport with connection pooling and HTTP/2 support
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
-		DisableCompression:  false, // Enable compression
-		ForceAttemptHTTP2:   true,  // Force HTTP/2 when possible
-	}
-
-	return &HTTPStrategy{
-		client: &http.Client{
-			Timeout:   cfg.RequestTimeout,
-			Transport: transport,
-		},
+	return &HTTPStrategy{client: NewHTTPClient(cfg)}
+}
+
+// NewHTTPClient returns a client using the configured timeout and transport
+// chain, for other stages that fetch on the scraper's behalf
+func NewHTTPClient(cfg *config.Config) *http.Client {
+	return &http.Client{
+		Timeout:   cfg.RequestTimeout,
+		Transport: newTransport(cfg),
+	}
+}
+
+// newTransport builds the transport chain shared by all strategies:
+// per-domain TLS profiles, then per-domain authentication, optionally wrapped
+// by the record/replay layer. Replay sits outside auth so fixtures never
+// contain credentials.
+func newTransport(cfg *config.Config) http.RoundTripper {
+	var transport http.RoundTripper = newTLSTransport(cfg)
+	if len(cfg.AuthProfiles) > 0 {
+		transport = auth.NewTransport(transport, cfg.AuthProfileFor)
+	}
+	if cfg.ReplayMode != "" {
+		transport = replay.NewTransport(transport, cfg.FixturesDir, cfg.ReplayMode)
 	}
+	return transport
 }
 
 // Execute performs HTTP-based scraping
 func (s *HTTPStrategy) Execute(ctx context.Context, urlStr string, cfg *config.Config) (*ScrapedResult, error) {
-	// Create request with context for cancellation
+	resp, body, timings, err := s.fetch(ctx, urlStr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+
+	// Feeds are detected by content type and parsed into entries
+	if parser.IsFeed(string(body), contentType) {
+		if feed, err := parser.ParseFeed(string(body), urlStr); err == nil {
+			return newFeedResult(feed, resp, body, timings, cfg), nil
+		}
+	}
+
+	// Extract title from response
+	title := parser.ExtractTitle(string(body), contentType)
+
+	// For HTTP strategy, we don't extract next URL (no JavaScript execution)
+	result := &ScrapedResult{
+		Title:      title,
+		Body:       string(body),
+		StatusCode: resp.StatusCode,
+		NextURL:    "", // HTTP strategy doesn't handle pagination
+		Strategy:   "http",
+	}
+	describeResponse(result, resp, timings)
+
+	// Resolve URLs against the final URL, after redirects
+	finalURL := resp.Request.URL.String()
+
+	// JSON API responses are mapped to records with JSONPath/JMESPath schemas
+	if cfg.Schema != nil && cfg.Schema.IsJSON() && json.Valid(body) {
+		records, err := cfg.Schema.ExtractJSONRecords(body, finalURL)
+		if err != nil {
+			return nil, errors.NewScraperError(urlStr, "Schema extraction failed", err)
+		}
+		result.Records = records
+		return result, nil
+	}
+
+	// XML APIs are mapped with namespace-aware XPath or converted to JSON
+	if cfg.Schema != nil && parser.IsXML(string(body), contentType) {
+		records, err := cfg.Schema.ExtractXMLRecords(body, finalURL)
+		if err != nil {
+			return nil, errors.NewScraperError(urlStr, "Schema extraction failed", err)
+		}
+		result.Records = records
+		return result, nil
+	}
+
+	if !parser.IsHTML(string(body), contentType) {
+		return result, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return result, nil
+	}
+
+	if err := extractDocument(result, doc, finalURL, cfg); err != nil {
+		return nil, errors.NewScraperError(urlStr, "Schema extraction failed", err)
+	}
+
+	return result, nil
+}
+
+// extractDocument fills the structured parts of a result from a parsed HTML
+// page: metadata, embedded structured data, outlinks, assets and tables if
+// enabled, and records from the configured schema
+func extractDocument(result *ScrapedResult, doc *goquery.Document, baseURL string, cfg *config.Config) error {
+	result.Metadata = parser.ExtractMetadataFromDocument(doc, baseURL)
+	result.Structured = parser.ExtractStructuredDataFromDocument(doc, baseURL)
+	result.Text = parser.VisibleText(doc)
+	if cfg.ExtractLinks {
+		result.Links = parser.ExtractLinksFromDocument(doc, baseURL)
+	}
+	if cfg.DownloadAssets {
+		for _, ref := range parser.ExtractAssetsFromDocument(doc, baseURL, cfg.AssetKinds, cfg.AssetExtensions) {
+			result.Assets = append(result.Assets, types.Asset{URL: ref.URL, Kind: ref.Kind})
+		}
+	}
+	if spec := cfg.TableSpec(); spec != nil {
+		tables, err := parser.ExtractTablesFromDocument(doc, spec)
+		if err != nil {
+			return err
+		}
+		result.Tables = tables
+	}
+
+	if cfg.Schema == nil {
+		return nil
+	}
+
+	records, err := cfg.Schema.Extract(doc, baseURL)
+	if err != nil {
+		return err
+	}
+	result.Records = records
+	return nil
+}
+
+// describeResponse records the method, final URL, redirect chain, headers
+// and timings of a fetched response on the result
+func describeResponse(result *ScrapedResult, resp *http.Response, timings *har.PhaseTimings) {
+	result.Method = resp.Request.Method
+	result.FinalURL = resp.Request.URL.String()
+	result.Headers = resp.Header
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.Timings = timings
+
+	// Each redirected request keeps the response that caused it
+	for redirect := resp.Request.Response; redirect != nil; redirect = redirect.Request.Response {
+		result.Redirects = append([]types.Redirect{{
+			URL:        redirect.Request.URL.String(),
+			StatusCode: redirect.StatusCode,
+		}}, result.Redirects...)
+	}
+}
+
+// fetch performs the GET request and returns the response with its body read
+// and the request's phase timings
+func (s *HTTPStrategy) fetch(ctx context.Context, urlStr string, cfg *config.Config) (*http.Response, []byte, *har.PhaseTimings, error) {
+	// Create request with context for cancellation, traced for timings
+	trace, ctx := har.NewTrace(ctx)
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		return nil, errors.NewScraperError(urlStr, "Failed to create request", err)
+		return nil, nil, nil, errors.NewScraperError(urlStr, "Failed to create request", err)
 	}
 
 	// Set user agent to be respectful
 	req.Header.Set("User-Agent", cfg.UserAgent)
 
+	// Record the exchange when a HAR recorder is attached
+	client := s.client
+	if cfg.HARRecorder != nil {
+		recording := *s.client
+		recording.Transport = har.NewTransport(s.client.Transport, cfg.HARRecorder)
+		client = &recording
+	}
+
 	// Make the request
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, errors.NewScraperError(urlStr, "Request failed", err)
+		return nil, nil, nil, errors.NewScraperError(urlStr, "Request failed", err)
 	}
 	defer resp.Body.Close()
 
 	// Check for HTTP errors
 	if resp.StatusCode >= 400 {
-		return nil, errors.NewHTTPError(urlStr, resp.StatusCode, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		return nil, nil, nil, errors.NewHTTPError(urlStr, resp.StatusCode, fmt.Sprintf("HTTP %d", resp.StatusCode))
 	}
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.NewScraperError(urlStr, "Failed to read body", err)
+		return nil, nil, nil, errors.NewScraperError(urlStr, "Failed to read body", err)
 	}
 
-	// Extract title from response
-	title := parser.ExtractTitle(string(body), resp.Header.Get("Content-Type"))
-
-	// For HTTP strategy, we don't extract next URL (no JavaScript execution)
-	return &ScrapedResult{
-		Title:      title,
-		Body:       string(body),
-		StatusCode: resp.StatusCode,
-		NextURL:    "", // HTTP strategy doesn't handle pagination
-	}, nil
+	return resp, body, trace.Timings(), nil
 }
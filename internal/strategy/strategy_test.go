@@ -0,0 +1,356 @@
+package strategy
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+	"arachne/internal/errors"
+	"arachne/internal/replay"
+	"arachne/internal/types"
+	"arachne/pkg/parser"
+)
+
+// record re-records the replay fixtures against the live sites:
+// go test ./internal/strategy -run TestHTTPStrategyReplay -record
+var record = flag.Bool("record", false, "record tests/fixtures/http against the live sites")
+
+// TestHTTPStrategyReplay scrapes the URLs of the default CLI run from fixtures.
+// The checked-in fixtures are hand-written, so the test only checks what a
+// re-recording keeps: httpbin echoes the caller's address, which varies.
+func TestHTTPStrategyReplay(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ReplayMode = replay.ModeReplay
+	if *record {
+		cfg.ReplayMode = replay.ModeRecord
+	}
+	cfg.FixturesDir = "../../tests/fixtures/http"
+	s := NewHTTPStrategy(cfg)
+
+	tests := []struct {
+		name          string
+		url           string
+		expectedTitle string
+		titlePrefix   bool // Only the start of the title is stable
+		expectedCode  int
+		unrecorded    bool
+	}{
+		{name: "HTML page", url: "https://golang.org", expectedTitle: "The Go Programming Language"},
+		{name: "JSON without a title field", url: "https://httpbin.org/get", expectedTitle: "origin: ", titlePrefix: true},
+		{name: "JSON API", url: "https://jsonplaceholder.typicode.com/posts/1", expectedTitle: "sunt aut facere repellat provident occaecati excepturi optio reprehenderit"},
+		{name: "JSON name field", url: "https://api.github.com/users/golang", expectedTitle: "Go"},
+		{name: "Not found", url: "https://httpbin.org/status/404", expectedCode: http.StatusNotFound},
+		{name: "Delayed response", url: "https://httpbin.org/delay/2", expectedTitle: "origin: ", titlePrefix: true},
+		{name: "Server error", url: "https://httpbin.org/status/500", expectedCode: http.StatusInternalServerError},
+		{name: "Rate limited", url: "https://httpbin.org/status/429", expectedCode: http.StatusTooManyRequests},
+		{name: "Unrecorded URL", url: "https://example.com/not-recorded", unrecorded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if *record {
+				if tt.unrecorded {
+					t.Skip("not recorded")
+				}
+				// Live responses change, only store them
+				s.Execute(context.Background(), tt.url, cfg)
+				return
+			}
+
+			result, err := s.Execute(context.Background(), tt.url, cfg)
+			switch {
+			case tt.unrecorded:
+				if !stderrors.Is(err, replay.ErrNoFixture) {
+					t.Fatalf("Execute() error = %v, want ErrNoFixture", err)
+				}
+			case tt.expectedCode != 0:
+				var scraperErr *errors.ScraperError
+				if !stderrors.As(err, &scraperErr) || scraperErr.StatusCode != tt.expectedCode {
+					t.Fatalf("Execute() error = %v, want HTTP %d", err, tt.expectedCode)
+				}
+			case err != nil:
+				t.Fatalf("Execute() error = %v", err)
+			case tt.titlePrefix && !strings.HasPrefix(result.Title, tt.expectedTitle):
+				t.Errorf("Execute() title = %q, want it to start with %q", result.Title, tt.expectedTitle)
+			case !tt.titlePrefix && result.Title != tt.expectedTitle:
+				t.Errorf("Execute() title = %q, want %q", result.Title, tt.expectedTitle)
+			}
+		})
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			w.Header().Set("Content-Type", "application/rss+xml")
+		case "/xml":
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		w.Write([]byte(`<rss version="2.0"><channel><title>News</title>` +
+			`<item><title>One</title><link>/one</link></item></channel></rss>`))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	tests := []struct {
+		name     string
+		path     string
+		strategy ScrapingStrategy
+	}{
+		{name: "Detected by content type", path: "/feed", strategy: NewHTTPStrategy(cfg)},
+		{name: "Explicit feed strategy", path: "/xml", strategy: NewFeedStrategy(cfg)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.strategy.Execute(context.Background(), server.URL+tt.path, cfg)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Title != "News" || len(result.FeedItems) != 1 || result.FeedItems[0].Link != server.URL+"/one" {
+				t.Errorf("got title %q with items %+v, want %q with the resolved entry", result.Title, result.FeedItems, "News")
+			}
+		})
+	}
+}
+
+func TestHTTPStrategyJSONSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results": [{"name": "alpha"}, {"name": "beta"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Schema = &parser.Schema{
+		ContainerJSONPath: "$.results[*]",
+		Fields:            []parser.Field{{Name: "name", JSONPath: "$.name"}},
+	}
+
+	result, err := NewHTTPStrategy(cfg).Execute(context.Background(), server.URL, cfg)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(result.Records) != 2 || result.Records[1]["name"] != "beta" {
+		t.Errorf("Records = %v, want one record per result", result.Records)
+	}
+}
+
+func TestHTTPStrategyResponseDetails(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Served-By", "test")
+		w.Write([]byte("<html><head><title>Page</title></head><body>Hello</body></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	result, err := NewHTTPStrategy(cfg).Execute(context.Background(), server.URL+"/old", cfg)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// The response details are carried over to the scraped data
+	var data types.ScrapedData
+	result.Fill(&data)
+
+	if data.Strategy != "http" || data.Method != http.MethodGet || data.FinalURL != server.URL+"/page" {
+		t.Errorf("strategy, method, final URL = %s %s %s", data.Strategy, data.Method, data.FinalURL)
+	}
+	expected := []types.Redirect{
+		{URL: server.URL + "/old", StatusCode: http.StatusMovedPermanently},
+		{URL: server.URL + "/moved", StatusCode: http.StatusFound},
+	}
+	if !reflect.DeepEqual(data.Redirects, expected) {
+		t.Errorf("Redirects = %+v, want %+v", data.Redirects, expected)
+	}
+	if data.ContentType != "text/html; charset=utf-8" || data.Headers.Get("X-Served-By") != "test" {
+		t.Errorf("content type = %q, headers = %v", data.ContentType, data.Headers)
+	}
+	if data.Timings == nil || data.Timings.Connect < 0 || data.Timings.TLS != -1 || data.Timings.Total < data.Timings.TTFB {
+		t.Errorf("Timings = %+v, want a plain HTTP breakdown", data.Timings)
+	}
+}
+
+func TestHTTPStrategyHidesCredentials(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("TEST_API_KEY", "s3cret")
+	cfg := config.DefaultConfig()
+	cfg.AuthProfiles = map[string]config.AuthProfile{
+		"127.0.0.1": {Type: config.AuthAPIKey, APIKey: "env:TEST_API_KEY", QueryParam: "key"},
+	}
+	result, err := NewHTTPStrategy(cfg).Execute(context.Background(), server.URL+"/old", cfg)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if result.FinalURL != server.URL+"/page" {
+		t.Errorf("FinalURL = %s, want it without the API key", result.FinalURL)
+	}
+	if len(result.Redirects) != 1 || result.Redirects[0].URL != server.URL+"/old" {
+		t.Errorf("Redirects = %+v, want them without the API key", result.Redirects)
+	}
+}
+
+func TestHTTPStrategyCrossHostRedirect(t *testing.T) {
+	var leaked string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization")
+		w.Write([]byte("ok"))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Same address, another host name
+		http.Redirect(w, r, strings.Replace(other.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_TOKEN", "s3cret")
+	cfg := config.DefaultConfig()
+	cfg.AuthProfiles = map[string]config.AuthProfile{
+		"127.0.0.1": {Type: config.AuthBearer, Token: "env:TEST_TOKEN"},
+		"*":         {Type: config.AuthBearer, Token: "env:TEST_TOKEN"},
+	}
+	result, err := NewHTTPStrategy(cfg).Execute(context.Background(), server.URL, cfg)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.HasPrefix(result.FinalURL, "http://localhost:") {
+		t.Fatalf("FinalURL = %s, want the other host", result.FinalURL)
+	}
+	if leaked != "" {
+		t.Errorf("redirect target received Authorization %q", leaked)
+	}
+}
+
+func TestSelectorForURL(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.URLStrategies = map[string]string{
+		"https://news.example/":      config.StrategyFeed,
+		"https://news.example/live/": config.StrategyHeadless,
+	}
+	selector := NewSelector(cfg)
+
+	tests := []struct {
+		url      string
+		expected ScrapingStrategy
+	}{
+		{url: "https://news.example/rss", expected: selector.feed},
+		{url: "https://news.example/live/today", expected: selector.headless},
+		{url: "https://other.example/", expected: selector.http},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := selector.ForURL(tt.url, cfg); got != tt.expected {
+				t.Errorf("ForURL() = %T, want %T", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCrawler(t *testing.T) {
+	var flaky int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`<rss version="2.0"><channel><title>News</title>` +
+				`<item><title>One</title><link>/one</link></item>` +
+				`<item><title>Feed</title><link>/feed</link></item></channel></rss>`))
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/flaky":
+			if flaky++; flaky == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fallthrough
+		default:
+			w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		follow   bool
+		expected []string
+	}{
+		{name: "Without following", expected: []string{"/feed", "/flaky", "/down"}},
+		{name: "Following feed entries", follow: true, expected: []string{"/feed", "/flaky", "/down", "/one"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky = 0
+			cfg := config.DefaultConfig()
+			cfg.RetryDelay = time.Millisecond
+			cfg.URLStrategies = map[string]string{server.URL + "/feed": config.StrategyFeed}
+			cfg.FeedFollowLinks = tt.follow
+
+			results := NewCrawler(cfg).ScrapeURLsWithConfig([]string{server.URL + "/feed", server.URL + "/flaky", server.URL + "/down"}, cfg)
+			var urls []string
+			for _, result := range results {
+				urls = append(urls, strings.TrimPrefix(result.URL, server.URL))
+			}
+			if !reflect.DeepEqual(urls, tt.expected) {
+				t.Fatalf("scraped %v, want %v", urls, tt.expected)
+			}
+			if results[0].Strategy != "feed" || len(results[0].FeedItems) != 2 {
+				t.Errorf("feed result = %s with %d items, want the feed strategy", results[0].Strategy, len(results[0].FeedItems))
+			}
+			if results[1].Error != "" || results[1].Title != "Page" || results[1].Attempts != 2 {
+				t.Errorf("retried result = %+v, want success on the second attempt", results[1])
+			}
+			if down := results[2]; down.ErrorType != "http_503" || down.Status != http.StatusServiceUnavailable || down.Attempts != cfg.RetryAttempts+1 {
+				t.Errorf("failed result = %+v, want an http_503 error after every attempt", down)
+			}
+		})
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	var data types.ScrapedData
+	RecordError(&data, errors.NewHTTPError("https://example.com", http.StatusServiceUnavailable, "HTTP 503"))
+	if data.ErrorType != "http_503" || data.Status != http.StatusServiceUnavailable || data.Error == "" {
+		t.Errorf("RecordError() = %+v", data)
+	}
+
+	var failed types.ScrapedData
+	RecordError(&failed, errors.NewScraperError("https://nowhere.invalid", "Request failed",
+		fmt.Errorf("dial tcp: lookup nowhere.invalid: no such host")))
+	if failed.ErrorType != "dns" || failed.Status != 0 {
+		t.Errorf("RecordError() type = %q, status = %d, want dns without status", failed.ErrorType, failed.Status)
+	}
+}
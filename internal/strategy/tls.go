@@ -0,0 +1,117 @@
+package strategy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"arachne/internal/config"
+)
+
+// newBaseTransport creates a transport with connection pooling and HTTP/2 support
+func newBaseTransport() *http.Transport {
+	return &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableCompression:  false, // Enable compression
+		ForceAttemptHTTP2:   true,  // Force HTTP/2 when possible
+	}
+}
+
+// buildTLSConfig converts a TLS profile into a crypto/tls configuration
+func buildTLSConfig(profile *config.TLSProfile) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: profile.InsecureSkipVerify,
+	}
+
+	if version := profile.TLSVersion(); version != 0 {
+		tlsConfig.MinVersion = version
+	}
+
+	if len(profile.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, caFile := range profile.CAFiles {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file %s: %v", caFile, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if profile.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(profile.ClientCertFile, profile.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// tlsTransport routes each request through a transport built for the
+// TLS profile of the request's host, so redirects across domains pick up
+// the right settings.
+type tlsTransport struct {
+	cfg        *config.Config
+	base       *http.Transport
+	mu         sync.Mutex
+	transports map[string]http.RoundTripper
+}
+
+// newTLSTransport creates a transport that applies per-domain TLS profiles
+func newTLSTransport(cfg *config.Config) *tlsTransport {
+	return &tlsTransport{
+		cfg:        cfg,
+		base:       newBaseTransport(),
+		transports: make(map[string]http.RoundTripper),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.transportFor(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// transportFor returns the cached transport for the host's TLS profile
+func (t *tlsTransport) transportFor(host string) (http.RoundTripper, error) {
+	key, profile := t.cfg.TLSProfileFor(host)
+	if profile == nil {
+		return t.base, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if transport, exists := t.transports[key]; exists {
+		return transport, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(profile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS profile %q: %w", key, err)
+	}
+
+	transport := newBaseTransport()
+	transport.TLSClientConfig = tlsConfig
+	t.transports[key] = transport
+	return transport, nil
+}
@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arachne/internal/config"
+)
+
+func newTLSTestServer(t *testing.T) (*httptest.Server, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Internal</title></head></html>`))
+	}))
+	t.Cleanup(server.Close)
+
+	// Write the test server's self-signed certificate as a CA bundle
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	return server, caFile
+}
+
+func TestHTTPStrategyTLSProfiles(t *testing.T) {
+	server, caFile := newTLSTestServer(t)
+	serverURL, _ := url.Parse(server.URL)
+	host := serverURL.Hostname()
+
+	tests := []struct {
+		name     string
+		profiles map[string]config.TLSProfile
+		wantErr  bool
+	}{
+		{
+			name:     "Strict verification by default",
+			profiles: nil,
+			wantErr:  true,
+		},
+		{
+			name:     "Custom CA bundle",
+			profiles: map[string]config.TLSProfile{host: {CAFiles: []string{caFile}}},
+			wantErr:  false,
+		},
+		{
+			name:     "Fallback profile",
+			profiles: map[string]config.TLSProfile{config.DefaultTLSProfile: {CAFiles: []string{caFile}}},
+			wantErr:  false,
+		},
+		{
+			name:     "Explicit insecure toggle",
+			profiles: map[string]config.TLSProfile{host: {InsecureSkipVerify: true}},
+			wantErr:  false,
+		},
+		{
+			name:     "Profile for another domain",
+			profiles: map[string]config.TLSProfile{"example.com": {InsecureSkipVerify: true}},
+			wantErr:  true,
+		},
+		{
+			name:     "Missing CA file",
+			profiles: map[string]config.TLSProfile{host: {CAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.TLSProfiles = tt.profiles
+
+			result, err := NewHTTPStrategy(cfg).Execute(context.Background(), server.URL, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && result.Title != "Internal" {
+				t.Errorf("Execute() title = %q, want %q", result.Title, "Internal")
+			}
+		})
+	}
+}
+
+func TestTLSProfileFor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TLSProfiles = map[string]config.TLSProfile{
+		"corp.example":     {MinVersion: "1.3"},
+		"api.corp.example": {InsecureSkipVerify: true},
+		"*":                {MinVersion: "1.2"},
+	}
+
+	tests := []struct {
+		host    string
+		wantKey string
+	}{
+		{host: "api.corp.example", wantKey: "api.corp.example"},
+		{host: "wiki.corp.example", wantKey: "corp.example"},
+		{host: "CORP.EXAMPLE", wantKey: "corp.example"},
+		{host: "golang.org", wantKey: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			key, profile := cfg.TLSProfileFor(tt.host)
+			if key != tt.wantKey || profile == nil {
+				t.Errorf("TLSProfileFor(%s) = %q, want %q", tt.host, key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestHeadlessInterception(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TLSProfiles = map[string]config.TLSProfile{
+		"modern.example": {MinVersion: "1.3"},
+		"plain.example":  {},
+	}
+
+	if !interceptsRequests(cfg, "modern.example") {
+		t.Error("a profile setting only min_version is not applied to headless requests")
+	}
+	if interceptsRequests(cfg, "plain.example") || interceptsRequests(cfg, "golang.org") {
+		t.Error("hosts without TLS settings are intercepted")
+	}
+}
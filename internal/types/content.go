@@ -0,0 +1,90 @@
+package types
+
+import "time"
+
+// The values below are produced by the parser and HTTP tracing packages,
+// which alias them, and kept here so results depend on no other package.
+
+// PhaseTimings is a compact phase breakdown in milliseconds. DNS, Connect and
+// TLS are -1 when the phase did not happen, e.g. on a reused connection.
+type PhaseTimings struct {
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	TLS     float64 `json:"tls"`
+	TTFB    float64 `json:"ttfb"`  // From the start of the request to the first response byte
+	Total   float64 `json:"total"` // Including redirects and reading the body
+}
+
+// ContentInfo describes the type of a response, combining the declared
+// Content-Type header with what the body looks like
+type ContentInfo struct {
+	MIMEType string `json:"mime_type"`          // Most reliable type: the sniffed one when the header is missing, generic or wrong
+	Charset  string `json:"charset,omitempty"`  // Declared, or found in a BOM, meta tag or XML declaration
+	Category string `json:"category"`           // html, json, xml, feed, image, pdf, text or binary
+	Declared string `json:"declared,omitempty"` // Media type from the Content-Type header
+	Sniffed  string `json:"sniffed,omitempty"`  // Media type detected from the body
+	Mismatch bool   `json:"mismatch,omitempty"` // The body contradicts the declared type
+}
+
+// FeedItem is a single feed entry
+type FeedItem struct {
+	Title     string     `json:"title"`
+	Link      string     `json:"link"`
+	Published *time.Time `json:"published,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Summary   string     `json:"summary,omitempty"`
+}
+
+// Metadata is the document-level metadata of an HTML page
+type Metadata struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Language    string            `json:"language,omitempty"`
+	OpenGraph   map[string]string `json:"open_graph,omitempty"`   // og:* properties, keyed without the prefix
+	TwitterCard map[string]string `json:"twitter_card,omitempty"` // twitter:* fields, keyed without the prefix
+	Favicon     string            `json:"favicon,omitempty"`
+	Alternates  []Alternate       `json:"alternates,omitempty"`
+}
+
+// Alternate is a localized version of a page declared with hreflang
+type Alternate struct {
+	Hreflang string `json:"hreflang"`
+	URL      string `json:"url"`
+}
+
+// Article is the main content of a page with boilerplate removed
+type Article struct {
+	Title     string     `json:"title,omitempty"`
+	Byline    string     `json:"byline,omitempty"`
+	Published *time.Time `json:"published,omitempty"`
+	Text      string     `json:"text"`
+	Markdown  string     `json:"markdown,omitempty"`
+	WordCount int        `json:"word_count"`
+}
+
+// Link is an outgoing link found on a page
+type Link struct {
+	URL      string   `json:"url"`
+	Text     string   `json:"text,omitempty"`
+	Rel      []string `json:"rel,omitempty"` // e.g. nofollow, sponsored, ugc
+	Internal bool     `json:"internal"`      // Same host as the page, ignoring a leading www.
+}
+
+// HasRel reports whether the link carries the given rel value
+func (l *Link) HasRel(rel string) bool {
+	for _, value := range l.Rel {
+		if value == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// Table is an HTML table converted to rows of named columns
+type Table struct {
+	Index   int                      `json:"index"` // Position among the page's selected tables
+	Caption string                   `json:"caption,omitempty"`
+	Headers []string                 `json:"headers"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
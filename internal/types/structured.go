@@ -0,0 +1,134 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Item is a structured data item normalised to JSON-LD form: "@type" holds
+// the short type name, properties map to strings, numbers, nested items or
+// lists of those
+type Item map[string]interface{}
+
+// StructuredData holds the schema.org items of a page keyed by @type. An item
+// with several types is listed under each of them. parser.StructuredData
+// has the same form, with typed views of the items.
+type StructuredData map[string][]Item
+
+// Type returns the item's first type name
+func (i Item) Type() string {
+	for _, name := range i.Types() {
+		return name
+	}
+	return ""
+}
+
+// Types returns the item's type names
+func (i Item) Types() []string {
+	var names []string
+	for _, value := range valueList(i["@type"]) {
+		if name, ok := value.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// String returns a property as text. Nested items yield their name, lists
+// their first value.
+func (i Item) String(property string) string {
+	return textValue(i[property])
+}
+
+// Strings returns every value of a property as text, skipping empty ones
+func (i Item) Strings(property string) []string {
+	var texts []string
+	for _, value := range valueList(i[property]) {
+		if text := textValue(value); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// URL returns a property given as a URL, or as an object with a url,
+// contentUrl or @id
+func (i Item) URL(property string) string {
+	for _, value := range valueList(i[property]) {
+		if s, ok := value.(string); ok {
+			return s
+		}
+		if item := asItem(value); item != nil {
+			return firstText(item.String("url"), item.String("contentUrl"), item.String("@id"))
+		}
+	}
+	return ""
+}
+
+// Items returns a property as a list of nested items
+func (i Item) Items(property string) []Item {
+	var items []Item
+	for _, value := range valueList(i[property]) {
+		if item := asItem(value); item != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// asItem returns value as an item when it is an object
+func asItem(value interface{}) Item {
+	switch v := value.(type) {
+	case Item:
+		return v
+	case map[string]interface{}:
+		return Item(v)
+	}
+	return nil
+}
+
+// valueList returns a property value as a list
+func valueList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	if value == nil {
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// textValue renders a property as text: strings as they are, numbers in
+// plain notation, nested items by name or @id
+func textValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return fmt.Sprint(v)
+	case []interface{}:
+		for _, element := range v {
+			if text := textValue(element); text != "" {
+				return text
+			}
+		}
+	default:
+		if item := asItem(v); item != nil {
+			return firstText(item.String("name"), item.String("@id"), item.String("url"))
+		}
+	}
+	return ""
+}
+
+// firstText returns the first value that is not blank
+func firstText(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
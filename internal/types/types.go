@@ -1,14 +1,106 @@
 package types
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 // ScrapedData represents the data we extract from websites
 type ScrapedData struct {
-	URL     string    `json:"url"`
-	Title   string    `json:"title"`
-	Status  int       `json:"status"`
-	Size    int       `json:"size"`
-	Error   string    `json:"error,omitempty"`
-	Scraped time.Time `json:"scraped"`
-	NextURL string    `json:"next_url,omitempty"`
+	URL          string                   `json:"url"`
+	Title        string                   `json:"title"`
+	Status       int                      `json:"status"`
+	Size         int                      `json:"size"`
+	Error        string                   `json:"error,omitempty"`
+	ErrorType    string                   `json:"error_type,omitempty"`    // timeout, dns, tls, connection, http_<status>, ...
+	PluginErrors map[string]string        `json:"plugin_errors,omitempty"` // Plugin name -> error, for plugins that continue on error
+	Scraped      time.Time                `json:"scraped"`
+	Method       string                   `json:"method,omitempty"`
+	FinalURL     string                   `json:"final_url,omitempty"` // URL after redirects
+	Redirects    []Redirect               `json:"redirects,omitempty"` // Redirect responses followed to reach FinalURL
+	Headers      http.Header              `json:"headers,omitempty"`   // Response headers
+	ContentType  string                   `json:"content_type,omitempty"`
+	ContentInfo  *ContentInfo             `json:"content_info,omitempty"` // Detected type, set by the content type plugin
+	Timings      *PhaseTimings            `json:"timings,omitempty"`
+	Attempts     int                      `json:"attempts,omitempty"` // Requests made, including retries
+	Strategy     string                   `json:"strategy,omitempty"` // http, headless or feed
+	NextURL      string                   `json:"next_url,omitempty"`
+	FeedItems    []FeedItem               `json:"feed_items,omitempty"`
+	Metadata     *Metadata                `json:"metadata,omitempty"`
+	Records      []map[string]interface{} `json:"records,omitempty"`         // Structured records from the job's extraction schema
+	Validation   *Validation              `json:"validation,omitempty"`      // Records checked against the job's JSON Schema
+	Article      *Article                 `json:"article,omitempty"`         // Main content, set by the readability plugin
+	Links        []Link                   `json:"links,omitempty"`           // Outgoing links, when link extraction is enabled
+	Tables       []Table                  `json:"tables,omitempty"`          // HTML tables, when table extraction is enabled
+	Structured   StructuredData           `json:"structured_data,omitempty"` // JSON-LD, microdata and RDFa items keyed by @type
+	ContentHash  string                   `json:"content_hash,omitempty"`    // SHA-256 of the visible text
+	SimHash      string                   `json:"simhash,omitempty"`         // 64-bit SimHash of the visible text, in hex
+	Assets       []Asset                  `json:"assets,omitempty"`          // Images, stylesheets, scripts and documents, when the asset stage runs
+	DuplicateOf  string                   `json:"duplicate_of,omitempty"`    // URL of the earlier result this one nearly duplicates
+	Change       *Change                  `json:"change,omitempty"`          // Comparison with the previous scrape, when change detection is enabled
+	InlineBody   string                   `json:"body,omitempty"`            // Response body, when bodies are inlined
+	BodyRef      string                   `json:"body_ref,omitempty"`        // Path of the stored response body, when bodies are stored
+	Body         string                   `json:"-"`                         // Raw response body, available to plugins
+}
+
+// Redirect is one redirect response followed while fetching a page
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// Validation is the outcome of checking a result's records against a JSON Schema
+type Validation struct {
+	Schema     string      `json:"schema"` // $id or title of the schema, or a hash of it
+	Valid      bool        `json:"valid"`
+	Records    int         `json:"records"` // Records checked
+	Failed     int         `json:"failed"`  // Records with at least one violation
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is one value of a record that does not satisfy the schema
+type Violation struct {
+	Record  int    `json:"record"`  // Index in Records
+	Path    string `json:"path"`    // JSON pointer of the value within the record
+	Keyword string `json:"keyword"` // Violated schema keyword, such as required or type
+	Message string `json:"message"`
+}
+
+// Change statuses of a result compared with the previous scrape of its URL
+const (
+	ChangeNew       = "new"
+	ChangeChanged   = "changed"
+	ChangeUnchanged = "unchanged"
+)
+
+// Change describes how a result differs from the previous scrape of the same
+// canonical URL
+type Change struct {
+	Status          string        `json:"status"`                     // new, changed or unchanged
+	CanonicalURL    string        `json:"canonical_url"`              // Key under which scrapes are compared
+	PreviousScraped *time.Time    `json:"previous_scraped,omitempty"` // When the compared scrape was made
+	PreviousHash    string        `json:"previous_hash,omitempty"`    // Content hash of the compared scrape
+	Fields          []FieldChange `json:"fields,omitempty"`           // Extracted values that differ
+	TextDiff        string        `json:"text_diff,omitempty"`        // Line diff of the main content
+	Text            string        `json:"text,omitempty"`             // Main content kept for the next comparison, when there is no article
+}
+
+// FieldChange is one extracted value that was added, removed or modified.
+// Paths use dots for object keys and [i] for list positions.
+type FieldChange struct {
+	Path     string      `json:"path"`
+	Previous interface{} `json:"previous,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+}
+
+// Asset is a file referenced by a scraped page. Downloaded assets are stored
+// by content hash, so Path is shared by every page using the same file.
+type Asset struct {
+	URL      string `json:"url"`
+	Kind     string `json:"kind"`                // image, stylesheet, script or document
+	Path     string `json:"path,omitempty"`      // Location in the content-addressed asset store
+	Size     int64  `json:"size,omitempty"`      // Size in bytes
+	MIMEType string `json:"mime_type,omitempty"` // From Content-Type, or sniffed when missing
+	SHA256   string `json:"sha256,omitempty"`    // Hex SHA-256 of the content
+	Error    string `json:"error,omitempty"`     // Why the download failed
 }
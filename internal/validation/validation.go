@@ -0,0 +1,166 @@
+// Package validation checks extracted records against a JSON Schema, so
+// selectors broken by a site redesign show up as data quality failures
+package validation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"arachne/internal/types"
+)
+
+// schemaURL is the location the schema is compiled under; references to
+// other documents are refused
+const schemaURL = "mem:///validation.json"
+
+// printer renders violation messages
+var printer = message.NewPrinter(language.English)
+
+// Schema is a compiled JSON Schema for the records of a job
+type Schema struct {
+	name   string
+	schema *jsonschema.Schema
+}
+
+// noLoader refuses external references, so a schema cannot read local files
+// or make requests
+type noLoader struct{}
+
+// Load implements jsonschema.URLLoader
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external schema references are not supported: %s", url)
+}
+
+// Compile parses and compiles a JSON Schema document
+func Compile(raw []byte) (*Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %v", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(noLoader{})
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %v", err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %v", err)
+	}
+	return &Schema{name: schemaName(doc, raw), schema: schema}, nil
+}
+
+// schemaName identifies a schema in results and metrics: its $id, else its
+// title, else a hash of the document
+func schemaName(doc any, raw []byte) string {
+	if object, ok := doc.(map[string]any); ok {
+		for _, key := range []string{"$id", "title"} {
+			if name, ok := object[key].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// Name returns the name the schema is reported under
+func (s *Schema) Name() string {
+	return s.name
+}
+
+// Validate checks every record, returning nil when there are none
+func (s *Schema) Validate(records []map[string]interface{}) *types.Validation {
+	if len(records) == 0 {
+		return nil
+	}
+
+	result := &types.Validation{Schema: s.name, Records: len(records)}
+	for i, record := range records {
+		violations, err := s.validateRecord(record)
+		if err != nil {
+			violations = []types.Violation{{Message: err.Error()}}
+		}
+		for j := range violations {
+			violations[j].Record = i
+		}
+		if len(violations) > 0 {
+			result.Failed++
+			result.Violations = append(result.Violations, violations...)
+		}
+	}
+	result.Valid = result.Failed == 0
+	return result
+}
+
+// validateRecord returns the violations of one record
+func (s *Schema) validateRecord(record map[string]interface{}) ([]types.Violation, error) {
+	// The validator expects values as decoded from JSON, not Go types
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %v", err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode record: %v", err)
+	}
+
+	err = s.schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	var violations []types.Violation
+	collect(validationErr, &violations)
+	return violations, nil
+}
+
+// collect adds the leaf errors of a validation error tree as violations
+func collect(err *jsonschema.ValidationError, violations *[]types.Violation) {
+	if len(err.Causes) > 0 {
+		for _, cause := range err.Causes {
+			collect(cause, violations)
+		}
+		return
+	}
+
+	keyword := strings.Join(err.ErrorKind.KeywordPath(), "/")
+	// Missing properties are reported where they should have been
+	if required, ok := err.ErrorKind.(*kind.Required); ok {
+		for _, property := range required.Missing {
+			*violations = append(*violations, types.Violation{
+				Path:    pointer(append(append([]string(nil), err.InstanceLocation...), property)),
+				Keyword: keyword,
+				Message: "missing required property",
+			})
+		}
+		return
+	}
+	*violations = append(*violations, types.Violation{
+		Path:    pointer(err.InstanceLocation),
+		Keyword: keyword,
+		Message: err.ErrorKind.LocalizedString(printer),
+	})
+}
+
+// pointer returns the JSON pointer of an instance location
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+const productSchema = `{
+	"title": "product",
+	"type": "object",
+	"required": ["name", "price"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"price": {"type": "number", "minimum": 0},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(productSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if schema.Name() != "product" {
+		t.Errorf("Name() = %q, want the schema title", schema.Name())
+	}
+
+	records := []map[string]interface{}{
+		{"name": "Kettle", "price": 25, "tags": []string{"kitchen"}},
+		{"price": -1, "tags": []interface{}{"a", 2}},
+	}
+	result := schema.Validate(records)
+	if result.Valid || result.Records != 2 || result.Failed != 1 {
+		t.Fatalf("Validate() = %+v, want the second record failing", result)
+	}
+
+	paths := make(map[string]string)
+	for _, violation := range result.Violations {
+		if violation.Record != 1 {
+			t.Errorf("violation %+v reported for record %d, want 1", violation, violation.Record)
+		}
+		paths[violation.Path] = violation.Keyword
+	}
+	expected := map[string]string{"/name": "required", "/price": "minimum", "/tags/1": "type"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("violated paths = %v, want %v", paths, expected)
+	}
+
+	if result := schema.Validate(nil); result != nil {
+		t.Errorf("Validate() without records = %+v, want nil", result)
+	}
+	if result := schema.Validate(records[:1]); !result.Valid || result.Violations != nil {
+		t.Errorf("Validate() of a valid record = %+v", result)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{"valid schema", productSchema, false},
+		{"not JSON", `{"type": `, true},
+		{"invalid keyword value", `{"type": "integer", "minimum": "zero"}`, true},
+		{"external reference", `{"$ref": "file:///etc/passwd"}`, true},
+		{"remote reference", `{"$ref": "https://example.com/schema.json"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Schemas without $id or title are named by content
+	schema, err := Compile([]byte(`{"type": "object"}`))
+	if err != nil || len(schema.Name()) != len("sha256:")+12 {
+		t.Errorf("Compile() = %v, name %q, want a hash name", err, schema.Name())
+	}
+}
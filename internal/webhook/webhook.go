@@ -0,0 +1,315 @@
+// Package webhook notifies job subscribers of lifecycle and record events
+// with signed HTTP callbacks
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"arachne/internal/config"
+)
+
+// Events a webhook can subscribe to. A job fails when it panics or when none
+// of its pages could be scraped. record.scraped events are sent once the
+// job's results are final, just before job.completed or job.failed.
+const (
+	JobStarted    = "job.started"
+	JobCompleted  = "job.completed"
+	JobFailed     = "job.failed"
+	RecordScraped = "record.scraped"
+)
+
+// Headers of a delivery; the signature is "sha256=" and the hex HMAC-SHA256
+// of the body with the shared secret
+const (
+	EventHeader     = "X-Arachne-Event"
+	DeliveryHeader  = "X-Arachne-Delivery"
+	SignatureHeader = "X-Arachne-Signature"
+)
+
+// maxBackoff caps the delay between delivery attempts
+const maxBackoff = time.Minute
+
+// validEvents lists the events a webhook can subscribe to
+var validEvents = map[string]bool{JobStarted: true, JobCompleted: true, JobFailed: true, RecordScraped: true}
+
+// defaultEvents are the events of a webhook subscribing to none explicitly;
+// per-record events are opt-in
+var defaultEvents = []string{JobStarted, JobCompleted, JobFailed}
+
+// Webhook is a URL notified of a job's events
+type Webhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"` // Job lifecycle events when empty
+}
+
+// Delivery records the outcome of sending one event to one webhook
+type Delivery struct {
+	ID          string    `json:"id"`
+	Event       string    `json:"event"`
+	URL         string    `json:"url"`
+	Delivered   bool      `json:"delivered"`
+	Attempts    int       `json:"attempts"`
+	StatusCode  int       `json:"status_code,omitempty"` // Response status of the last attempt
+	Error       string    `json:"error,omitempty"`       // Why the last attempt failed
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	ID        string      `json:"id"` // Delivery ID, also sent in the X-Arachne-Delivery header
+	Event     string      `json:"event"`
+	JobID     string      `json:"job_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Subscribes reports whether the webhook receives event
+func (w Webhook) Subscribes(event string) bool {
+	events := w.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the webhooks of a job request
+func Validate(hooks []Webhook) error {
+	for _, hook := range hooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL: %q", hook.URL)
+		}
+		for _, event := range hook.Events {
+			if !validEvents[event] {
+				return fmt.Errorf("invalid webhook event: %s, must be one of: %s, %s, %s, %s",
+					event, JobStarted, JobCompleted, JobFailed, RecordScraped)
+			}
+		}
+	}
+	return nil
+}
+
+// Sign returns the signature header value of body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body, for receivers
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatcher sends signed deliveries, retrying failed attempts with
+// exponential backoff
+type Dispatcher struct {
+	client  *http.Client
+	secret  string
+	retries int
+	backoff time.Duration
+}
+
+// NewDispatcher creates a dispatcher from the webhook settings
+func NewDispatcher(cfg *config.Config) *Dispatcher {
+	return &Dispatcher{
+		client:  &http.Client{Timeout: cfg.WebhookTimeout},
+		secret:  cfg.WebhookSecret,
+		retries: cfg.WebhookRetries,
+		backoff: cfg.WebhookBackoff,
+	}
+}
+
+// Enabled reports whether webhooks can be signed, which they must be
+func (d *Dispatcher) Enabled() bool {
+	return d.secret != ""
+}
+
+// Deliver sends one event to one webhook, retrying network errors, 429 and
+// 5xx responses up to the configured number of times
+func (d *Dispatcher) Deliver(hook Webhook, jobID, event string, data interface{}) Delivery {
+	delivery := Delivery{ID: uuid.New().String(), Event: event, URL: hook.URL, CreatedAt: time.Now()}
+	body, err := json.Marshal(Payload{ID: delivery.ID, Event: event, JobID: jobID, Timestamp: delivery.CreatedAt, Data: data})
+	if err != nil {
+		delivery.Error = fmt.Sprintf("failed to encode payload: %v", err)
+		delivery.CompletedAt = time.Now()
+		return delivery
+	}
+	signature := Sign(d.secret, body)
+
+	delay := d.backoff
+	for {
+		delivery.Attempts++
+		retry := d.attempt(&delivery, hook.URL, body, signature)
+		if delivery.Delivered || !retry || delivery.Attempts > d.retries {
+			break
+		}
+		time.Sleep(delay)
+		delay = min(delay*2, maxBackoff)
+	}
+	delivery.CompletedAt = time.Now()
+	return delivery
+}
+
+// attempt makes one delivery attempt, reporting whether a failure is worth retrying
+func (d *Dispatcher) attempt(delivery *Delivery, target string, body []byte, signature string) bool {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.StatusCode = 0
+		delivery.Error = err.Error()
+		return true
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Delivered = true
+		delivery.Error = ""
+		return false
+	}
+	delivery.Error = fmt.Sprintf("webhook returned %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// notification is one event queued for a job's webhooks
+type notification struct {
+	event string
+	data  interface{}
+}
+
+// Notifier delivers the events of one job in order, in the background
+type Notifier struct {
+	dispatcher *Dispatcher
+	jobID      string
+	hooks      []Webhook
+	record     func(Delivery)
+
+	mu      sync.Mutex // Guards pending and closed
+	pending []notification
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// ForJob starts a notifier for a job's webhooks; record is called with the
+// outcome of every delivery. Without webhooks, Notify does nothing.
+func (d *Dispatcher) ForJob(jobID string, hooks []Webhook, record func(Delivery)) *Notifier {
+	n := &Notifier{
+		dispatcher: d,
+		jobID:      jobID,
+		hooks:      hooks,
+		record:     record,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if len(hooks) == 0 {
+		close(n.done)
+		return n
+	}
+	go n.run()
+	return n
+}
+
+// Wants reports whether any webhook subscribes to event
+func (n *Notifier) Wants(event string) bool {
+	for _, hook := range n.hooks {
+		if hook.Subscribes(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify queues an event for the webhooks subscribing to it without waiting
+// for its delivery, so slow receivers do not hold up the job
+func (n *Notifier) Notify(event string, data interface{}) {
+	if !n.Wants(event) {
+		return
+	}
+	n.mu.Lock()
+	if !n.closed {
+		n.pending = append(n.pending, notification{event: event, data: data})
+	}
+	n.mu.Unlock()
+	n.signal()
+}
+
+// Close stops accepting events; queued events are still delivered
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+	n.signal()
+}
+
+// Done is closed once every queued event has been delivered or given up on
+func (n *Notifier) Done() <-chan struct{} {
+	return n.done
+}
+
+// signal wakes the delivery loop
+func (n *Notifier) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events one at a time, keeping their order
+func (n *Notifier) run() {
+	defer close(n.done)
+	for {
+		n.mu.Lock()
+		if len(n.pending) == 0 {
+			closed := n.closed
+			n.mu.Unlock()
+			if closed {
+				return
+			}
+			<-n.wake
+			continue
+		}
+		note := n.pending[0]
+		n.pending = n.pending[1:]
+		n.mu.Unlock()
+
+		for _, hook := range n.hooks {
+			if !hook.Subscribes(note.event) {
+				continue
+			}
+			delivery := n.dispatcher.Deliver(hook, n.jobID, note.event, note.data)
+			if n.record != nil {
+				n.record(delivery)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"arachne/internal/config"
+)
+
+func testDispatcher() *Dispatcher {
+	cfg := config.DefaultConfig()
+	cfg.WebhookSecret = "s3cret"
+	cfg.WebhookRetries = 2
+	cfg.WebhookBackoff = time.Millisecond
+	return NewDispatcher(cfg)
+}
+
+func TestDeliver(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("s3cret", body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Event != JobCompleted || payload.JobID != "job-1" {
+			t.Errorf("payload = %s, %v", body, err)
+		}
+		if payload.ID != r.Header.Get(DeliveryHeader) || r.Header.Get(EventHeader) != JobCompleted {
+			t.Errorf("headers = %v, want the delivery ID and event", r.Header)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	delivery := testDispatcher().Deliver(Webhook{URL: server.URL}, "job-1", JobCompleted, map[string]int{"results": 1})
+	if !delivery.Delivered || delivery.Attempts != 2 || delivery.StatusCode != http.StatusOK || delivery.Error != "" {
+		t.Errorf("Deliver() = %+v, want delivered on the retry", delivery)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"server errors are retried", http.StatusInternalServerError, 3},
+		{"rate limits are retried", http.StatusTooManyRequests, 3},
+		{"client errors are not", http.StatusGone, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			delivery := testDispatcher().Deliver(Webhook{URL: server.URL}, "job-1", JobFailed, nil)
+			if delivery.Delivered || delivery.Attempts != tt.attempts || delivery.StatusCode != tt.status || delivery.Error == "" {
+				t.Errorf("Deliver() = %+v, want %d failed attempts", delivery, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.URL.Path+" "+r.Header.Get(EventHeader))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	hooks := []Webhook{
+		{URL: server.URL + "/lifecycle"},
+		{URL: server.URL + "/records", Events: []string{RecordScraped}},
+	}
+	var deliveries []Delivery
+	notifier := testDispatcher().ForJob("job-1", hooks, func(delivery Delivery) {
+		deliveries = append(deliveries, delivery)
+	})
+	notifier.Notify(JobStarted, nil)
+	notifier.Notify(RecordScraped, map[string]string{"url": "https://example.com/"})
+	notifier.Notify(JobCompleted, nil)
+	notifier.Close()
+	notifier.Notify(JobFailed, nil) // Ignored once closed
+
+	select {
+	case <-notifier.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("deliveries did not finish in time")
+	}
+
+	expected := []string{"/lifecycle job.started", "/records record.scraped", "/lifecycle job.completed"}
+	if len(received) != len(expected) {
+		t.Fatalf("received %v, want %v", received, expected)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("received %v, want %v", received, expected)
+			break
+		}
+	}
+	if len(deliveries) != 3 || !deliveries[2].Delivered {
+		t.Errorf("recorded deliveries = %+v", deliveries)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   []Webhook
+		wantErr bool
+	}{
+		{"no webhooks", nil, false},
+		{"default events", []Webhook{{URL: "https://example.com/hook"}}, false},
+		{"record events", []Webhook{{URL: "http://example.com/hook", Events: []string{RecordScraped, JobFailed}}}, false},
+		{"relative URL", []Webhook{{URL: "/hook"}}, true},
+		{"unsupported scheme", []Webhook{{URL: "ftp://example.com/hook"}}, true},
+		{"unknown event", []Webhook{{URL: "https://example.com/hook", Events: []string{"job.paused"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hooks); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Asset kinds found on HTML pages
+const (
+	AssetImage      = "image"
+	AssetStylesheet = "stylesheet"
+	AssetScript     = "script"
+	AssetDocument   = "document" // Links to files with a configured extension, such as PDFs
+)
+
+// AssetKinds lists every asset kind
+var AssetKinds = []string{AssetImage, AssetStylesheet, AssetScript, AssetDocument}
+
+// AssetRef is an asset referenced by a page
+type AssetRef struct {
+	URL  string `json:"url"`
+	Kind string `json:"kind"`
+}
+
+// ValidateAssetKinds ensures every kind is a known asset kind
+func ValidateAssetKinds(kinds []string) error {
+	for _, kind := range kinds {
+		switch kind {
+		case AssetImage, AssetStylesheet, AssetScript, AssetDocument:
+		default:
+			return fmt.Errorf("invalid asset kind: %s, must be one of: %s", kind, strings.Join(AssetKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// ExtractAssets parses an HTML document and returns the assets it references
+func ExtractAssets(content, pageURL string, kinds, extensions []string) ([]AssetRef, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return ExtractAssetsFromDocument(doc, pageURL, kinds, extensions), nil
+}
+
+// ExtractAssetsFromDocument returns the images (src and srcset),
+// stylesheets, scripts and document links of a parsed page. Only the given
+// kinds are returned, all of them when kinds is empty. Document links are
+// anchors whose path ends in one of extensions, e.g. "pdf" or ".pdf". URLs are
+// resolved and de-duplicated; data: URIs are skipped.
+func ExtractAssetsFromDocument(doc *goquery.Document, pageURL string, kinds, extensions []string) []AssetRef {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	base := documentBase(doc, page)
+
+	wanted := make(map[string]bool)
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+	include := func(kind string) bool { return len(kinds) == 0 || wanted[kind] }
+
+	assets := make([]AssetRef, 0)
+	seen := make(map[string]bool)
+	add := func(ref, kind string) {
+		parsed, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil || ref == "" {
+			return
+		}
+		target := base.ResolveReference(parsed)
+		if target.Scheme != "http" && target.Scheme != "https" {
+			return
+		}
+		target.Fragment = ""
+		target.RawFragment = ""
+		if key := target.String(); !seen[key] {
+			seen[key] = true
+			assets = append(assets, AssetRef{URL: key, Kind: kind})
+		}
+	}
+
+	if include(AssetImage) {
+		doc.Find("img[src], img[srcset], picture source[srcset]").Each(func(_ int, s *goquery.Selection) {
+			add(s.AttrOr("src", ""), AssetImage)
+			for _, candidate := range srcsetURLs(s.AttrOr("srcset", "")) {
+				add(candidate, AssetImage)
+			}
+		})
+	}
+	if include(AssetStylesheet) {
+		doc.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+			for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+				if rel == "stylesheet" {
+					add(s.AttrOr("href", ""), AssetStylesheet)
+					return
+				}
+			}
+		})
+	}
+	if include(AssetScript) {
+		doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+			add(s.AttrOr("src", ""), AssetScript)
+		})
+	}
+	if include(AssetDocument) && len(extensions) > 0 {
+		doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			href := s.AttrOr("href", "")
+			parsed, err := url.Parse(strings.TrimSpace(href))
+			if err != nil {
+				return
+			}
+			ext := strings.ToLower(path.Ext(parsed.Path))
+			for _, wantedExt := range extensions {
+				if ext != "" && ext == "."+strings.TrimPrefix(strings.ToLower(wantedExt), ".") {
+					add(href, AssetDocument)
+					return
+				}
+			}
+		})
+	}
+	return assets
+}
+
+// srcsetURLs returns the candidate URLs of a srcset attribute, such as
+// "small.jpg 480w, large.jpg 1080w"
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
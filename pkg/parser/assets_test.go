@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+const assetPage = `<html><head>
+<link rel="stylesheet" href="/css/site.css">
+<link rel="preload stylesheet" href="/css/site.css">
+<link rel="icon" href="/favicon.ico">
+<script src="app.js"></script>
+<script>inline()</script>
+</head><body>
+<img src="/img/a.jpg" srcset="/img/a-480.jpg 480w, /img/a-1080.jpg 1080w">
+<img src="data:image/png;base64,AAAA">
+<picture><source srcset="/img/b.webp"></picture>
+<a href="/files/Manual.PDF#page=2">Manual</a>
+<a href="/files/sheet.xlsx">Sheet</a>
+<a href="/about">About</a>
+</body></html>`
+
+func TestExtractAssets(t *testing.T) {
+	assets, err := ExtractAssets(assetPage, "https://example.com/products/", nil, []string{"pdf"})
+	if err != nil {
+		t.Fatalf("ExtractAssets() error = %v", err)
+	}
+
+	expected := []AssetRef{
+		{URL: "https://example.com/img/a.jpg", Kind: AssetImage},
+		{URL: "https://example.com/img/a-480.jpg", Kind: AssetImage},
+		{URL: "https://example.com/img/a-1080.jpg", Kind: AssetImage},
+		{URL: "https://example.com/img/b.webp", Kind: AssetImage},
+		{URL: "https://example.com/css/site.css", Kind: AssetStylesheet},
+		{URL: "https://example.com/products/app.js", Kind: AssetScript},
+		{URL: "https://example.com/files/Manual.PDF", Kind: AssetDocument},
+	}
+	if !reflect.DeepEqual(assets, expected) {
+		t.Errorf("ExtractAssets() =\n%+v\nwant\n%+v", assets, expected)
+	}
+}
+
+func TestExtractAssetsKinds(t *testing.T) {
+	assets, err := ExtractAssets(assetPage, "https://example.com/", []string{AssetDocument}, []string{".pdf", ".xlsx"})
+	if err != nil {
+		t.Fatalf("ExtractAssets() error = %v", err)
+	}
+	if len(assets) != 2 || assets[0].Kind != AssetDocument || assets[1].URL != "https://example.com/files/sheet.xlsx" {
+		t.Errorf("ExtractAssets() = %+v, want the two documents", assets)
+	}
+
+	if err := ValidateAssetKinds([]string{AssetImage, "video"}); err == nil {
+		t.Error("ValidateAssetKinds() accepted an unknown kind")
+	}
+}
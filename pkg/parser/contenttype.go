@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"arachne/internal/types"
+)
+
+// Content categories, coarse enough for plugins and storage to branch on
+const (
+	CategoryHTML   = "html"
+	CategoryJSON   = "json"
+	CategoryXML    = "xml"
+	CategoryFeed   = "feed"
+	CategoryImage  = "image"
+	CategoryPDF    = "pdf"
+	CategoryText   = "text"
+	CategoryBinary = "binary"
+)
+
+// ContentInfo describes the type of a response, combining the declared
+// Content-Type header with what the body looks like
+type ContentInfo = types.ContentInfo
+
+// genericTypes are declared types that say nothing about the content
+var genericTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/unknown":      true,
+	"text/plain":               true,
+}
+
+var (
+	metaCharsetRegex = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w.:-]+)`)
+	xmlEncodingRegex = regexp.MustCompile(`^<\?xml[^>]+encoding\s*=\s*["']([\w.:-]+)["']`)
+)
+
+// DetectContentType determines the type of body, given the value of its
+// Content-Type header. It returns nil when there is neither a header nor a body.
+func DetectContentType(body, contentType string) *ContentInfo {
+	declared, params, _ := mime.ParseMediaType(contentType)
+	declared = strings.ToLower(declared)
+	if declared == "" && body == "" {
+		return nil
+	}
+
+	info := &ContentInfo{
+		Declared: declared,
+		Sniffed:  SniffContentType(body),
+		Charset:  strings.ToLower(params["charset"]),
+	}
+
+	declaredCategory := ContentCategory(declared)
+	sniffedCategory := ContentCategory(info.Sniffed)
+	switch {
+	case genericTypes[declared]:
+		info.MIMEType = info.Sniffed
+	case conclusive(info.Sniffed) && !compatible(declaredCategory, sniffedCategory):
+		info.MIMEType = info.Sniffed
+		info.Mismatch = true
+	case sniffedCategory == CategoryFeed:
+		// Feeds are often served as plain XML or JSON
+		info.MIMEType = info.Sniffed
+	default:
+		info.MIMEType = declared
+	}
+	if info.MIMEType == "" {
+		info.MIMEType = "application/octet-stream"
+	}
+	info.Category = ContentCategory(info.MIMEType)
+
+	if info.Charset == "" {
+		info.Charset = sniffCharset(body, info.MIMEType)
+	}
+	return info
+}
+
+// SniffContentType detects the media type of body from magic bytes and
+// HTML, JSON, XML and feed heuristics. It returns "" for an empty body.
+func SniffContentType(body string) string {
+	if body == "" {
+		return ""
+	}
+	trimmed := strings.TrimSpace(strings.TrimPrefix(body, "\ufeff"))
+
+	switch {
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		if json.Valid([]byte(trimmed)) {
+			if IsFeed(trimmed, "") {
+				return "application/feed+json"
+			}
+			return "application/json"
+		}
+	case strings.HasPrefix(trimmed, "<"):
+		if IsHTML(trimmed, "") {
+			return "text/html"
+		}
+		switch xmlRootName(trimmed) {
+		case "rss":
+			return "application/rss+xml"
+		case "feed":
+			return "application/atom+xml"
+		case "svg":
+			return "image/svg+xml"
+		case "html":
+			return "application/xhtml+xml"
+		}
+		if IsXML(trimmed, "") {
+			return "application/xml"
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType([]byte(body)))
+	return mediaType
+}
+
+// ContentCategory maps a media type to its coarse category
+func ContentCategory(mediaType string) string {
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return CategoryHTML
+	case mediaType == "application/rss+xml" || mediaType == "application/atom+xml" ||
+		mediaType == "application/feed+json" || mediaType == "application/rdf+xml":
+		return CategoryFeed
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return CategoryJSON
+	case strings.HasPrefix(mediaType, "image/"):
+		return CategoryImage
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return CategoryXML
+	case mediaType == "application/pdf":
+		return CategoryPDF
+	case strings.HasPrefix(mediaType, "text/"):
+		return CategoryText
+	default:
+		return CategoryBinary
+	}
+}
+
+// conclusive reports whether a sniffed type is specific enough to overrule
+// the declared one; plain text and unknown bytes are not
+func conclusive(sniffed string) bool {
+	return !genericTypes[sniffed] && !strings.HasPrefix(sniffed, "text/plain")
+}
+
+// compatible reports whether content of the sniffed category may be served
+// under the declared one
+func compatible(declared, sniffed string) bool {
+	switch {
+	case declared == sniffed:
+		return true
+	case sniffed == CategoryFeed:
+		return declared == CategoryXML || declared == CategoryJSON
+	case declared == CategoryFeed:
+		// Feed formats the heuristics do not recognise, such as RSS 1.0
+		return sniffed == CategoryXML || sniffed == CategoryJSON
+	}
+	return false
+}
+
+// sniffCharset finds the character set of body from a byte order mark, an
+// HTML meta tag or an XML declaration
+func sniffCharset(body, mediaType string) string {
+	switch {
+	case strings.HasPrefix(body, "\ufeff"):
+		return "utf-8"
+	case strings.HasPrefix(body, "\xfe\xff"):
+		return "utf-16be"
+	case strings.HasPrefix(body, "\xff\xfe"):
+		return "utf-16le"
+	}
+
+	if strings.HasSuffix(mediaType, "json") {
+		return "utf-8" // JSON text must be UTF-8
+	}
+	head := body[:min(len(body), 1024)]
+	switch ContentCategory(mediaType) {
+	case CategoryHTML:
+		if match := metaCharsetRegex.FindStringSubmatch(head); match != nil {
+			return strings.ToLower(match[1])
+		}
+	case CategoryXML, CategoryFeed:
+		if match := xmlEncodingRegex.FindStringSubmatch(strings.TrimSpace(head)); match != nil {
+			return strings.ToLower(match[1])
+		}
+		if strings.HasPrefix(strings.TrimSpace(head), "<?xml") {
+			return "utf-8" // The XML default
+		}
+	}
+	return ""
+}
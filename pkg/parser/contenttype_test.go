@@ -0,0 +1,50 @@
+package parser
+
+import "testing"
+
+func TestDetectContentType(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	rss := `<?xml version="1.0" encoding="ISO-8859-1"?><rss version="2.0"><channel></channel></rss>`
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		mimeType    string
+		charset     string
+		category    string
+		mismatch    bool
+	}{
+		{"declared HTML", "<!DOCTYPE html><html></html>", "text/html; charset=UTF-8", "text/html", "utf-8", CategoryHTML, false},
+		{"meta charset", `<html><head><meta charset="windows-1252"></head></html>`, "", "text/html", "windows-1252", CategoryHTML, false},
+		{"JSON without header", `{"a": [1, 2]}`, "", "application/json", "utf-8", CategoryJSON, false},
+		{"JSON as plain text", `[1, 2]`, "text/plain", "application/json", "utf-8", CategoryJSON, false},
+		{"HTML error page as JSON", "<html><body>Bad gateway</body></html>", "application/json", "text/html", "", CategoryHTML, true},
+		{"feed served as XML", rss, "text/xml", "application/rss+xml", "iso-8859-1", CategoryFeed, false},
+		{"JSON feed", `{"version": "https://jsonfeed.org/version/1.1", "items": []}`, "application/json", "application/feed+json", "utf-8", CategoryFeed, false},
+		{"XML document", `<?xml version="1.0"?><catalog/>`, "", "application/xml", "utf-8", CategoryXML, false},
+		{"image", png, "image/png", "image/png", "", CategoryImage, false},
+		{"image served as HTML", png, "text/html", "image/png", "", CategoryImage, true},
+		{"PDF", "%PDF-1.7\n", "application/octet-stream", "application/pdf", "", CategoryPDF, false},
+		{"unknown bytes", "\x00\x01\x02\x03", "", "application/octet-stream", "", CategoryBinary, false},
+		{"plain text under a specific type", "hello", "text/csv", "text/csv", "", CategoryText, false},
+		{"header only", "", "text/html", "text/html", "", CategoryHTML, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := DetectContentType(tt.body, tt.contentType)
+			if info == nil {
+				t.Fatal("DetectContentType() = nil")
+			}
+			if info.MIMEType != tt.mimeType || info.Charset != tt.charset || info.Category != tt.category || info.Mismatch != tt.mismatch {
+				t.Errorf("DetectContentType() = %+v, want %s, charset %q, %s, mismatch %v",
+					info, tt.mimeType, tt.charset, tt.category, tt.mismatch)
+			}
+		})
+	}
+
+	if info := DetectContentType("", ""); info != nil {
+		t.Errorf("DetectContentType() with nothing to detect = %+v, want nil", info)
+	}
+}
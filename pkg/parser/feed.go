@@ -0,0 +1,349 @@
+package parser
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"arachne/internal/types"
+)
+
+// Feed formats recognised by ParseFeed
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatJSON = "json"
+)
+
+// Feed is a parsed RSS, Atom or JSON feed
+type Feed struct {
+	Format string     `json:"format"`
+	Title  string     `json:"title"`
+	Link   string     `json:"link,omitempty"`
+	Items  []FeedItem `json:"items"`
+}
+
+// FeedItem is a single feed entry
+type FeedItem = types.FeedItem
+
+// Links returns the entry links of the feed, skipping empty ones
+func (f *Feed) Links() []string {
+	links := make([]string, 0, len(f.Items))
+	for _, item := range f.Items {
+		if item.Link != "" {
+			links = append(links, item.Link)
+		}
+	}
+	return links
+}
+
+// feedContentTypes are content types that always denote a feed
+var feedContentTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/feed+json",
+}
+
+// IsFeed reports whether a response is an RSS, Atom or JSON feed, based on
+// its content type or, for generic XML/JSON types, on its root element
+func IsFeed(content, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, feedType := range feedContentTypes {
+		if strings.Contains(contentType, feedType) {
+			return true
+		}
+	}
+
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") {
+		return strings.Contains(trimmed[:min(len(trimmed), 512)], "jsonfeed.org/version")
+	}
+	if strings.HasPrefix(trimmed, "<") {
+		root := xmlRootName(trimmed)
+		return root == "rss" || root == "feed"
+	}
+	return false
+}
+
+// xmlRootName returns the local name of the first element in an XML document
+func xmlRootName(content string) string {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	// Element names are ASCII in practice, whatever the declared encoding
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// ParseFeed parses an RSS 2.0, Atom 1.0 or JSON Feed document. Relative entry
+// links are resolved against baseURL.
+func ParseFeed(content, baseURL string) (*Feed, error) {
+	trimmed := strings.TrimSpace(content)
+
+	var feed *Feed
+	var err error
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		feed, err = parseJSONFeed(trimmed)
+	case xmlRootName(trimmed) == "rss":
+		feed, err = parseRSS(trimmed)
+	case xmlRootName(trimmed) == "feed":
+		feed, err = parseAtom(trimmed)
+	default:
+		return nil, fmt.Errorf("not a recognised feed document")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if base, err := url.Parse(baseURL); err == nil {
+		feed.Link = resolveURL(base, feed.Link)
+		for i := range feed.Items {
+			feed.Items[i].Link = resolveURL(base, feed.Items[i].Link)
+		}
+	}
+
+	return feed, nil
+}
+
+// resolveURL makes ref absolute against base, leaving it untouched on error
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// rssDocument mirrors the parts of RSS 2.0 we use
+type rssDocument struct {
+	Channel struct {
+		Title string   `xml:"title"`
+		Links []string `xml:"link"` // Also matches empty <atom:link> self references
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Author      string `xml:"author"`
+			Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// parseRSS parses an RSS 2.0 document
+func parseRSS(content string) (*Feed, error) {
+	var doc rssDocument
+	if err := unmarshalXML(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS feed: %v", err)
+	}
+
+	feed := &Feed{
+		Format: FeedFormatRSS,
+		Title:  strings.TrimSpace(doc.Channel.Title),
+		Link:   strings.TrimSpace(firstNonEmpty(doc.Channel.Links...)),
+		Items:  make([]FeedItem, 0, len(doc.Channel.Items)),
+	}
+
+	for _, entry := range doc.Channel.Items {
+		item := FeedItem{
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      strings.TrimSpace(entry.Link),
+			Published: parseFeedDate(entry.PubDate),
+			Author:    strings.TrimSpace(firstNonEmpty(entry.Creator, entry.Author)),
+			Summary:   strings.TrimSpace(entry.Description),
+		}
+		if item.Link == "" && strings.HasPrefix(entry.GUID, "http") {
+			item.Link = strings.TrimSpace(entry.GUID)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
+
+// atomLink is an Atom <link> element
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// atomDocument mirrors the parts of Atom 1.0 we use
+type atomDocument struct {
+	Title   string     `xml:"title"`
+	Links   []atomLink `xml:"link"`
+	Entries []struct {
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Summary string `xml:"summary"`
+		Content string `xml:"content"`
+	} `xml:"entry"`
+}
+
+// alternateLink picks the rel="alternate" link, which Atom treats as the default
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// parseAtom parses an Atom 1.0 document
+func parseAtom(content string) (*Feed, error) {
+	var doc atomDocument
+	if err := unmarshalXML(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse Atom feed: %v", err)
+	}
+
+	feed := &Feed{
+		Format: FeedFormatAtom,
+		Title:  strings.TrimSpace(doc.Title),
+		Link:   alternateLink(doc.Links),
+		Items:  make([]FeedItem, 0, len(doc.Entries)),
+	}
+
+	for _, entry := range doc.Entries {
+		item := FeedItem{
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      alternateLink(entry.Links),
+			Published: parseFeedDate(firstNonEmpty(entry.Published, entry.Updated)),
+			Summary:   strings.TrimSpace(firstNonEmpty(entry.Summary, entry.Content)),
+		}
+		if len(entry.Authors) > 0 {
+			item.Author = strings.TrimSpace(entry.Authors[0].Name)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
+
+// jsonFeedAuthor is a JSON Feed author object
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonFeedDocument mirrors JSON Feed 1.0 and 1.1
+type jsonFeedDocument struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	Items       []struct {
+		ID            string           `json:"id"`
+		URL           string           `json:"url"`
+		Title         string           `json:"title"`
+		Summary       string           `json:"summary"`
+		ContentText   string           `json:"content_text"`
+		DatePublished string           `json:"date_published"`
+		Author        *jsonFeedAuthor  `json:"author"`  // JSON Feed 1.0
+		Authors       []jsonFeedAuthor `json:"authors"` // JSON Feed 1.1
+	} `json:"items"`
+}
+
+// parseJSONFeed parses a JSON Feed document
+func parseJSONFeed(content string) (*Feed, error) {
+	var doc jsonFeedDocument
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON feed: %v", err)
+	}
+	if !strings.Contains(doc.Version, "jsonfeed.org") {
+		return nil, fmt.Errorf("not a JSON feed: missing version")
+	}
+
+	feed := &Feed{
+		Format: FeedFormatJSON,
+		Title:  strings.TrimSpace(doc.Title),
+		Link:   doc.HomePageURL,
+		Items:  make([]FeedItem, 0, len(doc.Items)),
+	}
+
+	for _, entry := range doc.Items {
+		item := FeedItem{
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      entry.URL,
+			Published: parseFeedDate(entry.DatePublished),
+			Summary:   strings.TrimSpace(firstNonEmpty(entry.Summary, entry.ContentText)),
+		}
+		if len(entry.Authors) > 0 {
+			item.Author = entry.Authors[0].Name
+		} else if entry.Author != nil {
+			item.Author = entry.Author.Name
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
+
+// unmarshalXML decodes XML leniently, as real-world feeds are often sloppy
+func unmarshalXML(content string, v interface{}) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder.Decode(v)
+}
+
+// feedDateLayouts are the date formats seen in RSS and Atom feeds
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedDate parses a feed timestamp, returning nil when it is missing or unknown
+func parseFeedDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range feedDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-blank value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"testing"
+)
+
+const rssFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Statistics Releases</title>
+    <link>https://stats.example/</link>
+    <atom:link href="https://stats.example/feed.xml" rel="self"/>
+    <item>
+      <title>Quarterly GDP</title>
+      <link>/releases/gdp-q2</link>
+      <pubDate>Tue, 01 Jul 2025 09:30:00 +0000</pubDate>
+      <dc:creator>Office of Statistics</dc:creator>
+      <description>GDP grew by 0.3%.</description>
+    </item>
+    <item>
+      <title>Inflation</title>
+      <guid>https://stats.example/releases/cpi</guid>
+    </item>
+  </channel>
+</rss>`
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Engineering Blog</title>
+  <link href="https://blog.example/"/>
+  <entry>
+    <title>Scaling scrapers</title>
+    <link rel="alternate" href="https://blog.example/posts/scaling"/>
+    <link rel="edit" href="https://blog.example/api/posts/1"/>
+    <published>2025-06-30T12:00:00Z</published>
+    <author><name>Sam</name></author>
+    <summary>How we scaled.</summary>
+  </entry>
+</feed>`
+
+const jsonFeedFixture = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Podcast",
+  "items": [
+    {"id": "1", "url": "https://pod.example/1", "title": "Episode 1",
+     "date_published": "2025-05-01T08:00:00Z", "authors": [{"name": "Alex"}], "summary": "Pilot"}
+  ]
+}`
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedTitle string
+		expectedItems []FeedItem
+	}{
+		{
+			name:          "RSS 2.0",
+			content:       rssFixture,
+			expectedTitle: "Statistics Releases",
+			expectedItems: []FeedItem{
+				{Title: "Quarterly GDP", Link: "https://stats.example/releases/gdp-q2", Author: "Office of Statistics", Summary: "GDP grew by 0.3%."},
+				{Title: "Inflation", Link: "https://stats.example/releases/cpi"},
+			},
+		},
+		{
+			name:          "Atom 1.0",
+			content:       atomFixture,
+			expectedTitle: "Engineering Blog",
+			expectedItems: []FeedItem{
+				{Title: "Scaling scrapers", Link: "https://blog.example/posts/scaling", Author: "Sam", Summary: "How we scaled."},
+			},
+		},
+		{
+			name:          "JSON Feed",
+			content:       jsonFeedFixture,
+			expectedTitle: "Podcast",
+			expectedItems: []FeedItem{
+				{Title: "Episode 1", Link: "https://pod.example/1", Author: "Alex", Summary: "Pilot"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := ParseFeed(tt.content, "https://stats.example/feed.xml")
+			if err != nil {
+				t.Fatalf("ParseFeed() error = %v", err)
+			}
+			if feed.Title != tt.expectedTitle {
+				t.Errorf("ParseFeed() title = %q, want %q", feed.Title, tt.expectedTitle)
+			}
+			if len(feed.Items) != len(tt.expectedItems) {
+				t.Fatalf("ParseFeed() returned %d items, want %d", len(feed.Items), len(tt.expectedItems))
+			}
+			for i, want := range tt.expectedItems {
+				got := feed.Items[i]
+				if got.Title != want.Title || got.Link != want.Link || got.Author != want.Author || got.Summary != want.Summary {
+					t.Errorf("item %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if feed.Items[0].Published == nil {
+				t.Errorf("expected first item to have a published date")
+			}
+		})
+	}
+}
+
+func TestIsFeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		expected    bool
+	}{
+		{name: "RSS content type", content: "", contentType: "application/rss+xml; charset=utf-8", expected: true},
+		{name: "Atom sniffed from XML", content: atomFixture, contentType: "application/xml", expected: true},
+		{name: "JSON Feed sniffed", content: jsonFeedFixture, contentType: "application/json", expected: true},
+		{name: "Plain JSON", content: `{"title": "x"}`, contentType: "application/json", expected: false},
+		{name: "HTML", content: `<html><head><title>x</title></head></html>`, contentType: "text/html", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFeed(tt.content, tt.contentType); got != tt.expected {
+				t.Errorf("IsFeed() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"arachne/internal/types"
+)
+
+// Link is an outgoing link found on a page
+type Link = types.Link
+
+// ExtractLinks parses an HTML document and returns its outgoing links
+func ExtractLinks(content, pageURL string) ([]Link, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return ExtractLinksFromDocument(doc, pageURL), nil
+}
+
+// ExtractLinksFromDocument returns the outgoing links of a parsed page.
+// Links are resolved against pageURL, or the page's <base href>, and
+// de-duplicated by URL without fragment, keeping document order. In-page
+// anchors and non-HTTP links such as mailto: and javascript: are skipped.
+func ExtractLinksFromDocument(doc *goquery.Document, pageURL string) []Link {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	base := documentBase(doc, page)
+
+	links := make([]Link, 0)
+	seen := make(map[string]int)
+	doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		ref, err := url.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || (ref.Scheme == "" && ref.Host == "" && ref.Path == "" && ref.RawQuery == "") {
+			return // In-page anchors are not outlinks
+		}
+		target := base.ResolveReference(ref)
+		if target.Scheme != "http" && target.Scheme != "https" {
+			return
+		}
+		target.Fragment = ""
+		target.RawFragment = ""
+
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text == "" {
+			// Image links carry their text in the alt attribute
+			text = strings.TrimSpace(firstNonEmpty(s.AttrOr("title", ""), s.Find("img[alt]").AttrOr("alt", ""), s.AttrOr("alt", "")))
+		}
+
+		key := target.String()
+		if index, exists := seen[key]; exists {
+			if links[index].Text == "" {
+				links[index].Text = text
+			}
+			return
+		}
+
+		seen[key] = len(links)
+		links = append(links, Link{
+			URL:      key,
+			Text:     text,
+			Rel:      strings.Fields(strings.ToLower(s.AttrOr("rel", ""))),
+			Internal: sameSite(page.Hostname(), target.Hostname()),
+		})
+	})
+	return links
+}
+
+// documentBase returns the URL relative links resolve against: the page's
+// <base href> when present, otherwise the page URL
+func documentBase(doc *goquery.Document, page *url.URL) *url.URL {
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if parsed, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			return page.ResolveReference(parsed)
+		}
+	}
+	return page
+}
+
+// sameSite compares hosts case-insensitively, treating www.example.com and
+// example.com as the same site
+func sameSite(a, b string) bool {
+	a = strings.TrimPrefix(strings.ToLower(a), "www.")
+	b = strings.TrimPrefix(strings.ToLower(b), "www.")
+	return a == b
+}
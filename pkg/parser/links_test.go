@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	html := `<html><body>
+<a href="/about">About  us</a>
+<a href="/about#team">Team</a>
+<a href="https://www.example.com/blog" rel="ugc">Blog</a>
+<a href="https://partner.example/offer?id=1" rel="Sponsored nofollow">Great deal</a>
+<a href="contact.html"><img src="/mail.png" alt="Contact"></a>
+<a href="mailto:hi@example.com">Mail</a>
+<a href="javascript:void(0)">Menu</a>
+<a href="#top">Top</a>
+</body></html>`
+
+	links, err := ExtractLinks(html, "https://example.com/company/")
+	if err != nil {
+		t.Fatalf("ExtractLinks() error = %v", err)
+	}
+
+	expected := []Link{
+		{URL: "https://example.com/about", Text: "About us", Rel: []string{}, Internal: true},
+		{URL: "https://www.example.com/blog", Text: "Blog", Rel: []string{"ugc"}, Internal: true},
+		{URL: "https://partner.example/offer?id=1", Text: "Great deal", Rel: []string{"sponsored", "nofollow"}, Internal: false},
+		{URL: "https://example.com/company/contact.html", Text: "Contact", Rel: []string{}, Internal: true},
+	}
+
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("ExtractLinks() =\n%+v\nwant\n%+v", links, expected)
+	}
+	if !links[2].HasRel("nofollow") || links[1].HasRel("nofollow") {
+		t.Errorf("HasRel() does not reflect rel values")
+	}
+}
+
+func TestExtractLinksBaseHref(t *testing.T) {
+	html := `<html><head><base href="https://cdn.example/docs/"></head><body><a href="guide">Guide</a></body></html>`
+
+	links, err := ExtractLinks(html, "https://example.com/")
+	if err != nil {
+		t.Fatalf("ExtractLinks() error = %v", err)
+	}
+	if len(links) != 1 || links[0].URL != "https://cdn.example/docs/guide" || links[0].Internal {
+		t.Errorf("ExtractLinks() = %+v, want link resolved against <base href>", links)
+	}
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"arachne/internal/types"
+)
+
+// Metadata is the document-level metadata of an HTML page
+type Metadata = types.Metadata
+
+// Alternate is a localized version of a page declared with hreflang
+type Alternate = types.Alternate
+
+// IsHTML reports whether a response should be parsed as an HTML document
+func IsHTML(content, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "html") {
+		return true
+	}
+	if contentType != "" && !strings.HasPrefix(contentType, "text/plain") {
+		return false
+	}
+	trimmed := strings.ToLower(strings.TrimSpace(content))
+	return strings.HasPrefix(trimmed, "<!doctype html") || strings.HasPrefix(trimmed, "<html")
+}
+
+// ExtractMetadata parses an HTML document and returns its metadata.
+// Relative URLs are resolved against baseURL.
+func ExtractMetadata(html, baseURL string) (*Metadata, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return ExtractMetadataFromDocument(doc, baseURL), nil
+}
+
+// ExtractMetadataFromDocument extracts metadata from an already parsed document
+func ExtractMetadataFromDocument(doc *goquery.Document, baseURL string) *Metadata {
+	base, _ := url.Parse(baseURL)
+	resolve := func(ref string) string {
+		if base == nil {
+			return strings.TrimSpace(ref)
+		}
+		return resolveURL(base, ref)
+	}
+
+	meta := &Metadata{
+		Title:       documentTitle(doc),
+		OpenGraph:   make(map[string]string),
+		TwitterCard: make(map[string]string),
+	}
+
+	meta.Language, _ = doc.Find("html").First().Attr("lang")
+	meta.Language = strings.TrimSpace(meta.Language)
+
+	doc.Find("meta").Each(func(_ int, s *goquery.Selection) {
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		property := strings.ToLower(strings.TrimSpace(s.AttrOr("property", "")))
+		httpEquiv := strings.ToLower(strings.TrimSpace(s.AttrOr("http-equiv", "")))
+
+		switch {
+		case name == "description" && meta.Description == "":
+			meta.Description = content
+		case httpEquiv == "content-language" && meta.Language == "":
+			meta.Language = content
+		case strings.HasPrefix(property, "og:"):
+			setFirst(meta.OpenGraph, strings.TrimPrefix(property, "og:"), content)
+		case strings.HasPrefix(name, "twitter:"):
+			setFirst(meta.TwitterCard, strings.TrimPrefix(name, "twitter:"), content)
+		case strings.HasPrefix(property, "twitter:"):
+			// Some sites use property instead of name for Twitter Cards
+			setFirst(meta.TwitterCard, strings.TrimPrefix(property, "twitter:"), content)
+		}
+	})
+
+	doc.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		rels := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+
+		for _, rel := range rels {
+			switch rel {
+			case "canonical":
+				if meta.Canonical == "" {
+					meta.Canonical = resolve(href)
+				}
+			case "icon":
+				if meta.Favicon == "" {
+					meta.Favicon = resolve(href)
+				}
+			case "alternate":
+				if lang, ok := s.Attr("hreflang"); ok && lang != "" {
+					meta.Alternates = append(meta.Alternates, Alternate{
+						Hreflang: strings.TrimSpace(lang),
+						URL:      resolve(href),
+					})
+				}
+			}
+		}
+	})
+
+	if len(meta.OpenGraph) == 0 {
+		meta.OpenGraph = nil
+	}
+	if len(meta.TwitterCard) == 0 {
+		meta.TwitterCard = nil
+	}
+	return meta
+}
+
+// setFirst stores value under key unless the key is already set, so the first
+// declaration in the document wins
+func setFirst(values map[string]string, key, value string) {
+	if key == "" || value == "" {
+		return
+	}
+	if _, exists := values[key]; !exists {
+		values[key] = value
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	html := `<!DOCTYPE html>
+<html lang="en-GB">
+<head>
+  <title lang="en"> Weekly Report </title>
+  <meta name="Description" content="Numbers for the week">
+  <meta name="description" content="Ignored duplicate">
+  <meta property="og:title" content="Weekly Report | Example">
+  <meta property="og:image" content="https://cdn.example/cover.png">
+  <meta name="twitter:card" content="summary_large_image">
+  <meta property="twitter:site" content="@example">
+  <link rel="canonical" href="/reports/weekly">
+  <link rel="shortcut icon" href="/static/favicon.ico">
+  <link rel="alternate" hreflang="de" href="https://example.com/de/reports/weekly">
+  <link rel="alternate" hreflang="x-default" href="/reports/weekly">
+  <link rel="alternate" type="application/rss+xml" href="/feed.xml">
+</head>
+<body><svg><title>Chart</title></svg></body>
+</html>`
+
+	meta, err := ExtractMetadata(html, "https://example.com/reports/weekly?ref=home")
+	if err != nil {
+		t.Fatalf("ExtractMetadata() error = %v", err)
+	}
+
+	expected := &Metadata{
+		Title:       "Weekly Report",
+		Description: "Numbers for the week",
+		Canonical:   "https://example.com/reports/weekly",
+		Language:    "en-GB",
+		OpenGraph: map[string]string{
+			"title": "Weekly Report | Example",
+			"image": "https://cdn.example/cover.png",
+		},
+		TwitterCard: map[string]string{
+			"card": "summary_large_image",
+			"site": "@example",
+		},
+		Favicon: "https://example.com/static/favicon.ico",
+		Alternates: []Alternate{
+			{Hreflang: "de", URL: "https://example.com/de/reports/weekly"},
+			{Hreflang: "x-default", URL: "https://example.com/reports/weekly"},
+		},
+	}
+
+	if !reflect.DeepEqual(meta, expected) {
+		t.Errorf("ExtractMetadata() = %+v, want %+v", meta, expected)
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		expected    bool
+	}{
+		{name: "HTML content type", content: "", contentType: "text/html; charset=utf-8", expected: true},
+		{name: "XHTML content type", content: "", contentType: "application/xhtml+xml", expected: true},
+		{name: "JSON", content: `{"a": 1}`, contentType: "application/json", expected: false},
+		{name: "Sniffed without content type", content: "  <!DOCTYPE html><html></html>", contentType: "", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHTML(tt.content, tt.contentType); got != tt.expected {
+				t.Errorf("IsHTML() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
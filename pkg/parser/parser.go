@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
-// ExtractTitle extracts title from HTML or JSON responses
+// ExtractTitle extracts title from HTML, XML or JSON responses
 func ExtractTitle(content, contentType string) string {
 	// Check if it's JSON based on content type or content
 	if strings.Contains(contentType, "application/json") ||
@@ -15,27 +18,32 @@ func ExtractTitle(content, contentType string) string {
 		return ExtractJSONTitle(content)
 	}
 
+	if IsXML(content, contentType) {
+		return ExtractXMLTitle(content)
+	}
+
 	// Otherwise treat as HTML
 	return ExtractHTMLTitle(content)
 }
 
 // ExtractHTMLTitle extracts title from HTML
 func ExtractHTMLTitle(html string) string {
-	// Look for <title> tag
-	titleStart := strings.Index(strings.ToLower(html), "<title>")
-	if titleStart == -1 {
+	// An unterminated <title> swallows the rest of the document, so flag it
+	// before handing the markup to the DOM parser
+	lower := strings.ToLower(html)
+	if !strings.Contains(lower, "<title") {
 		return "No HTML title found"
 	}
-
-	titleStart += 7 // length of "<title>"
-	titleEnd := strings.Index(html[titleStart:], "</title>")
-	if titleEnd == -1 {
+	if !strings.Contains(lower, "</title") {
 		return "Malformed HTML title"
 	}
 
-	title := html[titleStart : titleStart+titleEnd]
-	title = strings.TrimSpace(title)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return "Malformed HTML title"
+	}
 
+	title := documentTitle(doc)
 	if title == "" {
 		return "Empty HTML title"
 	}
@@ -43,12 +51,20 @@ func ExtractHTMLTitle(html string) string {
 	return title
 }
 
-// ExtractJSONTitle extracts meaningful title from JSON responses
+// ExtractJSONTitle extracts meaningful title from JSON responses. For a
+// top-level array the title comes from its first element.
 func ExtractJSONTitle(jsonStr string) string {
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+	var value interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &value); err != nil {
 		return "Invalid JSON"
 	}
+	if items, ok := value.([]interface{}); ok && len(items) > 0 {
+		value = items[0]
+	}
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return "JSON response (no title field)"
+	}
 
 	// Look for common title fields in JSON
 	titleFields := []string{"title", "name", "login", "message", "description"}
@@ -76,3 +92,41 @@ func ExtractJSONTitle(jsonStr string) string {
 
 	return "JSON response (no title field)"
 }
+
+// documentTitle returns the trimmed text of the page title, preferring the
+// one in <head> over titles nested in inline SVG
+func documentTitle(doc *goquery.Document) string {
+	titles := doc.Find("head > title")
+	if titles.Length() == 0 {
+		titles = doc.Find("title")
+	}
+	return strings.TrimSpace(titles.First().Text())
+}
+
+// hiddenElements are never rendered, so their text is not visible text
+var hiddenElements = map[string]bool{"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true}
+
+// VisibleText returns the text a reader sees on the page, with whitespace
+// collapsed and scripts, styles and the document head left out
+func VisibleText(doc *goquery.Document) string {
+	var words []string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			words = append(words, strings.Fields(node.Data)...)
+			return
+		case html.ElementNode:
+			if hiddenElements[node.Data] {
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range doc.Nodes {
+		walk(node)
+	}
+	return strings.Join(words, " ")
+}
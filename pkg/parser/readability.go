@@ -0,0 +1,383 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"arachne/internal/types"
+)
+
+// Article is the main content of a page with boilerplate removed
+type Article = types.Article
+
+var (
+	// unlikelyCandidates match class/id values of page chrome rather than content
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|^ad-|\bads?\b|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|legends|menu|modal|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|toolbar|widget`)
+	// maybeCandidates rescue elements that also look like content containers
+	maybeCandidates = regexp.MustCompile(`(?i)and|article|body|column|content|main|post|story|text`)
+	positiveWeight  = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeWeight  = regexp.MustCompile(`(?i)comment|footer|footnote|masthead|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|sponsor|shopping|tags|widget`)
+)
+
+// boilerplateSelector matches elements that never hold article content
+const boilerplateSelector = "script, style, noscript, template, iframe, form, nav, header, footer, aside, svg, canvas, button, input, select, textarea, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [aria-hidden=true], [hidden]"
+
+// ExtractArticle finds the main content of an HTML page, readability style,
+// and returns it as plain text and, if markdown is set, as Markdown.
+// Relative links in the Markdown are resolved against baseURL.
+func ExtractArticle(content, baseURL string, markdown bool) (*Article, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	article := &Article{
+		Title:     documentTitle(doc),
+		Byline:    findByline(doc),
+		Published: findPublished(doc),
+	}
+
+	doc.Find(boilerplateSelector).Remove()
+	removeUnlikelyCandidates(doc)
+
+	root := findMainContent(doc)
+	if root == nil {
+		return article, nil
+	}
+
+	base, _ := url.Parse(baseURL)
+	article.Text = strings.TrimSpace(renderBlocks(root, base, false))
+	article.WordCount = len(strings.Fields(article.Text))
+	if markdown {
+		article.Markdown = strings.TrimSpace(renderBlocks(root, base, true))
+	}
+	return article, nil
+}
+
+// removeUnlikelyCandidates drops elements whose class or id marks them as
+// page chrome, unless they also look like a content container
+func removeUnlikelyCandidates(doc *goquery.Document) {
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "article", "main", "a", "body":
+			return
+		}
+		match := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if strings.TrimSpace(match) == "" {
+			return
+		}
+		if unlikelyCandidates.MatchString(match) && !maybeCandidates.MatchString(match) {
+			s.Remove()
+		}
+	})
+}
+
+// findMainContent returns the node that holds the article. Semantic
+// containers win when they carry most of the text, otherwise paragraphs
+// are scored and credited to their ancestors.
+func findMainContent(doc *goquery.Document) *html.Node {
+	bodyLength := textLength(doc.Find("body"))
+	if bodyLength == 0 {
+		return nil
+	}
+
+	var best *goquery.Selection
+	doc.Find("article, main, [itemprop=articleBody], [role=main]").Each(func(_ int, s *goquery.Selection) {
+		if best == nil || textLength(s) > textLength(best) {
+			best = s
+		}
+	})
+	if best != nil && textLength(best) > bodyLength/3 {
+		return best.Get(0)
+	}
+
+	scores := make(map[*html.Node]float64)
+	doc.Find("p, pre, td, blockquote").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		parent := s.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		credit(scores, parent, score)
+		if grandparent := parent.Parent(); grandparent.Length() > 0 {
+			credit(scores, grandparent, score/2)
+		}
+	})
+
+	var top *html.Node
+	topScore := 0.0
+	for node, score := range scores {
+		score *= 1 - linkDensity(goquery.NewDocumentFromNode(node).Selection)
+		if score > topScore {
+			top, topScore = node, score
+		}
+	}
+	if top == nil {
+		return doc.Find("body").Get(0)
+	}
+	return top
+}
+
+// credit adds score to a candidate, seeding it with its class/id weight
+func credit(scores map[*html.Node]float64, s *goquery.Selection, score float64) {
+	node := s.Get(0)
+	if _, seen := scores[node]; !seen {
+		weight := 0.0
+		match := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if positiveWeight.MatchString(match) {
+			weight += 25
+		}
+		if negativeWeight.MatchString(match) {
+			weight -= 25
+		}
+		scores[node] = weight
+	}
+	scores[node] += score
+}
+
+// textLength returns the length of the whitespace-normalized text
+func textLength(s *goquery.Selection) int {
+	return len(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+// linkDensity is the share of text inside links
+func linkDensity(s *goquery.Selection) float64 {
+	total := textLength(s)
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += textLength(a)
+	})
+	return float64(links) / float64(total)
+}
+
+// findByline looks for the author in metadata and common byline markup
+func findByline(doc *goquery.Document) string {
+	if author := strings.TrimSpace(doc.Find(`meta[name=author]`).AttrOr("content", "")); author != "" {
+		return author
+	}
+
+	byline := ""
+	doc.Find(`[rel=author], [itemprop=author], .byline, .author`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text == "" {
+			text = strings.TrimSpace(s.AttrOr("content", ""))
+		}
+		if text != "" && len(text) < 100 {
+			byline = strings.TrimPrefix(text, "By ")
+			return false
+		}
+		return true
+	})
+	return byline
+}
+
+// findPublished looks for the publication date in metadata and <time> elements
+func findPublished(doc *goquery.Document) *time.Time {
+	candidates := []string{
+		doc.Find(`meta[property="article:published_time"]`).AttrOr("content", ""),
+		doc.Find(`meta[itemprop=datePublished]`).AttrOr("content", ""),
+		doc.Find(`meta[name=date], meta[name=pubdate], meta[name=publish-date], meta[name="dc.date"]`).AttrOr("content", ""),
+		doc.Find(`[itemprop=datePublished]`).AttrOr("datetime", ""),
+		doc.Find(`time[datetime]`).AttrOr("datetime", ""),
+	}
+	for _, candidate := range candidates {
+		if published := parseFeedDate(candidate); published != nil {
+			return published
+		}
+	}
+	return nil
+}
+
+// blockElements start a new paragraph when rendered
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "ul": true, "ol": true, "li": true, "pre": true,
+	"blockquote": true, "table": true, "tr": true, "figure": true, "figcaption": true, "hr": true,
+	"dl": true, "dt": true, "dd": true,
+}
+
+// renderBlocks renders node as paragraphs of plain text or Markdown
+func renderBlocks(node *html.Node, base *url.URL, markdown bool) string {
+	r := &blockRenderer{base: base, markdown: markdown}
+	r.render(node)
+	r.flush()
+	return strings.Join(r.blocks, "\n\n")
+}
+
+// blockRenderer accumulates inline text into paragraphs
+type blockRenderer struct {
+	base     *url.URL
+	markdown bool
+	blocks   []string
+	current  strings.Builder
+	prefix   string // Markdown prefix of the block being built, e.g. "## " or "- "
+	lists    []int  // Item counters of enclosing lists, -1 for unordered
+	quote    int    // Blockquote nesting depth
+}
+
+// flush ends the current paragraph
+func (r *blockRenderer) flush() {
+	text := strings.Join(strings.Fields(r.current.String()), " ")
+	r.current.Reset()
+	if text == "" {
+		r.prefix = ""
+		return
+	}
+	if r.markdown {
+		text = strings.Repeat("> ", r.quote) + r.prefix + text
+	}
+	r.blocks = append(r.blocks, text)
+	r.prefix = ""
+}
+
+// render walks the node tree
+func (r *blockRenderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.current.WriteString(node.Data)
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	name := node.Data
+	if blockElements[name] {
+		r.flush()
+	}
+
+	if r.markdown {
+		if r.renderMarkdown(node) {
+			return
+		}
+	} else if name == "br" {
+		r.current.WriteString(" ")
+	}
+
+	r.renderChildren(node)
+	if blockElements[name] {
+		r.flush()
+	}
+}
+
+// renderChildren renders every child of node
+func (r *blockRenderer) renderChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+// renderMarkdown handles elements with Markdown syntax. It returns true when
+// the element, including its children, has been fully rendered.
+func (r *blockRenderer) renderMarkdown(node *html.Node) bool {
+	s := goquery.NewDocumentFromNode(node).Selection
+	switch name := node.Data; name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.prefix = strings.Repeat("#", int(name[1]-'0')) + " "
+	case "li":
+		r.prefix = "- "
+		if depth := len(r.lists); depth > 0 {
+			if r.lists[depth-1] >= 0 {
+				r.lists[depth-1]++
+				r.prefix = fmt.Sprintf("%d. ", r.lists[depth-1])
+			}
+			r.prefix = strings.Repeat("  ", depth-1) + r.prefix
+		}
+	case "ul", "ol":
+		counter := -1
+		if name == "ol" {
+			counter = 0
+		}
+		r.lists = append(r.lists, counter)
+		r.renderChildren(node)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.flush()
+		return true
+	case "blockquote":
+		r.quote++
+		r.renderChildren(node)
+		r.flush()
+		r.quote--
+		return true
+	case "pre":
+		code := strings.Trim(s.Text(), "\n")
+		r.blocks = append(r.blocks, "```\n"+code+"\n```")
+		return true
+	case "hr":
+		r.blocks = append(r.blocks, "---")
+		return true
+	case "br":
+		r.current.WriteString("  \n")
+		return true
+	case "img":
+		alt := strings.TrimSpace(s.AttrOr("alt", ""))
+		if src := s.AttrOr("src", ""); src != "" {
+			r.current.WriteString(fmt.Sprintf("![%s](%s)", alt, r.resolve(src)))
+		}
+		return true
+	case "a":
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		href := s.AttrOr("href", "")
+		if text == "" {
+			return true
+		}
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			r.writeInline(s.Text(), text)
+		} else {
+			r.writeInline(s.Text(), fmt.Sprintf("[%s](%s)", text, r.resolve(href)))
+		}
+		return true
+	case "strong", "b":
+		return r.wrapInline(s, "**")
+	case "em", "i":
+		return r.wrapInline(s, "_")
+	case "code":
+		return r.wrapInline(s, "`")
+	}
+	return false
+}
+
+// wrapInline writes the element's text surrounded by a Markdown marker
+func (r *blockRenderer) wrapInline(s *goquery.Selection, marker string) bool {
+	raw := s.Text()
+	if text := strings.Join(strings.Fields(raw), " "); text != "" {
+		r.writeInline(raw, marker+text+marker)
+	}
+	return true
+}
+
+// writeInline writes rendered inline markup, keeping the whitespace that
+// surrounded the original text so words do not run together
+func (r *blockRenderer) writeInline(raw, rendered string) {
+	if strings.TrimLeft(raw, " \t\n") != raw {
+		r.current.WriteString(" ")
+	}
+	r.current.WriteString(rendered)
+	if strings.TrimRight(raw, " \t\n") != raw {
+		r.current.WriteString(" ")
+	}
+}
+
+// resolve makes a link absolute against the page URL
+func (r *blockRenderer) resolve(ref string) string {
+	if r.base == nil {
+		return ref
+	}
+	return resolveURL(r.base, ref)
+}
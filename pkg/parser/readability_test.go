@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+const articleHTML = `<html><head>
+<title>Rivers are rising | Daily News</title>
+<meta name="author" content="Jo Smith">
+<meta property="article:published_time" content="2025-04-02T08:15:00Z">
+</head><body>
+<header><nav><a href="/">Home</a> <a href="/world">World</a></nav></header>
+<div class="sidebar"><p>Subscribe to our newsletter for more updates, offers, and news.</p></div>
+<div id="story">
+  <h1>Rivers are rising</h1>
+  <p>Heavy rain across the valley has pushed rivers to record levels, officials said on Tuesday.</p>
+  <p>Residents were told to <a href="/advice">follow the advice</a>, move valuables upstairs, and <strong>avoid</strong> flooded roads.</p>
+  <ul><li>Check the flood map</li><li>Keep a radio nearby</li></ul>
+</div>
+<div class="ad-banner">Buy now, limited offer, while stocks last, everything must go!</div>
+<footer><p>Copyright Daily News, all rights reserved, since 1901.</p></footer>
+</body></html>`
+
+func TestExtractArticle(t *testing.T) {
+	article, err := ExtractArticle(articleHTML, "https://news.example/2025/rivers", true)
+	if err != nil {
+		t.Fatalf("ExtractArticle() error = %v", err)
+	}
+
+	if article.Byline != "Jo Smith" {
+		t.Errorf("Byline = %q, want Jo Smith", article.Byline)
+	}
+	if article.Published == nil || article.Published.Format("2006-01-02") != "2025-04-02" {
+		t.Errorf("Published = %v, want 2025-04-02", article.Published)
+	}
+
+	for _, boilerplate := range []string{"Home", "newsletter", "Buy now", "Copyright"} {
+		if strings.Contains(article.Text, boilerplate) {
+			t.Errorf("Text contains boilerplate %q:\n%s", boilerplate, article.Text)
+		}
+	}
+	if !strings.Contains(article.Text, "Heavy rain across the valley") {
+		t.Errorf("Text is missing the article body:\n%s", article.Text)
+	}
+	if article.WordCount != len(strings.Fields(article.Text)) || article.WordCount < 30 {
+		t.Errorf("WordCount = %d for text:\n%s", article.WordCount, article.Text)
+	}
+
+	expectedMarkdown := []string{
+		"# Rivers are rising",
+		"[follow the advice](https://news.example/advice), move valuables upstairs, and **avoid** flooded roads.",
+		"- Check the flood map\n\n- Keep a radio nearby",
+	}
+	for _, expected := range expectedMarkdown {
+		if !strings.Contains(article.Markdown, expected) {
+			t.Errorf("Markdown is missing %q:\n%s", expected, article.Markdown)
+		}
+	}
+}
+
+func TestExtractArticleWithoutMarkdown(t *testing.T) {
+	article, err := ExtractArticle(`<html><body><article><p>Short but real content here.</p></article></body></html>`, "", false)
+	if err != nil {
+		t.Fatalf("ExtractArticle() error = %v", err)
+	}
+	if article.Markdown != "" {
+		t.Errorf("Markdown should be empty when not requested, got %q", article.Markdown)
+	}
+	if article.Text != "Short but real content here." {
+		t.Errorf("Text = %q", article.Text)
+	}
+}
@@ -0,0 +1,336 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/jmespath/go-jmespath"
+	"github.com/theory/jsonpath"
+	"golang.org/x/net/html"
+)
+
+// Field types supported by extraction schemas
+const (
+	FieldTypeString = "string"
+	FieldTypeInt    = "int"
+	FieldTypeFloat  = "float"
+	FieldTypeDate   = "date"
+	FieldTypeURL    = "url"
+)
+
+// Schema declares how to turn an HTML page, an XML document or a JSON response
+// into structured records. With a container selector every matching element
+// yields one record, otherwise the whole page yields a single record. HTML
+// schemas use CSS or XPath selectors, JSON schemas use JSONPath or JMESPath
+// expressions. XML documents take either XPath or JSON expressions, the latter
+// applied to the structure ParseXML produces.
+type Schema struct {
+	Container         string            `json:"container,omitempty"`          // CSS selector of repeated items
+	ContainerXPath    string            `json:"container_xpath,omitempty"`    // XPath alternative to Container
+	ContainerJSONPath string            `json:"container_jsonpath,omitempty"` // JSONPath of repeated items
+	ContainerJMESPath string            `json:"container_jmespath,omitempty"` // JMESPath alternative to ContainerJSONPath
+	Namespaces        map[string]string `json:"namespaces,omitempty"`         // XPath prefix -> namespace URI for XML documents
+	Fields            []Field           `json:"fields"`
+}
+
+// Field maps a record field to a selector. A field without a selector reads
+// from the element it is scoped to.
+type Field struct {
+	Name     string      `json:"name"`
+	Selector string      `json:"selector,omitempty"` // CSS selector
+	XPath    string      `json:"xpath,omitempty"`    // XPath alternative to Selector
+	JSONPath string      `json:"jsonpath,omitempty"` // JSONPath, relative to the record as $
+	JMESPath string      `json:"jmespath,omitempty"` // JMESPath, relative to the record
+	Attr     string      `json:"attr,omitempty"`     // Attribute to read, text content when empty
+	Type     string      `json:"type,omitempty"`     // string (default), int, float, date or url
+	Format   string      `json:"format,omitempty"`   // Go time layout for date fields
+	Multiple bool        `json:"multiple,omitempty"` // Collect every match into a list
+	Default  interface{} `json:"default,omitempty"`  // Used when nothing matches or coercion fails
+	Fields   []Field     `json:"fields,omitempty"`   // Nested object extracted from each match
+}
+
+// Validate ensures the schema is well formed and its selectors compile
+func (s *Schema) Validate() error {
+	if countSet(s.Container, s.ContainerXPath, s.ContainerJSONPath, s.ContainerJMESPath) > 1 {
+		return fmt.Errorf("schema takes only one container selector")
+	}
+	if err := validateSelectors(s.Container, s.ContainerXPath, s.ContainerJSONPath, s.ContainerJMESPath); err != nil {
+		return fmt.Errorf("invalid container: %v", err)
+	}
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("schema has no fields")
+	}
+
+	hasHTML := s.Container != "" || s.ContainerXPath != "" || fieldsUse(s.Fields, isHTMLField)
+	if hasHTML && s.IsJSON() {
+		return fmt.Errorf("schema cannot mix HTML selectors with JSONPath or JMESPath")
+	}
+	return validateFields(s.Fields, "")
+}
+
+// IsJSON reports whether the schema targets JSON responses
+func (s *Schema) IsJSON() bool {
+	return s.ContainerJSONPath != "" || s.ContainerJMESPath != "" || fieldsUse(s.Fields, isJSONField)
+}
+
+// isHTMLField reports whether a field uses an HTML selector
+func isHTMLField(f *Field) bool { return f.Selector != "" || f.XPath != "" || f.Attr != "" }
+
+// isJSONField reports whether a field uses a JSON expression
+func isJSONField(f *Field) bool { return f.JSONPath != "" || f.JMESPath != "" }
+
+// fieldsUse reports whether any field, including nested ones, satisfies match
+func fieldsUse(fields []Field, match func(*Field) bool) bool {
+	for i := range fields {
+		if match(&fields[i]) || fieldsUse(fields[i].Fields, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// countSet returns how many of values are non-empty
+func countSet(values ...string) int {
+	count := 0
+	for _, value := range values {
+		if value != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// validateFields checks a list of fields, reporting errors with their path
+func validateFields(fields []Field, prefix string) error {
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		path := prefix + field.Name
+		if field.Name == "" {
+			return fmt.Errorf("field in %q has no name", strings.TrimSuffix(prefix, "."))
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("duplicate field %s", path)
+		}
+		seen[field.Name] = true
+
+		if countSet(field.Selector, field.XPath, field.JSONPath, field.JMESPath) > 1 {
+			return fmt.Errorf("field %s takes only one of selector, xpath, jsonpath or jmespath", path)
+		}
+		if err := validateSelectors(field.Selector, field.XPath, field.JSONPath, field.JMESPath); err != nil {
+			return fmt.Errorf("field %s: %v", path, err)
+		}
+
+		switch field.Type {
+		case "", FieldTypeString, FieldTypeInt, FieldTypeFloat, FieldTypeDate, FieldTypeURL:
+		default:
+			return fmt.Errorf("field %s: invalid type %s, must be one of: string, int, float, date, url", path, field.Type)
+		}
+
+		if len(field.Fields) > 0 {
+			if field.Attr != "" || field.Type != "" {
+				return fmt.Errorf("field %s: nested fields cannot set attr or type", path)
+			}
+			if err := validateFields(field.Fields, path+"."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateSelectors compiles whichever selectors are set
+func validateSelectors(css, xpathExpr, jsonPath, jmesPath string) error {
+	if css != "" {
+		if _, err := cascadia.Compile(css); err != nil {
+			return fmt.Errorf("invalid CSS selector %q: %v", css, err)
+		}
+	}
+	if xpathExpr != "" {
+		if _, err := xpath.Compile(xpathExpr); err != nil {
+			return fmt.Errorf("invalid XPath %q: %v", xpathExpr, err)
+		}
+	}
+	if jsonPath != "" {
+		if _, err := jsonpath.Parse(jsonPath); err != nil {
+			return fmt.Errorf("invalid JSONPath %q: %v", jsonPath, err)
+		}
+	}
+	if jmesPath != "" {
+		if _, err := jmespath.Compile(jmesPath); err != nil {
+			return fmt.Errorf("invalid JMESPath %q: %v", jmesPath, err)
+		}
+	}
+	return nil
+}
+
+// ExtractRecords parses an HTML document and applies the schema to it
+func ExtractRecords(content, baseURL string, schema *Schema) ([]map[string]interface{}, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return schema.Extract(doc, baseURL)
+}
+
+// Extract applies the schema to a parsed document. Relative URLs in url
+// fields are resolved against baseURL. JSON schemas yield no records.
+func (s *Schema) Extract(doc *goquery.Document, baseURL string) ([]map[string]interface{}, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if s.IsJSON() {
+		return nil, nil
+	}
+
+	e := &extractor{doc: doc, xpaths: make(map[string]*xpath.Expr)}
+	e.base, _ = url.Parse(baseURL)
+
+	if s.Container == "" && s.ContainerXPath == "" {
+		return []map[string]interface{}{e.object(doc.Selection, s.Fields)}, nil
+	}
+
+	containers := e.find(doc.Selection, s.Container, s.ContainerXPath)
+	records := make([]map[string]interface{}, 0, containers.Length())
+	containers.Each(func(_ int, container *goquery.Selection) {
+		records = append(records, e.object(container, s.Fields))
+	})
+	return records, nil
+}
+
+// extractor holds the state of a single schema application
+type extractor struct {
+	doc    *goquery.Document
+	base   *url.URL
+	xpaths map[string]*xpath.Expr
+}
+
+// object extracts every field within scope into a record
+func (e *extractor) object(scope *goquery.Selection, fields []Field) map[string]interface{} {
+	record := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		record[field.Name] = e.field(scope, &field)
+	}
+	return record
+}
+
+// field extracts a single field, falling back to its default
+func (e *extractor) field(scope *goquery.Selection, field *Field) interface{} {
+	matches := scope
+	if field.Selector != "" || field.XPath != "" {
+		matches = e.find(scope, field.Selector, field.XPath)
+	}
+
+	if field.Multiple {
+		values := make([]interface{}, 0, matches.Length())
+		matches.Each(func(_ int, match *goquery.Selection) {
+			if value := e.value(match, field); value != nil {
+				values = append(values, value)
+			}
+		})
+		if len(values) == 0 && field.Default != nil {
+			return field.Default
+		}
+		return values
+	}
+
+	if matches.Length() == 0 {
+		return field.Default
+	}
+	if value := e.value(matches.First(), field); value != nil {
+		return value
+	}
+	return field.Default
+}
+
+// value reads and coerces the value of one matched element
+func (e *extractor) value(match *goquery.Selection, field *Field) interface{} {
+	if len(field.Fields) > 0 {
+		return e.object(match, field.Fields)
+	}
+
+	var raw string
+	if field.Attr != "" {
+		raw = strings.TrimSpace(match.AttrOr(field.Attr, ""))
+	} else {
+		raw = strings.Join(strings.Fields(match.Text()), " ")
+	}
+	if raw == "" {
+		return nil
+	}
+
+	value, err := coerceText(raw, field, e.base)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// find evaluates a CSS selector or XPath expression relative to scope
+func (e *extractor) find(scope *goquery.Selection, css, xpathExpr string) *goquery.Selection {
+	if xpathExpr == "" {
+		return scope.Find(css)
+	}
+
+	expr, ok := e.xpaths[xpathExpr]
+	if !ok {
+		expr = xpath.MustCompile(xpathExpr) // Validated by Schema.Validate
+		e.xpaths[xpathExpr] = expr
+	}
+
+	var nodes []*html.Node
+	for _, node := range scope.Nodes {
+		nodes = append(nodes, htmlquery.QuerySelectorAll(node, expr)...)
+	}
+	return e.doc.FindNodes(nodes...)
+}
+
+var (
+	intPattern   = regexp.MustCompile(`-?\d[\d,]*`)
+	floatPattern = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?|-?\.\d+`)
+)
+
+// coerceText converts raw text to the field type. Numbers are taken from the
+// first number in the text, so "$1,299.00" and "42 reviews" both work.
+func coerceText(raw string, field *Field, base *url.URL) (interface{}, error) {
+	switch field.Type {
+	case FieldTypeInt:
+		match := intPattern.FindString(raw)
+		if match == "" {
+			return nil, fmt.Errorf("no integer in %q", raw)
+		}
+		return strconv.ParseInt(strings.ReplaceAll(match, ",", ""), 10, 64)
+	case FieldTypeFloat:
+		match := floatPattern.FindString(raw)
+		if match == "" {
+			return nil, fmt.Errorf("no number in %q", raw)
+		}
+		return strconv.ParseFloat(strings.ReplaceAll(match, ",", ""), 64)
+	case FieldTypeDate:
+		if field.Format != "" {
+			parsed, err := time.Parse(field.Format, raw)
+			if err != nil {
+				return nil, err
+			}
+			return parsed.Format(time.RFC3339), nil
+		}
+		if parsed := parseFeedDate(raw); parsed != nil {
+			return parsed.Format(time.RFC3339), nil
+		}
+		return nil, fmt.Errorf("unrecognised date %q", raw)
+	case FieldTypeURL:
+		if base == nil {
+			return raw, nil
+		}
+		return resolveURL(base, raw), nil
+	default:
+		return raw, nil
+	}
+}
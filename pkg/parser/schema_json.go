@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/theory/jsonpath"
+)
+
+// ExtractJSONRecords applies a JSON schema to a JSON response. A container
+// expression, or a top-level array without one, fans out into one record per
+// element. HTML schemas yield no records.
+func (s *Schema) ExtractJSONRecords(content []byte, baseURL string) ([]map[string]interface{}, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if !s.IsJSON() {
+		return nil, nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return s.extractJSONDocument(document, baseURL), nil
+}
+
+// extractJSONDocument applies a validated JSON schema to a decoded document
+func (s *Schema) extractJSONDocument(document interface{}, baseURL string) []map[string]interface{} {
+	e := &jsonExtractor{
+		jsonPaths: make(map[string]*jsonpath.Path),
+		jmesPaths: make(map[string]*jmespath.JMESPath),
+	}
+	e.base, _ = url.Parse(baseURL)
+
+	items := []interface{}{document}
+	switch {
+	case s.ContainerJSONPath != "":
+		items = e.selectJSONPath(s.ContainerJSONPath, document)
+	case s.ContainerJMESPath != "":
+		items = asList(e.searchJMESPath(s.ContainerJMESPath, document))
+	default:
+		if list, ok := document.([]interface{}); ok {
+			items = list
+		}
+	}
+
+	// A container that selects a single array fans out over its elements
+	if len(items) == 1 {
+		if list, ok := items[0].([]interface{}); ok && (s.ContainerJSONPath != "" || s.ContainerJMESPath != "") {
+			items = list
+		}
+	}
+
+	records := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		records = append(records, e.object(item, s.Fields))
+	}
+	return records
+}
+
+// jsonExtractor holds the state of a single JSON schema application
+type jsonExtractor struct {
+	base      *url.URL
+	jsonPaths map[string]*jsonpath.Path
+	jmesPaths map[string]*jmespath.JMESPath
+}
+
+// object extracts every field relative to scope into a record
+func (e *jsonExtractor) object(scope interface{}, fields []Field) map[string]interface{} {
+	record := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		record[field.Name] = e.field(scope, &field)
+	}
+	return record
+}
+
+// field extracts a single field, falling back to its default. JSONPath
+// matches are treated like selector matches, while a JMESPath result is used
+// as is, since the expression already decides its shape.
+func (e *jsonExtractor) field(scope interface{}, field *Field) interface{} {
+	var matches []interface{}
+	switch {
+	case field.JSONPath != "":
+		matches = e.selectJSONPath(field.JSONPath, scope)
+	case field.JMESPath != "":
+		result := e.searchJMESPath(field.JMESPath, scope)
+		if field.Multiple {
+			matches = asList(result)
+		} else if result != nil {
+			matches = []interface{}{result}
+		}
+	default:
+		matches = []interface{}{scope}
+	}
+
+	if field.Multiple {
+		values := make([]interface{}, 0, len(matches))
+		for _, match := range matches {
+			if value := e.value(match, field); value != nil {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 && field.Default != nil {
+			return field.Default
+		}
+		return values
+	}
+
+	if len(matches) == 0 {
+		return field.Default
+	}
+	if value := e.value(matches[0], field); value != nil {
+		return value
+	}
+	return field.Default
+}
+
+// value coerces one matched JSON value to the field type
+func (e *jsonExtractor) value(match interface{}, field *Field) interface{} {
+	if len(field.Fields) > 0 {
+		if _, ok := match.(map[string]interface{}); !ok {
+			return nil
+		}
+		return e.object(match, field.Fields)
+	}
+
+	switch v := match.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		value, err := coerceText(v, field, e.base)
+		if err != nil {
+			return nil
+		}
+		return value
+	case float64:
+		switch field.Type {
+		case "":
+			return v
+		case FieldTypeInt:
+			return int64(v)
+		case FieldTypeFloat:
+			return v
+		case FieldTypeString:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return nil
+	case bool:
+		switch field.Type {
+		case "":
+			return v
+		case FieldTypeString:
+			return strconv.FormatBool(v)
+		}
+		return nil
+	default:
+		// Objects and arrays are kept as they are unless a type is requested
+		if field.Type != "" {
+			return nil
+		}
+		return v
+	}
+}
+
+// selectJSONPath returns the values a JSONPath query selects
+func (e *jsonExtractor) selectJSONPath(expr string, input interface{}) []interface{} {
+	path, ok := e.jsonPaths[expr]
+	if !ok {
+		path = jsonpath.MustParse(expr) // Validated by Schema.Validate
+		e.jsonPaths[expr] = path
+	}
+	return []interface{}(path.Select(input))
+}
+
+// searchJMESPath evaluates a JMESPath expression, returning nil on error
+func (e *jsonExtractor) searchJMESPath(expr string, input interface{}) interface{} {
+	query, ok := e.jmesPaths[expr]
+	if !ok {
+		query = jmespath.MustCompile(expr) // Validated by Schema.Validate
+		e.jmesPaths[expr] = query
+	}
+	result, err := query.Search(input)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// asList returns value as a list, wrapping scalars and dropping nil
+func asList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
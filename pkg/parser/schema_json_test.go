@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+const ordersJSON = `{
+  "data": {
+    "orders": [
+      {"id": 101, "total": "19.90", "customer": {"name": "Ana", "vip": true}, "items": [{"sku": "a"}, {"sku": "b"}], "link": "/orders/101"},
+      {"id": 102, "total": 5, "customer": {"name": "Ben"}, "items": []}
+    ]
+  }
+}`
+
+func TestExtractJSONRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		schema   Schema
+		expected []map[string]interface{}
+	}{
+		{
+			name:    "JSONPath container fans out",
+			content: ordersJSON,
+			schema: Schema{
+				ContainerJSONPath: "$.data.orders[*]",
+				Fields: []Field{
+					{Name: "id", JSONPath: "$.id", Type: FieldTypeInt},
+					{Name: "total", JSONPath: "$.total", Type: FieldTypeFloat},
+					{Name: "skus", JSONPath: "$.items[*].sku", Multiple: true},
+					{Name: "link", JSONPath: "$.link", Type: FieldTypeURL, Default: ""},
+					{Name: "customer", JSONPath: "$.customer", Fields: []Field{
+						{Name: "name", JSONPath: "$.name"},
+						{Name: "vip", JSONPath: "$.vip", Default: false},
+					}},
+				},
+			},
+			expected: []map[string]interface{}{
+				{"id": int64(101), "total": 19.9, "skus": []interface{}{"a", "b"}, "link": "https://api.example/orders/101",
+					"customer": map[string]interface{}{"name": "Ana", "vip": true}},
+				{"id": int64(102), "total": 5.0, "skus": []interface{}{}, "link": "",
+					"customer": map[string]interface{}{"name": "Ben", "vip": false}},
+			},
+		},
+		{
+			name:    "JMESPath container selecting an array",
+			content: ordersJSON,
+			schema: Schema{
+				ContainerJMESPath: "data.orders",
+				Fields: []Field{
+					{Name: "customer", JMESPath: "customer.name"},
+					{Name: "item_count", JMESPath: "length(items)"},
+				},
+			},
+			expected: []map[string]interface{}{
+				{"customer": "Ana", "item_count": 2.0},
+				{"customer": "Ben", "item_count": 0.0},
+			},
+		},
+		{
+			name:    "Top-level array without container",
+			content: `[{"title": "a"}, {"title": "b"}]`,
+			schema:  Schema{Fields: []Field{{Name: "title", JSONPath: "$.title"}}},
+			expected: []map[string]interface{}{
+				{"title": "a"},
+				{"title": "b"},
+			},
+		},
+		{
+			name:    "Single record from an object",
+			content: ordersJSON,
+			schema:  Schema{Fields: []Field{{Name: "ids", JMESPath: "data.orders[].id", Multiple: true}}},
+			expected: []map[string]interface{}{
+				{"ids": []interface{}{101.0, 102.0}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := tt.schema.ExtractJSONRecords([]byte(tt.content), "https://api.example/v1/orders")
+			if err != nil {
+				t.Fatalf("ExtractJSONRecords() error = %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("ExtractJSONRecords() =\n%#v\nwant\n%#v", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  Schema
+		wantErr bool
+	}{
+		{name: "Valid JSONPath", schema: Schema{Fields: []Field{{Name: "a", JSONPath: "$.a"}}}},
+		{name: "Bad JSONPath", schema: Schema{Fields: []Field{{Name: "a", JSONPath: "$.a["}}}, wantErr: true},
+		{name: "Bad JMESPath", schema: Schema{Fields: []Field{{Name: "a", JMESPath: "a.["}}}, wantErr: true},
+		{name: "Mixed HTML and JSON", schema: Schema{Container: ".item", Fields: []Field{{Name: "a", JSONPath: "$.a"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
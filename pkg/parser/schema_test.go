@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+const productsHTML = `<html><body>
+<div class="product" data-sku="A-1">
+  <h2><a href="/p/lamp">Desk  Lamp</a></h2>
+  <span class="price">$1,299.50</span>
+  <span class="reviews">42 reviews</span>
+  <time datetime="2025-03-04T10:00:00Z">March 4</time>
+  <ul class="tags"><li>home</li><li>light</li></ul>
+  <div class="seller"><span class="name">Acme</span><span class="rating">4.5</span></div>
+</div>
+<div class="product" data-sku="B-2">
+  <h2><a href="https://shop.example/p/chair">Chair</a></h2>
+  <span class="price">call us</span>
+</div>
+</body></html>`
+
+func TestSchemaExtract(t *testing.T) {
+	schema := &Schema{
+		Container: "div.product",
+		Fields: []Field{
+			{Name: "sku", Attr: "data-sku"},
+			{Name: "name", Selector: "h2 a"},
+			{Name: "url", Selector: "h2 a", Attr: "href", Type: FieldTypeURL},
+			{Name: "price", XPath: ".//span[@class='price']", Type: FieldTypeFloat, Default: 0.0},
+			{Name: "reviews", Selector: ".reviews", Type: FieldTypeInt},
+			{Name: "added", Selector: "time", Attr: "datetime", Type: FieldTypeDate},
+			{Name: "tags", Selector: ".tags li", Multiple: true},
+			{Name: "seller", Selector: ".seller", Fields: []Field{
+				{Name: "name", Selector: ".name"},
+				{Name: "rating", Selector: ".rating", Type: FieldTypeFloat},
+			}},
+		},
+	}
+
+	records, err := ExtractRecords(productsHTML, "https://shop.example/catalog", schema)
+	if err != nil {
+		t.Fatalf("ExtractRecords() error = %v", err)
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"sku":     "A-1",
+			"name":    "Desk Lamp",
+			"url":     "https://shop.example/p/lamp",
+			"price":   1299.5,
+			"reviews": int64(42),
+			"added":   "2025-03-04T10:00:00Z",
+			"tags":    []interface{}{"home", "light"},
+			"seller":  map[string]interface{}{"name": "Acme", "rating": 4.5},
+		},
+		{
+			"sku":     "B-2",
+			"name":    "Chair",
+			"url":     "https://shop.example/p/chair",
+			"price":   0.0, // Coercion fails, so the default applies
+			"reviews": nil,
+			"added":   nil,
+			"tags":    []interface{}{},
+			"seller":  nil,
+		},
+	}
+
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("ExtractRecords() =\n%#v\nwant\n%#v", records, expected)
+	}
+}
+
+func TestSchemaWithoutContainer(t *testing.T) {
+	schema := &Schema{Fields: []Field{
+		{Name: "first_product", XPath: "//div[@class='product'][1]/h2"},
+		{Name: "skus", Selector: "div.product", Multiple: true, Attr: "data-sku"},
+	}}
+
+	records, err := ExtractRecords(productsHTML, "", schema)
+	if err != nil {
+		t.Fatalf("ExtractRecords() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected a single page record, got %d", len(records))
+	}
+	if records[0]["first_product"] != "Desk Lamp" {
+		t.Errorf("first_product = %v, want Desk Lamp", records[0]["first_product"])
+	}
+	if skus := records[0]["skus"].([]interface{}); len(skus) != 2 {
+		t.Errorf("expected 2 skus, got %v", skus)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  Schema
+		wantErr bool
+	}{
+		{name: "Valid", schema: Schema{Container: ".item", Fields: []Field{{Name: "a", Selector: "a"}}}},
+		{name: "No fields", schema: Schema{Container: ".item"}, wantErr: true},
+		{name: "Bad CSS", schema: Schema{Fields: []Field{{Name: "a", Selector: "a[href"}}}, wantErr: true},
+		{name: "Bad XPath", schema: Schema{Fields: []Field{{Name: "a", XPath: "//a[@href"}}}, wantErr: true},
+		{name: "Both selectors", schema: Schema{Fields: []Field{{Name: "a", Selector: "a", XPath: "//a"}}}, wantErr: true},
+		{name: "Unknown type", schema: Schema{Fields: []Field{{Name: "a", Type: "bool"}}}, wantErr: true},
+		{name: "Duplicate nested", schema: Schema{Fields: []Field{{Name: "o", Fields: []Field{{Name: "x"}, {Name: "x"}}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"arachne/internal/types"
+)
+
+// Item is a structured data item normalised to JSON-LD form
+type Item = types.Item
+
+// StructuredData holds the schema.org items of a page keyed by @type. An item
+// with several types is listed under each of them.
+type StructuredData map[string][]Item
+
+// ExtractStructuredData parses an HTML document and returns its JSON-LD,
+// microdata and RDFa items
+func ExtractStructuredData(content, baseURL string) (StructuredData, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return ExtractStructuredDataFromDocument(doc, baseURL), nil
+}
+
+// ExtractStructuredDataFromDocument returns the JSON-LD, microdata and RDFa
+// items of a parsed page, or nil when it has none. Malformed JSON-LD blocks
+// are skipped.
+func ExtractStructuredDataFromDocument(doc *goquery.Document, baseURL string) StructuredData {
+	base, _ := url.Parse(baseURL)
+	data := make(StructuredData)
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		for _, item := range parseJSONLD(s.Text()) {
+			data.add(item)
+		}
+	})
+
+	doc.Find("[itemscope]:not([itemprop])").Each(func(_ int, s *goquery.Selection) {
+		data.add(microdataItem(doc, s, base))
+	})
+
+	doc.Find("[typeof]:not([property])").Each(func(_ int, s *goquery.Selection) {
+		data.add(rdfaItem(s, base))
+	})
+
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// add indexes an item under each of its types
+func (d StructuredData) add(item Item) {
+	for _, name := range item.Types() {
+		d[name] = append(d[name], item)
+	}
+}
+
+// parseJSONLD decodes a JSON-LD block into its top-level nodes, flattening
+// arrays and @graph containers
+func parseJSONLD(text string) []Item {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "<!--")
+	text = strings.TrimSuffix(text, "-->")
+	text = strings.TrimSuffix(strings.TrimSpace(text), ";")
+
+	var document interface{}
+	if err := json.Unmarshal([]byte(text), &document); err != nil {
+		return nil
+	}
+
+	var items []Item
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, element := range v {
+				collect(element)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+				return
+			}
+			if item, ok := normaliseJSONLD(v).(Item); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	collect(document)
+	return items
+}
+
+// normaliseJSONLD converts decoded JSON-LD objects to items with short type
+// names, unwrapping {"@value": ...} literals
+func normaliseJSONLD(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if literal, ok := v["@value"]; ok {
+			return literal
+		}
+		item := make(Item, len(v))
+		for key, property := range v {
+			if key == "@type" {
+				item[key] = normaliseTypes(property)
+				continue
+			}
+			item[shortName(key)] = normaliseJSONLD(property)
+		}
+		return item
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, element := range v {
+			list[i] = normaliseJSONLD(element)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// normaliseTypes shortens one or several type IRIs
+func normaliseTypes(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return shortName(v)
+	case []interface{}:
+		types := make([]interface{}, 0, len(v))
+		for _, element := range v {
+			if name, ok := element.(string); ok {
+				types = append(types, shortName(name))
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// shortName strips a vocabulary IRI or prefix, so "https://schema.org/Product"
+// and "schema:Product" both become "Product"
+func shortName(name string) string {
+	if strings.HasPrefix(name, "@") {
+		return name
+	}
+	if index := strings.LastIndexAny(name, "/#"); index >= 0 {
+		return name[index+1:]
+	}
+	if prefix, local, found := strings.Cut(name, ":"); found && prefix != "" && !strings.Contains(local, "/") {
+		return local
+	}
+	return name
+}
+
+// microdataItem builds an item from an element with itemscope. Properties
+// are the itemprop elements whose closest scope is this element, plus those
+// under the elements listed in itemref.
+func microdataItem(doc *goquery.Document, scope *goquery.Selection, base *url.URL) Item {
+	item := make(Item)
+	var types []interface{}
+	for _, typeName := range strings.Fields(scope.AttrOr("itemtype", "")) {
+		types = append(types, shortName(typeName))
+	}
+	if len(types) == 1 {
+		item["@type"] = types[0]
+	} else if len(types) > 1 {
+		item["@type"] = types
+	}
+	if id := scope.AttrOr("itemid", ""); id != "" {
+		item["@id"] = id
+	}
+
+	roots := []*goquery.Selection{scope}
+	for _, id := range strings.Fields(scope.AttrOr("itemref", "")) {
+		if ref := doc.Find("#" + cssEscapeID(id)); ref.Length() > 0 {
+			roots = append(roots, ref)
+		}
+	}
+
+	for _, root := range roots {
+		props := root.Find("[itemprop]")
+		if root != scope {
+			props = root.Filter("[itemprop]").AddSelection(props)
+		}
+		props.Each(func(_ int, prop *goquery.Selection) {
+			if !ownedBy(prop.Get(0), root.Get(0), "itemscope") {
+				return
+			}
+			var value interface{}
+			if _, nested := prop.Attr("itemscope"); nested {
+				value = microdataItem(doc, prop, base)
+			} else {
+				value = microdataValue(prop, base)
+			}
+			for _, name := range strings.Fields(prop.AttrOr("itemprop", "")) {
+				addProperty(item, shortName(name), value)
+			}
+		})
+	}
+	return item
+}
+
+// microdataValue reads a microdata property value following the HTML rules:
+// URLs from src/href/data, machine-readable values from content, value or
+// datetime, and text content otherwise
+func microdataValue(s *goquery.Selection, base *url.URL) interface{} {
+	if content, ok := s.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	switch goquery.NodeName(s) {
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return resolveItemURL(base, s.AttrOr("src", ""))
+	case "a", "area", "link":
+		return resolveItemURL(base, s.AttrOr("href", ""))
+	case "object":
+		return resolveItemURL(base, s.AttrOr("data", ""))
+	case "data", "meter":
+		return strings.TrimSpace(s.AttrOr("value", ""))
+	case "time":
+		if datetime, ok := s.Attr("datetime"); ok {
+			return strings.TrimSpace(datetime)
+		}
+	}
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
+
+// rdfaItem builds an item from an element with typeof, reading RDFa Lite
+// property attributes whose closest typeof ancestor is this element
+func rdfaItem(scope *goquery.Selection, base *url.URL) Item {
+	item := make(Item)
+	var types []interface{}
+	for _, typeName := range strings.Fields(scope.AttrOr("typeof", "")) {
+		types = append(types, shortName(typeName))
+	}
+	if len(types) == 1 {
+		item["@type"] = types[0]
+	} else if len(types) > 1 {
+		item["@type"] = types
+	}
+	if id := scope.AttrOr("resource", ""); id != "" {
+		item["@id"] = resolveItemURL(base, id)
+	}
+
+	scope.Find("[property]").Each(func(_ int, prop *goquery.Selection) {
+		if !ownedBy(prop.Get(0), scope.Get(0), "typeof") {
+			return
+		}
+		var value interface{}
+		if _, nested := prop.Attr("typeof"); nested {
+			value = rdfaItem(prop, base)
+		} else {
+			value = rdfaValue(prop, base)
+		}
+		for _, name := range strings.Fields(prop.AttrOr("property", "")) {
+			addProperty(item, shortName(name), value)
+		}
+	})
+	return item
+}
+
+// rdfaValue reads an RDFa property value: content, then a link target, then
+// datetime, then text content
+func rdfaValue(s *goquery.Selection, base *url.URL) interface{} {
+	if content, ok := s.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	for _, attr := range []string{"href", "src", "resource"} {
+		if target, ok := s.Attr(attr); ok {
+			return resolveItemURL(base, target)
+		}
+	}
+	if datetime, ok := s.Attr("datetime"); ok {
+		return strings.TrimSpace(datetime)
+	}
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
+
+// ownedBy reports whether node belongs to root's item: no element between
+// them, excluding root, opens a new scope with attr
+func ownedBy(node, root *html.Node, attr string) bool {
+	if node == root {
+		return true
+	}
+	for parent := node.Parent; parent != nil && parent != root; parent = parent.Parent {
+		for _, a := range parent.Attr {
+			if a.Key == attr {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// addProperty sets a property, turning repeated properties into lists
+func addProperty(item Item, name string, value interface{}) {
+	existing, ok := item[name]
+	if !ok {
+		item[name] = value
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		item[name] = append(list, value)
+		return
+	}
+	item[name] = []interface{}{existing, value}
+}
+
+// resolveItemURL resolves a URL property against the page URL when known
+func resolveItemURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+	return resolveURL(base, ref)
+}
+
+// cssEscapeID escapes an element id for use in an #id selector
+func cssEscapeID(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractStructuredDataJSONLD(t *testing.T) {
+	html := `<html><head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+  {"@type": "Product", "name": "Kettle", "sku": "K-1", "brand": {"@type": "Brand", "name": "Acme"},
+   "image": ["https://example.com/kettle.jpg"],
+   "offers": {"@type": "Offer", "price": "29.99", "priceCurrency": "EUR", "availability": "https://schema.org/InStock"}},
+  {"@type": "BreadcrumbList", "itemListElement": [
+    {"@type": "ListItem", "position": 2, "name": "Kettles", "item": "https://example.com/kettles"},
+    {"@type": "ListItem", "position": 1, "name": "Home", "item": {"@id": "https://example.com/"}}
+  ]}
+]}
+</script>
+<script type="application/ld+json">[{"@type": ["NewsArticle", "Article"], "headline": "Kettles rise",
+  "author": [{"@type": "Person", "name": "Ada"}, "Grace"], "datePublished": "2024-03-01"}]</script>
+<script type="application/ld+json">{not json</script>
+</head><body></body></html>`
+
+	data, err := ExtractStructuredData(html, "https://example.com/p/kettle")
+	if err != nil {
+		t.Fatalf("ExtractStructuredData() error = %v", err)
+	}
+
+	price := 29.99
+	products := data.Products()
+	expectedProducts := []Product{{
+		Name:   "Kettle",
+		SKU:    "K-1",
+		Brand:  "Acme",
+		Image:  "https://example.com/kettle.jpg",
+		Offers: []Offer{{Price: &price, PriceCurrency: "EUR", Availability: "InStock"}},
+	}}
+	if !reflect.DeepEqual(products, expectedProducts) {
+		t.Errorf("Products() = %+v, want %+v", products, expectedProducts)
+	}
+
+	articles := data.Articles()
+	if len(articles) != 1 {
+		t.Fatalf("Articles() returned %d articles, want 1", len(articles))
+	}
+	if articles[0].Headline != "Kettles rise" || !reflect.DeepEqual(articles[0].Authors, []string{"Ada", "Grace"}) {
+		t.Errorf("Articles()[0] = %+v", articles[0])
+	}
+
+	expectedCrumbs := []BreadcrumbList{{Items: []Breadcrumb{
+		{Position: 1, Name: "Home", URL: "https://example.com/"},
+		{Position: 2, Name: "Kettles", URL: "https://example.com/kettles"},
+	}}}
+	if crumbs := data.Breadcrumbs(); !reflect.DeepEqual(crumbs, expectedCrumbs) {
+		t.Errorf("Breadcrumbs() = %+v, want %+v", crumbs, expectedCrumbs)
+	}
+}
+
+func TestExtractStructuredDataMicrodata(t *testing.T) {
+	html := `<html><body>
+<div itemscope itemtype="https://schema.org/Product" itemref="extra">
+  <h1 itemprop="name">Lamp</h1>
+  <img itemprop="image" src="/lamp.png">
+  <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+    <span itemprop="price" content="1234.50">1.234,50 €</span>
+    <meta itemprop="priceCurrency" content="EUR">
+    <link itemprop="availability" href="https://schema.org/OutOfStock">
+  </div>
+</div>
+<p id="extra"><span itemprop="description">Warm light</span></p>
+</body></html>`
+
+	data, err := ExtractStructuredData(html, "https://shop.example/lamp")
+	if err != nil {
+		t.Fatalf("ExtractStructuredData() error = %v", err)
+	}
+
+	products := data.Products()
+	if len(products) != 1 {
+		t.Fatalf("Products() returned %d products, want 1", len(products))
+	}
+	product := products[0]
+	if product.Name != "Lamp" || product.Image != "https://shop.example/lamp.png" || product.Description != "Warm light" {
+		t.Errorf("Products()[0] = %+v", product)
+	}
+	if len(product.Offers) != 1 || product.Offers[0].Price == nil || *product.Offers[0].Price != 1234.5 ||
+		product.Offers[0].Availability != "OutOfStock" {
+		t.Errorf("Products()[0].Offers = %+v", product.Offers)
+	}
+	// Properties of the nested offer do not leak into the product
+	if _, ok := data["Product"][0]["price"]; ok {
+		t.Error("nested offer property attached to the product")
+	}
+}
+
+func TestExtractStructuredDataRDFa(t *testing.T) {
+	html := `<html><body vocab="https://schema.org/">
+<ol typeof="BreadcrumbList">
+  <li property="itemListElement" typeof="ListItem">
+    <a property="item" href="/books"><span property="name">Books</span></a>
+    <meta property="position" content="1">
+  </li>
+</ol>
+<article typeof="BlogPosting">
+  <h1 property="headline">Reading list</h1>
+  <span property="author" typeof="Person"><span property="name">Lin</span></span>
+  <time property="datePublished" datetime="2024-05-01">May 1</time>
+</article>
+</body></html>`
+
+	data, err := ExtractStructuredData(html, "https://example.com/")
+	if err != nil {
+		t.Fatalf("ExtractStructuredData() error = %v", err)
+	}
+
+	expectedCrumbs := []BreadcrumbList{{Items: []Breadcrumb{{Position: 1, Name: "Books", URL: "https://example.com/books"}}}}
+	if crumbs := data.Breadcrumbs(); !reflect.DeepEqual(crumbs, expectedCrumbs) {
+		t.Errorf("Breadcrumbs() = %+v, want %+v", crumbs, expectedCrumbs)
+	}
+
+	expectedArticles := []StructuredArticle{{Headline: "Reading list", Authors: []string{"Lin"}, DatePublished: "2024-05-01"}}
+	if articles := data.Articles(); !reflect.DeepEqual(articles, expectedArticles) {
+		t.Errorf("Articles() = %+v, want %+v", articles, expectedArticles)
+	}
+}
+
+func TestExtractStructuredDataNone(t *testing.T) {
+	data, err := ExtractStructuredData(`<html><body><p>Plain</p></body></html>`, "https://example.com/")
+	if err != nil {
+		t.Fatalf("ExtractStructuredData() error = %v", err)
+	}
+	if data != nil {
+		t.Errorf("ExtractStructuredData() = %v, want nil", data)
+	}
+}
@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"sort"
+	"strconv"
+)
+
+// articleTypes lists the schema.org types treated as articles
+var articleTypes = []string{"Article", "NewsArticle", "BlogPosting", "Report", "ScholarlyArticle", "TechArticle"}
+
+// Product is a schema.org Product
+type Product struct {
+	Name        string  `json:"name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	SKU         string  `json:"sku,omitempty"`
+	Brand       string  `json:"brand,omitempty"`
+	Image       string  `json:"image,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	Offers      []Offer `json:"offers,omitempty"`
+}
+
+// Offer is a schema.org Offer. AggregateOffer price ranges report their low
+// price.
+type Offer struct {
+	Price         *float64 `json:"price,omitempty"` // Nil when the price is missing or not a number
+	PriceCurrency string   `json:"price_currency,omitempty"`
+	Availability  string   `json:"availability,omitempty"` // e.g. InStock, OutOfStock
+	Seller        string   `json:"seller,omitempty"`
+	URL           string   `json:"url,omitempty"`
+}
+
+// StructuredArticle is a schema.org Article or one of its subtypes
+type StructuredArticle struct {
+	Headline      string   `json:"headline,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Authors       []string `json:"authors,omitempty"`
+	DatePublished string   `json:"date_published,omitempty"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	Publisher     string   `json:"publisher,omitempty"`
+	URL           string   `json:"url,omitempty"`
+}
+
+// BreadcrumbList is a schema.org BreadcrumbList, ordered by position
+type BreadcrumbList struct {
+	Items []Breadcrumb `json:"items"`
+}
+
+// Breadcrumb is one entry of a breadcrumb trail
+type Breadcrumb struct {
+	Position int    `json:"position,omitempty"`
+	Name     string `json:"name,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Products returns the page's products with their offers
+func (d StructuredData) Products() []Product {
+	var products []Product
+	for _, item := range d["Product"] {
+		product := Product{
+			Name:        item.String("name"),
+			Description: item.String("description"),
+			SKU:         item.String("sku"),
+			Brand:       item.String("brand"),
+			Image:       item.URL("image"),
+			URL:         item.URL("url"),
+		}
+		for _, offer := range item.Items("offers") {
+			product.Offers = append(product.Offers, offersOf(offer)...)
+		}
+		products = append(products, product)
+	}
+	return products
+}
+
+// Offers returns the offers of every product plus standalone offers
+func (d StructuredData) Offers() []Offer {
+	var offers []Offer
+	for _, product := range d.Products() {
+		offers = append(offers, product.Offers...)
+	}
+	for _, typeName := range []string{"Offer", "AggregateOffer"} {
+		for _, item := range d[typeName] {
+			offers = append(offers, offersOf(item)...)
+		}
+	}
+	return offers
+}
+
+// Articles returns the page's articles, including news and blog posts
+func (d StructuredData) Articles() []StructuredArticle {
+	var articles []StructuredArticle
+	for index, typeName := range articleTypes {
+		for _, item := range d[typeName] {
+			// Items typed as both Article and NewsArticle are listed once
+			if hasType(item, articleTypes[:index]) {
+				continue
+			}
+
+			article := StructuredArticle{
+				Headline:      firstNonEmpty(item.String("headline"), item.String("name")),
+				Description:   item.String("description"),
+				DatePublished: item.String("datePublished"),
+				DateModified:  item.String("dateModified"),
+				Image:         item.URL("image"),
+				Publisher:     item.String("publisher"),
+				URL:           item.URL("url"),
+				Authors:       item.Strings("author"),
+			}
+			articles = append(articles, article)
+		}
+	}
+	return articles
+}
+
+// Breadcrumbs returns the page's breadcrumb trails
+func (d StructuredData) Breadcrumbs() []BreadcrumbList {
+	var lists []BreadcrumbList
+	for _, item := range d["BreadcrumbList"] {
+		list := BreadcrumbList{Items: make([]Breadcrumb, 0)}
+		for i, element := range item.Items("itemListElement") {
+			crumb := Breadcrumb{
+				Position: i + 1,
+				Name:     element.String("name"),
+				URL:      element.URL("item"),
+			}
+			if position, err := strconv.Atoi(element.String("position")); err == nil {
+				crumb.Position = position
+			}
+			if crumb.Name == "" {
+				crumb.Name = element.String("item")
+			}
+			list.Items = append(list.Items, crumb)
+		}
+		sort.SliceStable(list.Items, func(i, j int) bool {
+			return list.Items[i].Position < list.Items[j].Position
+		})
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+// offersOf reads an Offer, or the offers nested in an AggregateOffer
+func offersOf(item Item) []Offer {
+	if nested := item.Items("offers"); len(nested) > 0 {
+		var offers []Offer
+		for _, offer := range nested {
+			offers = append(offers, offersOf(offer)...)
+		}
+		return offers
+	}
+
+	offer := Offer{
+		PriceCurrency: item.String("priceCurrency"),
+		Availability:  shortName(item.String("availability")),
+		Seller:        item.String("seller"),
+		URL:           item.URL("url"),
+	}
+	price := item.String("price")
+	if price == "" {
+		price = item.String("lowPrice")
+	}
+	if value, ok := cleanCell(price).(float64); ok {
+		offer.Price = &value
+	}
+	return []Offer{offer}
+}
+
+// hasType reports whether the item has any of the given types
+func hasType(item Item, types []string) bool {
+	for _, value := range item.Types() {
+		for _, typeName := range types {
+			if value == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"arachne/internal/types"
+)
+
+// maxSpan caps colspan/rowspan so malformed markup cannot blow up a table
+const maxSpan = 1000
+
+// TableSpec selects which tables of a page to extract. An empty spec selects
+// every table.
+type TableSpec struct {
+	Selector string `json:"selector,omitempty"` // CSS selector matching <table> elements
+	Indexes  []int  `json:"indexes,omitempty"`  // Zero-based positions among the selected tables
+}
+
+// Table is an HTML table converted to rows of named columns
+type Table = types.Table
+
+// ExtractTables parses an HTML document and returns the tables spec selects
+func ExtractTables(content string, spec *TableSpec) ([]Table, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return ExtractTablesFromDocument(doc, spec)
+}
+
+// ExtractTablesFromDocument returns the tables of a parsed page that spec selects
+func ExtractTablesFromDocument(doc *goquery.Document, spec *TableSpec) ([]Table, error) {
+	selector := "table"
+	var indexes map[int]bool
+	if spec != nil {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		if spec.Selector != "" {
+			selector = spec.Selector
+		}
+		if len(spec.Indexes) > 0 {
+			indexes = make(map[int]bool, len(spec.Indexes))
+			for _, index := range spec.Indexes {
+				indexes[index] = true
+			}
+		}
+	}
+
+	tables := make([]Table, 0)
+	doc.Find(selector).FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return goquery.NodeName(s) == "table"
+	}).Each(func(i int, s *goquery.Selection) {
+		if indexes != nil && !indexes[i] {
+			return
+		}
+		table := parseTable(s)
+		table.Index = i
+		tables = append(tables, table)
+	})
+	return tables, nil
+}
+
+// Validate ensures the table selector compiles and indexes are not negative
+func (t *TableSpec) Validate() error {
+	if err := validateSelectors(t.Selector, "", "", ""); err != nil {
+		return err
+	}
+	for _, index := range t.Indexes {
+		if index < 0 {
+			return fmt.Errorf("table index cannot be negative, got %d", index)
+		}
+	}
+	return nil
+}
+
+// tableCell is a cell placed in the expanded grid
+type tableCell struct {
+	text   string
+	header bool
+}
+
+// parseTable expands a table into a grid and names its columns
+func parseTable(s *goquery.Selection) Table {
+	table := Table{
+		Caption: strings.Join(strings.Fields(s.ChildrenFiltered("caption").Text()), " "),
+		Rows:    make([]map[string]interface{}, 0),
+	}
+
+	// Rows of nested tables belong to those tables, not this one
+	tableNode := s.Get(0)
+	var rows []*goquery.Selection
+	var headRows int
+	s.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		if closestTable(tr.Get(0)) != tableNode {
+			return
+		}
+		if goquery.NodeName(tr.Parent()) == "thead" {
+			headRows++
+		}
+		rows = append(rows, tr)
+	})
+
+	grid := expandGrid(rows)
+	if len(grid) == 0 {
+		return table
+	}
+
+	// Header rows come from <thead>, or else a leading row made only of <th>
+	if headRows == 0 && len(grid[0]) > 0 {
+		allHeaders := true
+		for _, cell := range grid[0] {
+			if !cell.header {
+				allHeaders = false
+				break
+			}
+		}
+		if allHeaders {
+			headRows = 1
+		}
+	}
+
+	width := 0
+	for _, row := range grid {
+		width = max(width, len(row))
+	}
+	table.Headers = columnNames(grid[:headRows], width)
+
+	for _, row := range grid[headRows:] {
+		record := make(map[string]interface{}, width)
+		empty := true
+		for col, name := range table.Headers {
+			var value interface{}
+			if col < len(row) && row[col].text != "" {
+				value = cleanCell(row[col].text)
+				empty = false
+			}
+			record[name] = value
+		}
+		if !empty {
+			table.Rows = append(table.Rows, record)
+		}
+	}
+	return table
+}
+
+// closestTable returns the nearest <table> ancestor of node
+func closestTable(node *html.Node) *html.Node {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.Type == html.ElementNode && parent.Data == "table" {
+			return parent
+		}
+	}
+	return nil
+}
+
+// expandGrid lays cells out on a grid, repeating spanned cells in every
+// position they cover
+func expandGrid(rows []*goquery.Selection) [][]tableCell {
+	grid := make([][]tableCell, len(rows))
+	filled := make([][]bool, len(rows))
+
+	for r, tr := range rows {
+		col := 0
+		tr.ChildrenFiltered("td, th").Each(func(_ int, td *goquery.Selection) {
+			// Skip positions taken by rowspans from earlier rows
+			for col < len(filled[r]) && filled[r][col] {
+				col++
+			}
+
+			cell := tableCell{
+				text:   strings.Join(strings.Fields(td.Text()), " "),
+				header: goquery.NodeName(td) == "th",
+			}
+			colspan := spanAttr(td, "colspan")
+			rowspan := min(spanAttr(td, "rowspan"), len(rows)-r)
+
+			for dr := 0; dr < rowspan; dr++ {
+				for dc := 0; dc < colspan; dc++ {
+					placeCell(grid, filled, r+dr, col+dc, cell)
+				}
+			}
+			col += colspan
+		})
+	}
+	return grid
+}
+
+// placeCell stores cell at row, col, growing the row as needed
+func placeCell(grid [][]tableCell, filled [][]bool, row, col int, cell tableCell) {
+	for len(grid[row]) <= col {
+		grid[row] = append(grid[row], tableCell{})
+		filled[row] = append(filled[row], false)
+	}
+	grid[row][col] = cell
+	filled[row][col] = true
+}
+
+// spanAttr reads a colspan or rowspan attribute, defaulting to 1
+func spanAttr(s *goquery.Selection, name string) int {
+	span, err := strconv.Atoi(strings.TrimSpace(s.AttrOr(name, "1")))
+	if err != nil || span < 1 {
+		return 1
+	}
+	return min(span, maxSpan)
+}
+
+// columnNames builds unique column names from the header rows. Stacked
+// header rows are joined with " / ", and unnamed columns become column_N.
+func columnNames(headerRows [][]tableCell, width int) []string {
+	names := make([]string, width)
+	seen := make(map[string]int, width)
+
+	for col := 0; col < width; col++ {
+		var parts []string
+		for _, row := range headerRows {
+			if col < len(row) && row[col].text != "" {
+				// A cell spanning several header rows is only named once
+				if len(parts) == 0 || parts[len(parts)-1] != row[col].text {
+					parts = append(parts, row[col].text)
+				}
+			}
+		}
+
+		name := strings.Join(parts, " / ")
+		if name == "" {
+			name = fmt.Sprintf("column_%d", col+1)
+		}
+		if count := seen[name]; count > 0 {
+			seen[name]++
+			name = fmt.Sprintf("%s_%d", name, count+1)
+		} else {
+			seen[name] = 1
+		}
+		names[col] = name
+	}
+	return names
+}
+
+// numericCell matches cells that are numbers once formatting is removed
+var numericCell = regexp.MustCompile(`^-?(\d+(\.\d+)?|\.\d+)$`)
+
+// cleanCell converts numeric-looking cells such as "1,234", "-5.2%",
+// "$3.50" or "(1,000)" to float64, leaving any other text unchanged
+func cleanCell(text string) interface{} {
+	cleaned := strings.NewReplacer(
+		",", "", " ", "", " ", "", " ", "",
+		"$", "", "€", "", "£", "", "¥", "", "%", "",
+		"−", "-", // Unicode minus sign
+	).Replace(text)
+
+	if strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")") {
+		cleaned = "-" + strings.Trim(cleaned, "()") // Accounting notation for negatives
+	}
+	if !numericCell.MatchString(cleaned) {
+		return text
+	}
+
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return text
+	}
+	return value
+}
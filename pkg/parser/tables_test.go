@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTables(t *testing.T) {
+	html := `<html><body>
+<table id="prices">
+  <caption>Quarterly  prices</caption>
+  <thead>
+    <tr><th rowspan="2">Product</th><th colspan="2">Price</th></tr>
+    <tr><th>Q1</th><th>Q2</th></tr>
+  </thead>
+  <tbody>
+    <tr><td>Widget</td><td>$1,299.50</td><td>(12)</td></tr>
+    <tr><td>Gadget</td><td>−3.5%</td><td></td></tr>
+  </tbody>
+</table>
+<table class="plain">
+  <tr><td rowspan="2">A</td><td>1</td></tr>
+  <tr><td>2</td></tr>
+</table>
+</body></html>`
+
+	tables, err := ExtractTables(html, nil)
+	if err != nil {
+		t.Fatalf("ExtractTables() error = %v", err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("ExtractTables() returned %d tables, want 2", len(tables))
+	}
+
+	expected := Table{
+		Index:   0,
+		Caption: "Quarterly prices",
+		Headers: []string{"Product", "Price / Q1", "Price / Q2"},
+		Rows: []map[string]interface{}{
+			{"Product": "Widget", "Price / Q1": 1299.5, "Price / Q2": -12.0},
+			{"Product": "Gadget", "Price / Q1": -3.5, "Price / Q2": nil},
+		},
+	}
+	if !reflect.DeepEqual(tables[0], expected) {
+		t.Errorf("ExtractTables()[0] =\n%+v\nwant\n%+v", tables[0], expected)
+	}
+
+	// Without header cells columns are numbered, and rowspans repeat the value
+	plain := Table{
+		Index:   1,
+		Headers: []string{"column_1", "column_2"},
+		Rows: []map[string]interface{}{
+			{"column_1": "A", "column_2": 1.0},
+			{"column_1": "A", "column_2": 2.0},
+		},
+	}
+	if !reflect.DeepEqual(tables[1], plain) {
+		t.Errorf("ExtractTables()[1] =\n%+v\nwant\n%+v", tables[1], plain)
+	}
+}
+
+func TestExtractTablesSelection(t *testing.T) {
+	html := `<table><tr><th>Name</th><th>Name</th></tr><tr><td>x</td><td>y</td></tr></table>
+<table class="data"><tr><th>Id</th></tr><tr><td>7 <table><tr><td>nested</td></tr></table></td></tr></table>`
+
+	tests := []struct {
+		name    string
+		spec    *TableSpec
+		indexes []int
+	}{
+		{"all tables", &TableSpec{}, []int{0, 1, 2}},
+		{"by index", &TableSpec{Indexes: []int{1}}, []int{1}},
+		{"by selector", &TableSpec{Selector: "table.data"}, []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, err := ExtractTables(html, tt.spec)
+			if err != nil {
+				t.Fatalf("ExtractTables() error = %v", err)
+			}
+			var indexes []int
+			for _, table := range tables {
+				indexes = append(indexes, table.Index)
+			}
+			if !reflect.DeepEqual(indexes, tt.indexes) {
+				t.Errorf("ExtractTables() indexes = %v, want %v", indexes, tt.indexes)
+			}
+		})
+	}
+
+	tables, _ := ExtractTables(html, &TableSpec{Indexes: []int{0, 1}})
+	if !reflect.DeepEqual(tables[0].Headers, []string{"Name", "Name_2"}) {
+		t.Errorf("duplicate headers = %v, want [Name Name_2]", tables[0].Headers)
+	}
+	// Rows of the nested table are not part of the outer table
+	if len(tables[1].Rows) != 1 || tables[1].Rows[0]["Id"] != "7 nested" {
+		t.Errorf("outer table rows = %+v, want a single row", tables[1].Rows)
+	}
+
+	if _, err := ExtractTables(html, &TableSpec{Selector: "table["}); err == nil {
+		t.Error("ExtractTables() accepted an invalid selector")
+	}
+	if _, err := ExtractTables(html, &TableSpec{Indexes: []int{-1}}); err == nil {
+		t.Error("ExtractTables() accepted a negative index")
+	}
+}
+
+func TestCleanCell(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1,234", 1234.0},
+		{"€ 12.50", 12.5},
+		{"45%", 45.0},
+		{"(1,000)", -1000.0},
+		{"−7", -7.0},
+		{".5", 0.5},
+		{"N/A", "N/A"},
+		{"2024-01-02", "2024-01-02"},
+		{"1.2.3", "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanCell(tt.input); got != tt.expected {
+			t.Errorf("cleanCell(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// IsXML reports whether a response is a non-HTML XML document, such as a
+// SOAP envelope or an OData feed
+func IsXML(content, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "html") {
+		return false
+	}
+	if strings.Contains(contentType, "xml") {
+		return true
+	}
+	if contentType != "" && !strings.HasPrefix(contentType, "text/plain") {
+		return false
+	}
+
+	trimmed := strings.ToLower(strings.TrimSpace(content))
+	if !strings.HasPrefix(trimmed, "<?xml") {
+		return false
+	}
+	// XHTML served without a content type starts with an XML declaration too
+	head := trimmed[:min(len(trimmed), 512)]
+	return !strings.Contains(head, "<html") && !strings.Contains(head, "<!doctype html")
+}
+
+// ParseXML converts an XML document to a JSON-compatible structure. Elements
+// become objects keyed by local name, so namespace prefixes do not matter:
+// attributes are stored as "@name", text next to child elements as "#text",
+// repeated elements as lists, and text-only elements as plain strings.
+func ParseXML(content string) (interface{}, error) {
+	doc, err := xmlquery.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %v", err)
+	}
+	root := xmlRoot(doc)
+	if root == nil {
+		return nil, fmt.Errorf("failed to parse XML: no root element")
+	}
+	return map[string]interface{}{root.Data: xmlValue(root)}, nil
+}
+
+// ExtractXMLTitle returns the text of the first title element of an XML
+// document, or names its root element when there is none
+func ExtractXMLTitle(content string) string {
+	doc, err := xmlquery.Parse(strings.NewReader(content))
+	if err != nil {
+		return "Invalid XML"
+	}
+	root := xmlRoot(doc)
+	if root == nil {
+		return "Invalid XML"
+	}
+
+	for _, name := range []string{"title", "name"} {
+		for _, node := range xmlquery.Find(root, fmt.Sprintf("//*[translate(local-name(), 'TITLENAM', 'titlenam')='%s']", name)) {
+			if text := strings.Join(strings.Fields(node.InnerText()), " "); text != "" {
+				return text
+			}
+		}
+	}
+	return fmt.Sprintf("XML document (%s)", root.Data)
+}
+
+// xmlRoot returns the document element
+func xmlRoot(doc *xmlquery.Node) *xmlquery.Node {
+	for node := doc.FirstChild; node != nil; node = node.NextSibling {
+		if node.Type == xmlquery.ElementNode {
+			return node
+		}
+	}
+	return nil
+}
+
+// xmlValue converts one element to a string or an object
+func xmlValue(node *xmlquery.Node) interface{} {
+	object := make(map[string]interface{})
+	for _, attr := range node.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue // Namespace declarations are not data
+		}
+		object["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case xmlquery.ElementNode:
+			addProperty(object, child.Data, xmlValue(child))
+		case xmlquery.TextNode, xmlquery.CharDataNode:
+			text.WriteString(child.Data)
+		}
+	}
+
+	trimmed := strings.TrimSpace(text.String())
+	if len(object) == 0 {
+		return trimmed
+	}
+	if trimmed != "" {
+		object["#text"] = trimmed
+	}
+	return object
+}
+
+// ExtractXMLRecords applies a schema to an XML document. JSON schemas run
+// against the structure ParseXML produces, XPath schemas against the XML
+// itself with the schema's namespace prefixes. CSS selectors do not apply to
+// XML, so CSS schemas yield no records.
+func (s *Schema) ExtractXMLRecords(content []byte, baseURL string) ([]map[string]interface{}, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.IsJSON() {
+		document, err := ParseXML(string(content))
+		if err != nil {
+			return nil, err
+		}
+		return s.extractJSONDocument(document, baseURL), nil
+	}
+	if s.Container != "" || fieldsUse(s.Fields, func(f *Field) bool { return f.Selector != "" }) {
+		return nil, nil
+	}
+
+	doc, err := xmlquery.Parse(strings.NewReader(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %v", err)
+	}
+
+	e := &xmlExtractor{namespaces: s.Namespaces, xpaths: make(map[string]*xpath.Expr)}
+	e.base, _ = url.Parse(baseURL)
+
+	if s.ContainerXPath == "" {
+		return []map[string]interface{}{e.object(doc, s.Fields)}, nil
+	}
+
+	containers := e.find(doc, s.ContainerXPath)
+	records := make([]map[string]interface{}, 0, len(containers))
+	for _, container := range containers {
+		records = append(records, e.object(container, s.Fields))
+	}
+	return records, nil
+}
+
+// xmlExtractor holds the state of a single schema application to XML
+type xmlExtractor struct {
+	base       *url.URL
+	namespaces map[string]string
+	xpaths     map[string]*xpath.Expr
+}
+
+// object extracts every field within scope into a record
+func (e *xmlExtractor) object(scope *xmlquery.Node, fields []Field) map[string]interface{} {
+	record := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		record[field.Name] = e.field(scope, &field)
+	}
+	return record
+}
+
+// field extracts a single field, falling back to its default
+func (e *xmlExtractor) field(scope *xmlquery.Node, field *Field) interface{} {
+	matches := []*xmlquery.Node{scope}
+	if field.XPath != "" {
+		matches = e.find(scope, field.XPath)
+	}
+
+	if field.Multiple {
+		values := make([]interface{}, 0, len(matches))
+		for _, match := range matches {
+			if value := e.value(match, field); value != nil {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 && field.Default != nil {
+			return field.Default
+		}
+		return values
+	}
+
+	if len(matches) == 0 {
+		return field.Default
+	}
+	if value := e.value(matches[0], field); value != nil {
+		return value
+	}
+	return field.Default
+}
+
+// value reads and coerces the value of one matched node
+func (e *xmlExtractor) value(match *xmlquery.Node, field *Field) interface{} {
+	if len(field.Fields) > 0 {
+		return e.object(match, field.Fields)
+	}
+
+	var raw string
+	if field.Attr != "" {
+		raw = strings.TrimSpace(match.SelectAttr(field.Attr))
+	} else {
+		raw = strings.Join(strings.Fields(match.InnerText()), " ")
+	}
+	if raw == "" {
+		return nil
+	}
+
+	value, err := coerceText(raw, field, e.base)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// find evaluates an XPath expression relative to scope
+func (e *xmlExtractor) find(scope *xmlquery.Node, expr string) []*xmlquery.Node {
+	compiled, ok := e.xpaths[expr]
+	if !ok {
+		var err error
+		compiled, err = xpath.CompileWithNS(expr, e.namespaces)
+		if err != nil {
+			return nil // Syntax is validated by Schema.Validate
+		}
+		e.xpaths[expr] = compiled
+	}
+	return xmlquery.QuerySelectorAll(scope, compiled)
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+const soapResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:stations">
+  <soap:Body>
+    <m:GetStationsResponse>
+      <m:Station id="s1"><m:Name>Central</m:Name><m:Level unit="cm">142</m:Level></m:Station>
+      <m:Station id="s2"><m:Name>Harbour</m:Name><m:Level unit="cm">98.5</m:Level></m:Station>
+    </m:GetStationsResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestIsXML(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		expected    bool
+	}{
+		{"SOAP content type", soapResponse, "application/soap+xml; charset=utf-8", true},
+		{"text/xml", "<a/>", "text/xml", true},
+		{"XML declaration", soapResponse, "", true},
+		{"HTML", "<html></html>", "text/html", false},
+		{"XHTML", `<?xml version="1.0"?><!DOCTYPE html><html></html>`, "", false},
+		{"XHTML content type", "<html/>", "application/xhtml+xml", false},
+		{"JSON", `{"a": 1}`, "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsXML(tt.content, tt.contentType); got != tt.expected {
+				t.Errorf("IsXML() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	document, err := ParseXML(soapResponse)
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"Envelope": map[string]interface{}{
+			"Body": map[string]interface{}{
+				"GetStationsResponse": map[string]interface{}{
+					"Station": []interface{}{
+						map[string]interface{}{
+							"@id":   "s1",
+							"Name":  "Central",
+							"Level": map[string]interface{}{"@unit": "cm", "#text": "142"},
+						},
+						map[string]interface{}{
+							"@id":   "s2",
+							"Name":  "Harbour",
+							"Level": map[string]interface{}{"@unit": "cm", "#text": "98.5"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(document, expected) {
+		t.Errorf("ParseXML() =\n%#v\nwant\n%#v", document, expected)
+	}
+
+	if _, err := ParseXML("<open>"); err == nil {
+		t.Error("ParseXML() accepted an unterminated document")
+	}
+}
+
+func TestExtractXMLRecords(t *testing.T) {
+	expected := []map[string]interface{}{
+		{"id": "s1", "name": "Central", "level": 142.0},
+		{"id": "s2", "name": "Harbour", "level": 98.5},
+	}
+
+	schemas := map[string]*Schema{
+		"namespaced XPath": {
+			ContainerXPath: "//m:Station",
+			Namespaces:     map[string]string{"m": "urn:stations"},
+			Fields: []Field{
+				{Name: "id", Attr: "id"},
+				{Name: "name", XPath: "m:Name"},
+				{Name: "level", XPath: "m:Level", Type: FieldTypeFloat},
+			},
+		},
+		"JSONPath over converted XML": {
+			ContainerJSONPath: "$.Envelope.Body.GetStationsResponse.Station[*]",
+			Fields: []Field{
+				{Name: "id", JSONPath: "$['@id']"},
+				{Name: "name", JSONPath: "$.Name"},
+				{Name: "level", JSONPath: "$.Level['#text']", Type: FieldTypeFloat},
+			},
+		},
+	}
+
+	for name, schema := range schemas {
+		t.Run(name, func(t *testing.T) {
+			records, err := schema.ExtractXMLRecords([]byte(soapResponse), "https://example.com/soap")
+			if err != nil {
+				t.Fatalf("ExtractXMLRecords() error = %v", err)
+			}
+			if !reflect.DeepEqual(records, expected) {
+				t.Errorf("ExtractXMLRecords() = %v, want %v", records, expected)
+			}
+		})
+	}
+
+	// Prefixes are matched by namespace URI, not by the document's prefix
+	rebound := &Schema{
+		ContainerXPath: "//st:Station",
+		Namespaces:     map[string]string{"st": "urn:stations"},
+		Fields:         []Field{{Name: "id", Attr: "id"}},
+	}
+	if records, err := rebound.ExtractXMLRecords([]byte(soapResponse), ""); err != nil || len(records) != 2 {
+		t.Errorf("ExtractXMLRecords() with rebound prefix = %v, %v, want 2 records", records, err)
+	}
+	other := &Schema{
+		ContainerXPath: "//m:Station",
+		Namespaces:     map[string]string{"m": "urn:other"},
+		Fields:         []Field{{Name: "id", Attr: "id"}},
+	}
+	if records, err := other.ExtractXMLRecords([]byte(soapResponse), ""); err != nil || len(records) != 0 {
+		t.Errorf("ExtractXMLRecords() with another namespace = %v, %v, want no records", records, err)
+	}
+}
+
+func TestExtractXMLTitle(t *testing.T) {
+	if title := ExtractXMLTitle(`<?xml version="1.0"?><feed><Title> Water levels </Title></feed>`); title != "Water levels" {
+		t.Errorf("ExtractXMLTitle() = %q, want %q", title, "Water levels")
+	}
+	if title := ExtractXMLTitle(`<?xml version="1.0"?><data><value>1</value></data>`); title != "XML document (data)" {
+		t.Errorf("ExtractXMLTitle() = %q, want root element name", title)
+	}
+	if title := ExtractXMLTitle(`<broken`); title != "Invalid XML" {
+		t.Errorf("ExtractXMLTitle() = %q, want %q", title, "Invalid XML")
+	}
+}